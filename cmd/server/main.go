@@ -112,16 +112,28 @@ func run() error {
 	urlShortenerRepo := repository.NewURLShortenerRepository(queries)
 	pastebinRepo := repository.NewPastebinRepository(queries)
 	qrcodeRepo := repository.NewQRCodeRepository(queries)
+	pipelineRepo := repository.NewPipelineRepository(queries)
 
 	// Initialize JWT middleware
 	jwtMiddleware := middleware.NewJWTMiddleware(cfg.JWT.Secret)
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo, jwtMiddleware, cfg.JWT.Expiration, log.Logger)
+	authService := service.NewAuthService(userRepo, jwtMiddleware, cfg.JWT.Expiration, cfg.Auth.MinPasswordScore, log.Logger)
 	todoService := service.NewTodoService(todoRepo, log.Logger)
 	urlShortenerService := service.NewURLShortenerService(urlShortenerRepo)
 	pastebinService := service.NewPastebinService(pastebinRepo)
-	qrcodeService := service.NewQRCodeService(qrcodeRepo)
+	qrcodeService := service.NewQRCodeService(qrcodeRepo, urlShortenerService, cfg.Server.PublicURL)
+	pipelineService := service.NewPipelineService(pipelineRepo, service.NewHashService(), service.NewConverterService(), service.NewCryptoService(), service.NewGeneratorService())
+
+	// Start the expiry sweeper
+	sweeper := service.NewExpirySweeper(cfg.Sweeper.Interval, log.Logger)
+	sweeper.Register("short_urls", urlShortenerService.DeleteExpiredURLs)
+	sweeper.Register("pastes", pastebinService.DeleteExpiredPastes)
+	sweeper.Register("qr_codes", qrcodeService.DeleteExpiredQRCodes)
+
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+	go sweeper.Run(sweeperCtx)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
@@ -130,6 +142,7 @@ func run() error {
 	pastebinHandler := handler.NewPastebinHandler(pastebinService)
 	qrcodeHandler := handler.NewQRCodeHandler(qrcodeService)
 	utilityHandler := handler.NewUtilityHandler()
+	pipelineHandler := handler.NewPipelineHandler(pipelineService)
 
 	// Set Gin mode
 	if cfg.Log.Level == "debug" {
@@ -211,6 +224,7 @@ func run() error {
 		// URL Shortener
 		v1Public.POST("/shorten", urlShortenerHandler.CreateShortURL)
 		v1Public.GET("/shorten/:code", urlShortenerHandler.GetShortURL)
+		v1Public.PUT("/shorten/:code", urlShortenerHandler.UpdateShortURL)
 
 		// Pastebin
 		v1Public.POST("/paste", pastebinHandler.CreatePaste)
@@ -218,23 +232,48 @@ func run() error {
 		v1Public.GET("/paste/recent", pastebinHandler.ListRecentPastes)
 
 		// QR Code
-		v1Public.POST("/qr", qrcodeHandler.GenerateQR)
+		v1Public.POST("/qr", jwtMiddleware.OptionalAuthMiddleware(), qrcodeHandler.GenerateQR)
+		v1Public.GET("/qr", jwtMiddleware.AuthMiddleware(), qrcodeHandler.ListQRCodes)
+		v1Public.POST("/qr/decode", qrcodeHandler.DecodeQR)
+		v1Public.GET("/qr/render", qrcodeHandler.RenderQR)
 		v1Public.GET("/qr/:id", qrcodeHandler.GetQRCode)
+		v1Public.DELETE("/qr/:id", jwtMiddleware.OptionalAuthMiddleware(), qrcodeHandler.DeleteQRCode)
+
+		// Barcode
+		v1Public.POST("/barcode", jwtMiddleware.OptionalAuthMiddleware(), qrcodeHandler.GenerateBarcode)
+		v1Public.GET("/barcode/:id", qrcodeHandler.GetQRCode)
+		v1Public.DELETE("/barcode/:id", jwtMiddleware.OptionalAuthMiddleware(), qrcodeHandler.DeleteQRCode)
 
 		// Hash & Encode
 		v1Public.POST("/hash", utilityHandler.Hash)
+		v1Public.POST("/hash/file", utilityHandler.HashFile)
+		v1Public.POST("/hash/verify", utilityHandler.VerifyHash)
 		v1Public.POST("/encode", utilityHandler.Encode)
 		v1Public.POST("/generate/password", utilityHandler.GeneratePassword)
+		v1Public.POST("/password/analyze", utilityHandler.AnalyzePassword)
 
 		// Converter
 		v1Public.POST("/convert/base", utilityHandler.ConvertBase)
 		v1Public.POST("/convert/color", utilityHandler.ConvertColor)
 		v1Public.POST("/convert/time", utilityHandler.ConvertTime)
+		v1Public.POST("/time/calc", utilityHandler.CalculateTime)
+		v1Public.POST("/cron/parse", utilityHandler.ParseCron)
+		v1Public.POST("/convert/data", utilityHandler.ConvertYAML)
+
+		// Color
+		v1Public.POST("/color/contrast", utilityHandler.ColorContrast)
+		v1Public.POST("/color/palette", utilityHandler.ColorPalette)
+		v1Public.POST("/color/simulate", utilityHandler.SimulateColorBlindness)
 
 		// Formatter
 		v1Public.POST("/format/json", utilityHandler.FormatJSON)
 		v1Public.POST("/format/yaml", utilityHandler.ConvertYAML)
 
+		// JSON
+		v1Public.POST("/json/query", utilityHandler.QueryJSON)
+		v1Public.POST("/json/validate", utilityHandler.ValidateJSON)
+		v1Public.POST("/json/schema/infer", utilityHandler.InferJSONSchema)
+
 		// Generator
 		v1Public.POST("/generate/uuid", utilityHandler.GenerateUUID)
 		v1Public.POST("/generate/token", utilityHandler.GenerateToken)
@@ -247,6 +286,13 @@ func run() error {
 		v1Public.POST("/crypto/rsa/keygen", utilityHandler.GenerateRSAKeypair)
 		v1Public.POST("/crypto/rsa", utilityHandler.RSAOperation)
 		v1Public.POST("/crypto/hmac", utilityHandler.HMACOperation)
+
+		// Pipeline
+		v1Public.POST("/pipeline", jwtMiddleware.OptionalAuthMiddleware(), pipelineHandler.RunPipeline)
+		v1Public.GET("/pipeline/recipes", jwtMiddleware.AuthMiddleware(), pipelineHandler.ListRecipes)
+		v1Public.GET("/pipeline/recipes/:name", jwtMiddleware.AuthMiddleware(), pipelineHandler.GetRecipe)
+		v1Public.PUT("/pipeline/recipes/:name", jwtMiddleware.AuthMiddleware(), pipelineHandler.SaveRecipe)
+		v1Public.DELETE("/pipeline/recipes/:name", jwtMiddleware.AuthMiddleware(), pipelineHandler.DeleteRecipe)
 	}
 
 	// Create HTTP server
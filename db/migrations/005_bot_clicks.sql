@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE short_urls ADD COLUMN IF NOT EXISTS bot_clicks BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE url_clicks ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +migrate Down
+ALTER TABLE url_clicks DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE short_urls DROP COLUMN IF EXISTS bot_clicks;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE short_urls ADD COLUMN IF NOT EXISTS edit_token_hash VARCHAR(64);
+
+-- +migrate Down
+ALTER TABLE short_urls DROP COLUMN IF EXISTS edit_token_hash;
@@ -0,0 +1,27 @@
+-- +migrate Up
+-- QR codes are re-rendered on request from their text and style, so image_data is no longer written
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'text';
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS error_correction VARCHAR(1) NOT NULL DEFAULT 'M';
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS foreground VARCHAR(32);
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS background VARCHAR(32);
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS quiet_zone INT NOT NULL DEFAULT 4;
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS logo BYTEA;
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS short_code VARCHAR(20);
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+CREATE INDEX idx_qr_codes_user_id ON qr_codes(user_id);
+CREATE INDEX idx_qr_codes_expires_at ON qr_codes(expires_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_qr_codes_expires_at;
+DROP INDEX IF EXISTS idx_qr_codes_user_id;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS user_id;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS short_code;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS logo;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS quiet_zone;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS background;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS foreground;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS error_correction;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS type;
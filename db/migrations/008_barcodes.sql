@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS symbology VARCHAR(20) NOT NULL DEFAULT 'qr';
+ALTER TABLE qr_codes ADD COLUMN IF NOT EXISTS height INT;
+
+-- +migrate Down
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS height;
+ALTER TABLE qr_codes DROP COLUMN IF EXISTS symbology;
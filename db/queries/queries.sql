@@ -47,23 +47,34 @@ WHERE user_id = $1;
 
 -- URL Shortener Queries
 -- name: CreateShortURL :one
-INSERT INTO short_urls (code, original_url, alias, clicks, is_public, expires_at)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at;
+INSERT INTO short_urls (code, original_url, alias, clicks, is_public, expires_at, edit_token_hash)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at, bot_clicks, edit_token_hash;
 
 -- name: GetShortURLByCode :one
-SELECT id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at
+SELECT id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at, bot_clicks, edit_token_hash
 FROM short_urls
 WHERE code = $1;
 
+-- name: UpdateShortURLDestination :one
+UPDATE short_urls
+SET original_url = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at, bot_clicks, edit_token_hash;
+
 -- name: IncrementShortURLClicks :exec
 UPDATE short_urls
 SET clicks = clicks + 1, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1;
 
+-- name: IncrementShortURLBotClicks :exec
+UPDATE short_urls
+SET bot_clicks = bot_clicks + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
 -- name: CreateURLClick :exec
-INSERT INTO url_clicks (short_url_id, referrer, user_agent, ip_address)
-VALUES ($1, $2, $3, $4);
+INSERT INTO url_clicks (short_url_id, referrer, user_agent, ip_address, is_bot)
+VALUES ($1, $2, $3, $4, $5);
 
 -- name: DeleteExpiredShortURLs :exec
 DELETE FROM short_urls
@@ -97,11 +108,52 @@ WHERE expires_at IS NOT NULL AND expires_at < CURRENT_TIMESTAMP;
 
 -- QR Code Queries
 -- name: CreateQRCode :one
-INSERT INTO qr_codes (id, text, format, size, image_data)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, text, format, size, created_at;
+INSERT INTO qr_codes (id, symbology, type, text, format, size, height, error_correction, foreground, background, quiet_zone, logo, short_code, user_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id, created_at;
 
 -- name: GetQRCodeByID :one
-SELECT id, text, format, size, image_data, created_at
+SELECT id, symbology, type, text, format, size, height, error_correction, foreground, background, quiet_zone, logo, short_code, user_id, expires_at, created_at
+FROM qr_codes
+WHERE id = $1;
+
+-- name: ListQRCodesByUser :many
+SELECT id, symbology, type, text, format, size, height, error_correction, foreground, background, quiet_zone, short_code, user_id, expires_at, created_at
 FROM qr_codes
+WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountQRCodesByUser :one
+SELECT COUNT(*) FROM qr_codes
+WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP);
+
+-- name: DeleteQRCode :exec
+DELETE FROM qr_codes
 WHERE id = $1;
+
+-- name: DeleteExpiredQRCodes :exec
+DELETE FROM qr_codes
+WHERE expires_at IS NOT NULL AND expires_at < CURRENT_TIMESTAMP;
+
+-- Pipeline Recipe Queries
+-- name: UpsertPipelineRecipe :one
+INSERT INTO pipeline_recipes (user_id, name, steps)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, name) DO UPDATE SET steps = EXCLUDED.steps, updated_at = CURRENT_TIMESTAMP
+RETURNING id, created_at, updated_at;
+
+-- name: GetPipelineRecipe :one
+SELECT id, user_id, name, steps, created_at, updated_at
+FROM pipeline_recipes
+WHERE user_id = $1 AND name = $2;
+
+-- name: ListPipelineRecipesByUser :many
+SELECT id, user_id, name, steps, created_at, updated_at
+FROM pipeline_recipes
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: DeletePipelineRecipe :execrows
+DELETE FROM pipeline_recipes
+WHERE user_id = $1 AND name = $2;
@@ -12,9 +12,11 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
+	Auth     AuthConfig
 	Log      LogConfig
 	Metrics  MetricsConfig
 	Tracing  TracingConfig
+	Sweeper  SweeperConfig
 }
 
 type ServerConfig struct {
@@ -22,6 +24,7 @@ type ServerConfig struct {
 	Host         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	PublicURL    string // external base URL used in generated links
 }
 
 type DatabaseConfig struct {
@@ -38,6 +41,10 @@ type JWTConfig struct {
 	Expiration time.Duration
 }
 
+type AuthConfig struct {
+	MinPasswordScore int // minimum password strength score (0-4) required to register
+}
+
 type LogConfig struct {
 	Level  string
 	Format string
@@ -54,6 +61,10 @@ type TracingConfig struct {
 	Endpoint    string
 }
 
+type SweeperConfig struct {
+	Interval time.Duration
+}
+
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -67,6 +78,7 @@ func Load() (*Config, error) {
 	viper.SetDefault("server.host", "0.0.0.0")
 	viper.SetDefault("server.readTimeout", "15s")
 	viper.SetDefault("server.writeTimeout", "15s")
+	viper.SetDefault("server.publicURL", "http://localhost:8080")
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", "5432")
 	viper.SetDefault("database.user", "postgres")
@@ -75,6 +87,7 @@ func Load() (*Config, error) {
 	viper.SetDefault("database.sslmode", "disable")
 	viper.SetDefault("jwt.secret", "your-secret-key-change-this")
 	viper.SetDefault("jwt.expiration", "24h")
+	viper.SetDefault("auth.minPasswordScore", 2)
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
 	viper.SetDefault("metrics.enabled", true)
@@ -82,6 +95,7 @@ func Load() (*Config, error) {
 	viper.SetDefault("tracing.enabled", false)
 	viper.SetDefault("tracing.serviceName", "gopilot")
 	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("sweeper.interval", "1h")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -94,6 +108,7 @@ func Load() (*Config, error) {
 	cfg.Server.Host = viper.GetString("server.host")
 	cfg.Server.ReadTimeout = viper.GetDuration("server.readTimeout")
 	cfg.Server.WriteTimeout = viper.GetDuration("server.writeTimeout")
+	cfg.Server.PublicURL = strings.TrimRight(viper.GetString("server.publicURL"), "/")
 	cfg.Database.Host = viper.GetString("database.host")
 	cfg.Database.Port = viper.GetString("database.port")
 	cfg.Database.User = viper.GetString("database.user")
@@ -102,6 +117,7 @@ func Load() (*Config, error) {
 	cfg.Database.SSLMode = viper.GetString("database.sslmode")
 	cfg.JWT.Secret = viper.GetString("jwt.secret")
 	cfg.JWT.Expiration = viper.GetDuration("jwt.expiration")
+	cfg.Auth.MinPasswordScore = viper.GetInt("auth.minPasswordScore")
 	cfg.Log.Level = viper.GetString("log.level")
 	cfg.Log.Format = viper.GetString("log.format")
 	cfg.Metrics.Enabled = viper.GetBool("metrics.enabled")
@@ -109,6 +125,11 @@ func Load() (*Config, error) {
 	cfg.Tracing.Enabled = viper.GetBool("tracing.enabled")
 	cfg.Tracing.ServiceName = viper.GetString("tracing.serviceName")
 	cfg.Tracing.Endpoint = viper.GetString("tracing.endpoint")
+	cfg.Sweeper.Interval = viper.GetDuration("sweeper.interval")
+
+	if cfg.Auth.MinPasswordScore < 0 || cfg.Auth.MinPasswordScore > 4 {
+		return nil, fmt.Errorf("auth.minPasswordScore must be between 0 and 4, got %d", cfg.Auth.MinPasswordScore)
+	}
 
 	return &cfg, nil
 }
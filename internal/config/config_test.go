@@ -42,6 +42,26 @@ func TestLoadWithEnv(t *testing.T) {
 	}
 }
 
+func TestLoadRejectsInvalidMinPasswordScore(t *testing.T) {
+	defer os.Unsetenv("AUTH_MINPASSWORDSCORE")
+
+	for _, score := range []string{"-1", "5"} {
+		os.Setenv("AUTH_MINPASSWORDSCORE", score)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for minimum password score %s, got nil", score)
+		}
+	}
+
+	os.Setenv("AUTH_MINPASSWORDSCORE", "4")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Auth.MinPasswordScore != 4 {
+		t.Errorf("Expected minimum password score 4 from env, got %d", cfg.Auth.MinPasswordScore)
+	}
+}
+
 func TestDatabaseDSN(t *testing.T) {
 	// Clear any existing DATABASE_DBNAME environment variable to ensure default value
 	originalDBName := os.Getenv("DATABASE_DBNAME")
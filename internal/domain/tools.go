@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // URL Shortener models
 type ShortURL struct {
@@ -9,10 +12,15 @@ type ShortURL struct {
 	OriginalURL string     `json:"original_url"`
 	Alias       *string    `json:"alias,omitempty"`
 	Clicks      int64      `json:"clicks"`
+	BotClicks   int64      `json:"bot_clicks"`
 	IsPublic    bool       `json:"is_public"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Editable    bool       `json:"editable"`
+	EditToken   string     `json:"edit_token,omitempty"` // only returned on creation
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	EditTokenHash *string `json:"-"`
 }
 
 type CreateShortURLRequest struct {
@@ -20,6 +28,12 @@ type CreateShortURLRequest struct {
 	Alias       *string `json:"alias" binding:"omitempty,min=3,max=50,alphanum"`
 	ExpireIn    *int    `json:"expire_in" binding:"omitempty,min=1"` // in hours
 	IsPublic    *bool   `json:"is_public"`
+	Editable    *bool   `json:"editable"` // returns an edit token for changing the destination later
+}
+
+type UpdateShortURLRequest struct {
+	OriginalURL string `json:"original_url" binding:"required,url"`
+	EditToken   string `json:"edit_token" binding:"required"`
 }
 
 type URLClickLog struct {
@@ -28,6 +42,7 @@ type URLClickLog struct {
 	Referrer   *string   `json:"referrer,omitempty"`
 	UserAgent  *string   `json:"user_agent,omitempty"`
 	IPAddress  *string   `json:"ip_address,omitempty"`
+	IsBot      bool      `json:"is_bot"`
 	ClickedAt  time.Time `json:"clicked_at"`
 }
 
@@ -55,101 +70,512 @@ type CreatePasteRequest struct {
 
 // QR Code models
 type QRCode struct {
-	ID        string    `json:"id"`
-	Text      string    `json:"text"`
-	Format    string    `json:"format"`
-	Size      int       `json:"size"`
-	ImageData []byte    `json:"-"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              string     `json:"id"`
+	Symbology       string     `json:"symbology"`
+	Type            string     `json:"type"`
+	Text            string     `json:"text"`
+	Format          string     `json:"format"`
+	Size            int        `json:"size"`             // width for barcodes
+	Height          *int       `json:"height,omitempty"` // barcodes only
+	ErrorCorrection string     `json:"error_correction"`
+	Foreground      *string    `json:"foreground,omitempty"`
+	Background      *string    `json:"background,omitempty"`
+	QuietZone       int        `json:"quiet_zone"`
+	Logo            []byte     `json:"-"`
+	UserID          *int64     `json:"user_id,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ImageData       []byte     `json:"-"` // rendered on request, not stored
+
+	// Set for dynamic QR codes
+	ShortCode   *string `json:"short_code,omitempty"`
+	Destination string  `json:"destination,omitempty"`
+	EditToken   string  `json:"edit_token,omitempty"`
 }
 
 type GenerateQRRequest struct {
-	Text   string  `json:"text" binding:"required,max=1000"`
-	Size   *int    `json:"size" binding:"omitempty,min=64,max=2048"`
-	Format *string `json:"format" binding:"omitempty,oneof=png svg"`
+	Type            *string `json:"type" form:"type" binding:"omitempty,oneof=text wifi vcard mecard geo vevent sms mailto epc"` // default text
+	Text            string  `json:"text" form:"text" binding:"max=1000"`                                                         // required for type text
+	Size            *int    `json:"size" form:"size" binding:"omitempty,min=64,max=2048"`
+	Format          *string `json:"format" form:"format" binding:"omitempty,oneof=png svg eps text utf8"`
+	ErrorCorrection *string `json:"error_correction" form:"error_correction" binding:"omitempty,oneof=L M Q H"`
+	Foreground      *string `json:"foreground" form:"foreground" binding:"omitempty,max=32"`       // CSS color, default black
+	Background      *string `json:"background" form:"background" binding:"omitempty,max=32"`       // CSS color, default white
+	QuietZone       *int    `json:"quiet_zone" form:"quiet_zone" binding:"omitempty,min=0,max=16"` // in modules, default 4
+	Logo            []byte  `json:"logo" form:"-" binding:"max=2097152"`                           // PNG, JPEG or GIF, base64 in JSON or a "logo" file in multipart forms; png format only
+	Dynamic         bool    `json:"dynamic" form:"dynamic"`                                        // encode an editable short URL pointing at the text URL
+	ExpireIn        *int    `json:"expire_in" form:"expire_in" binding:"omitempty,min=1"`          // in hours
+
+	// Structured payloads, used according to Type
+	WiFi    *QRWiFiPayload    `json:"wifi" form:"-"`
+	Contact *QRContactPayload `json:"contact" form:"-"` // for vcard and mecard
+	Geo     *QRGeoPayload     `json:"geo" form:"-"`
+	Event   *QREventPayload   `json:"event" form:"-"` // for vevent
+	SMS     *QRSMSPayload     `json:"sms" form:"-"`
+	Mailto  *QRMailtoPayload  `json:"mailto" form:"-"`
+	Payment *QRPaymentPayload `json:"payment" form:"-"` // for epc (SEPA credit transfer)
+}
+
+type GetQRCodeRequest struct {
+	Size *int `form:"size" binding:"omitempty,min=64,max=2048"` // default is the size chosen at generation
+}
+
+type GenerateBarcodeRequest struct {
+	Symbology     string  `json:"symbology" binding:"required,oneof=code128 code39 ean8 ean13 upca datamatrix aztec pdf417"`
+	Text          string  `json:"text" binding:"required,max=1000"`
+	Width         *int    `json:"width" binding:"omitempty,min=16,max=2048"`  // in pixels, default 2 px per module for linear codes
+	Height        *int    `json:"height" binding:"omitempty,min=16,max=2048"` // in pixels, default 100 for linear codes
+	Format        *string `json:"format" binding:"omitempty,oneof=png svg"`
+	Foreground    *string `json:"foreground" binding:"omitempty,max=32"`          // CSS color, default black
+	Background    *string `json:"background" binding:"omitempty,max=32"`          // CSS color, default white
+	QuietZone     *int    `json:"quiet_zone" binding:"omitempty,min=0,max=20"`    // in modules, default depends on symbology
+	Checksum      bool    `json:"checksum"`                                       // append the optional Code 39 mod 43 check character
+	SecurityLevel *int    `json:"security_level" binding:"omitempty,min=0,max=8"` // PDF417 error correction level, default 2
+	ExpireIn      *int    `json:"expire_in" binding:"omitempty,min=1"`            // in hours
+}
+
+type RenderQRRequest struct {
+	Text            string  `form:"text" binding:"required,max=1000"`
+	Size            *int    `form:"size" binding:"omitempty,min=64,max=2048"`
+	Format          *string `form:"format" binding:"omitempty,oneof=png svg eps text utf8"`
+	ErrorCorrection *string `form:"error_correction" binding:"omitempty,oneof=L M Q H"`
+	Foreground      *string `form:"foreground" binding:"omitempty,max=32"`
+	Background      *string `form:"background" binding:"omitempty,max=32"`
+	QuietZone       *int    `form:"quiet_zone" binding:"omitempty,min=0,max=16"`
+}
+
+type QRWiFiPayload struct {
+	SSID     string `json:"ssid" binding:"required,max=32"`
+	Auth     string `json:"auth" binding:"omitempty,oneof=WPA WEP nopass"` // default WPA
+	Password string `json:"password" binding:"max=63"`
+	Hidden   bool   `json:"hidden"`
+}
+
+type QRContactPayload struct {
+	FirstName    string `json:"first_name" binding:"max=100"`
+	LastName     string `json:"last_name" binding:"max=100"`
+	Organization string `json:"organization" binding:"max=100"`
+	Title        string `json:"title" binding:"max=100"`
+	Phone        string `json:"phone" binding:"max=30"`
+	Email        string `json:"email" binding:"omitempty,email"`
+	Address      string `json:"address" binding:"max=200"`
+	URL          string `json:"url" binding:"omitempty,url"`
+	Note         string `json:"note" binding:"max=300"`
+}
+
+type QRGeoPayload struct {
+	Latitude  float64  `json:"latitude" binding:"min=-90,max=90"`
+	Longitude float64  `json:"longitude" binding:"min=-180,max=180"`
+	Altitude  *float64 `json:"altitude"`
+	Label     string   `json:"label" binding:"max=100"`
+}
+
+type QREventPayload struct {
+	Summary     string     `json:"summary" binding:"required,max=200"`
+	Start       time.Time  `json:"start" binding:"required"`
+	End         *time.Time `json:"end"`
+	Location    string     `json:"location" binding:"max=200"`
+	Description string     `json:"description" binding:"max=500"`
+}
+
+type QRSMSPayload struct {
+	Phone   string `json:"phone" binding:"required,max=30"`
+	Message string `json:"message" binding:"max=500"`
+}
+
+type QRMailtoPayload struct {
+	To      string `json:"to" binding:"required,email"`
+	Cc      string `json:"cc" binding:"omitempty,email"`
+	Subject string `json:"subject" binding:"max=200"`
+	Body    string `json:"body" binding:"max=500"`
+}
+
+type QRPaymentPayload struct {
+	Name       string  `json:"name" binding:"required,max=70"`
+	IBAN       string  `json:"iban" binding:"required,max=34"`
+	BIC        string  `json:"bic" binding:"omitempty,max=11"`
+	Amount     float64 `json:"amount" binding:"omitempty,min=0.01,max=999999999.99"` // in EUR
+	Remittance string  `json:"remittance" binding:"max=140"`
+}
+
+type DecodeQRRequest struct {
+	Image []byte `json:"image" form:"-"` // base64 in JSON, "image" file in multipart forms; PNG, JPEG or GIF
+}
+
+type QRPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type QRBounds struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type DecodedQRCode struct {
+	Text   string    `json:"text"`
+	Bounds QRBounds  `json:"bounds"`
+	Points []QRPoint `json:"points"` // finder (and alignment) pattern centers
+}
+
+type DecodeQRResponse struct {
+	Codes []DecodedQRCode `json:"codes"`
+	Count int             `json:"count"`
 }
 
 // Hash & Encode models
 type HashRequest struct {
-	Text      string  `json:"text" binding:"required"`
-	Algorithm string  `json:"algorithm" binding:"required,oneof=md5 sha1 sha256 sha512 bcrypt"`
-	Salt      *string `json:"salt"`
+	Text       string      `json:"text" binding:"required"`
+	Algorithm  string      `json:"algorithm" binding:"required_without=Algorithms,omitempty,oneof=md5 sha1 sha224 sha256 sha384 sha512 sha3-224 sha3-256 sha3-384 sha3-512 blake2b-256 blake2b-384 blake2b-512 blake2s-256 blake3 crc32 crc32c crc64-iso crc64-ecma xxhash64 bcrypt argon2id argon2i scrypt pbkdf2-sha256 pbkdf2-sha512"`
+	Algorithms []string    `json:"algorithms" binding:"omitempty,max=20,dive,oneof=md5 sha1 sha224 sha256 sha384 sha512 sha3-224 sha3-256 sha3-384 sha3-512 blake2b-256 blake2b-384 blake2b-512 blake2s-256 blake3 crc32 crc32c crc64-iso crc64-ecma xxhash64"` // computed in one pass
+	Salt       *string     `json:"salt"`
+	Params     *HashParams `json:"params"` // cost parameters for password hashing algorithms
+}
+
+// HashParams tunes the cost of password hashing algorithms; omitted values use recommended defaults
+type HashParams struct {
+	Cost        *int `json:"cost" binding:"omitempty,min=4,max=14"`            // bcrypt cost
+	Memory      *int `json:"memory" binding:"omitempty,min=8,max=262144"`      // argon2 memory in KiB
+	Iterations  *int `json:"iterations" binding:"omitempty,min=1,max=2000000"` // argon2 passes or PBKDF2 iterations
+	Parallelism *int `json:"parallelism" binding:"omitempty,min=1,max=8"`      // argon2 lanes or scrypt p
+	LogN        *int `json:"log_n" binding:"omitempty,min=1,max=20"`           // scrypt cost as log2(N)
+	BlockSize   *int `json:"block_size" binding:"omitempty,min=1,max=16"`      // scrypt r
+}
+
+type VerifyHashRequest struct {
+	Text string `json:"text" binding:"required"`
+	Hash string `json:"hash" binding:"required,max=1024"`
+}
+
+type VerifyHashResponse struct {
+	Valid     bool   `json:"valid"`
+	Algorithm string `json:"algorithm"`
 }
 
 type HashResponse struct {
-	Hash      string `json:"hash"`
+	Hash      string       `json:"hash,omitempty"`
+	Base64    string       `json:"base64,omitempty"`
+	Algorithm string       `json:"algorithm,omitempty"`
+	Digests   []HashDigest `json:"digests,omitempty"`
+}
+
+type HashDigest struct {
 	Algorithm string `json:"algorithm"`
+	Hex       string `json:"hex"`
+	Base64    string `json:"base64"`
+}
+
+type HashFileResponse struct {
+	Filename string       `json:"filename"`
+	Size     int64        `json:"size"`
+	Digests  []HashDigest `json:"digests"`
+}
+
+type AnalyzePasswordRequest struct {
+	Password   string   `json:"password" binding:"required,max=256"`
+	UserInputs []string `json:"user_inputs" binding:"omitempty,max=20,dive,max=100"` // e.g. username or email, treated as a dictionary
+}
+
+type PasswordAnalysis struct {
+	Score          int              `json:"score"`           // 0 (weakest) to 4 (strongest)
+	Entropy        float64          `json:"entropy"`         // in bits, after pattern matching
+	CharsetEntropy float64          `json:"charset_entropy"` // in bits, assuming random characters
+	Length         int              `json:"length"`
+	CrackTimes     CrackTimes       `json:"crack_times"`
+	Matches        []PasswordMatch  `json:"matches"`
+	Feedback       PasswordFeedback `json:"feedback"`
+}
+
+type CrackTimes struct {
+	OnlineThrottled   CrackTimeEstimate `json:"online_throttled"`   // 100 guesses per hour
+	OnlineUnthrottled CrackTimeEstimate `json:"online_unthrottled"` // 10 guesses per second
+	OfflineSlowHash   CrackTimeEstimate `json:"offline_slow_hash"`  // 10k guesses per second
+	OfflineFastHash   CrackTimeEstimate `json:"offline_fast_hash"`  // 10B guesses per second
+}
+
+type CrackTimeEstimate struct {
+	Seconds float64 `json:"seconds"`
+	Display string  `json:"display"`
+}
+
+type PasswordMatch struct {
+	Pattern    string `json:"pattern"`
+	Token      string `json:"token"`
+	Dictionary string `json:"dictionary,omitempty"`
+}
+
+type PasswordFeedback struct {
+	Warning     string   `json:"warning,omitempty"`
+	Suggestions []string `json:"suggestions"`
 }
 
 type EncodeRequest struct {
-	Text      string `json:"text" binding:"required"`
-	Operation string `json:"operation" binding:"required,oneof=base64-encode base64-decode url-encode url-decode hex-encode hex-decode"`
+	Text           string  `json:"text" binding:"required,max=1048576"`
+	Operation      string  `json:"operation" binding:"required,oneof=base64-encode base64-decode base64url-encode base64url-decode base64-raw-encode base64-raw-decode base64url-raw-encode base64url-raw-decode base32-encode base32-decode base32-raw-encode base32-raw-decode base58-encode base58-decode base85-encode base85-decode ascii85-encode ascii85-decode hex-encode hex-decode url-encode url-decode quoted-printable-encode quoted-printable-decode html-encode html-decode unicode-encode unicode-decode punycode-encode punycode-decode rot13-encode rot13-decode"`
+	InputEncoding  *string `json:"input_encoding" binding:"omitempty,oneof=text hex base64"` // how text is converted to bytes, for binary input
+	BinaryEncoding *string `json:"binary_encoding" binding:"omitempty,oneof=hex base64"`     // encoding of binary results, default base64
 }
 
 type EncodeResponse struct {
 	Result    string `json:"result"`
 	Operation string `json:"operation"`
+	Binary    bool   `json:"binary,omitempty"`   // result is not valid UTF-8 and is encoded
+	Encoding  string `json:"encoding,omitempty"` // hex or base64 for binary results
 }
 
 type GeneratePasswordRequest struct {
-	Length         *int  `json:"length" binding:"omitempty,min=8,max=128"`
-	IncludeUpper   *bool `json:"include_upper"`
-	IncludeLower   *bool `json:"include_lower"`
-	IncludeNumbers *bool `json:"include_numbers"`
-	IncludeSymbols *bool `json:"include_symbols"`
+	Mode             *string `json:"mode" binding:"omitempty,oneof=random passphrase pronounceable"`
+	Length           *int    `json:"length" binding:"omitempty,min=8,max=128"`
+	IncludeUpper     *bool   `json:"include_upper"`
+	IncludeLower     *bool   `json:"include_lower"`
+	IncludeNumbers   *bool   `json:"include_numbers"` // in passphrases, appends a digit to a random word
+	IncludeSymbols   *bool   `json:"include_symbols"`
+	RequireEachClass *bool   `json:"require_each_class"`                     // at least one character of each included type
+	ExcludeAmbiguous *bool   `json:"exclude_ambiguous"`                      // excludes look-alike characters such as I, l, 1, O and 0
+	Words            *int    `json:"words" binding:"omitempty,min=3,max=20"` // passphrase word count
+	Separator        *string `json:"separator" binding:"omitempty,max=5"`    // passphrase word separator
+	Capitalize       *bool   `json:"capitalize"`                             // capitalizes passphrase words
 }
 
 type GeneratePasswordResponse struct {
-	Password string `json:"password"`
-	Length   int    `json:"length"`
+	Password string  `json:"password"`
+	Length   int     `json:"length"`
+	Mode     string  `json:"mode"`
+	Words    int     `json:"words,omitempty"`
+	Entropy  float64 `json:"entropy"` // in bits
 }
 
 // Converter models
 type ConvertBaseRequest struct {
-	Value    string `json:"value" binding:"required"`
-	FromBase *int   `json:"from_base" binding:"omitempty,min=2,max=64"`
-	ToBase   int    `json:"to_base" binding:"required,min=2,max=64"`
+	Value        string  `json:"value" binding:"required,max=1024"`
+	FromBase     *int    `json:"from_base" binding:"omitempty,min=2,max=64"`
+	ToBase       int     `json:"to_base" binding:"required,min=2,max=64"`
+	FromAlphabet *string `json:"from_alphabet" binding:"omitempty,oneof=standard base62 base64 base64url crockford"` // digits of the input base
+	ToAlphabet   *string `json:"to_alphabet" binding:"omitempty,oneof=standard base62 base64 base64url crockford"`   // digits of the output base
+	Precision    *int    `json:"precision" binding:"omitempty,min=0,max=256"`                                        // maximum fractional digits, default 20
+	BitWidth     *int    `json:"bit_width" binding:"omitempty,min=2,max=1024"`                                       // outputs two's complement padded to this width
+	SignedInput  *bool   `json:"signed_input"`                                                                       // reads the input as a two's complement value of bit_width bits
 }
 
 type ConvertBaseResponse struct {
-	Original string `json:"original"`
-	Result   string `json:"result"`
-	FromBase int    `json:"from_base"`
-	ToBase   int    `json:"to_base"`
+	Original  string `json:"original"`
+	Result    string `json:"result"`
+	FromBase  int    `json:"from_base"`
+	ToBase    int    `json:"to_base"`
+	Truncated bool   `json:"truncated,omitempty"` // fractional digits were cut at the precision
 }
 
 type ConvertColorRequest struct {
-	Value string `json:"value" binding:"required"`
-	To    string `json:"to" binding:"required,oneof=rgb hex hsl"`
+	Value string  `json:"value" binding:"required,max=100"`
+	From  *string `json:"from" binding:"omitempty,oneof=hex rgb hsl hsv hwb cmyk lab lch oklab oklch name"` // detected when omitted
+	To    *string `json:"to" binding:"omitempty,oneof=hex rgb hsl hsv hwb cmyk lab lch oklab oklch name"`   // format of result, default hex
 }
 
 type ConvertColorResponse struct {
-	Original string `json:"original"`
-	Result   string `json:"result"`
-	Format   string `json:"format"`
+	Original string       `json:"original"`
+	Result   string       `json:"result"`
+	Format   string       `json:"format"`
+	Detected string       `json:"detected"` // format of the input
+	InGamut  bool         `json:"in_gamut"` // false when sRGB formats are clipped
+	Formats  ColorFormats `json:"formats"`
+}
+
+// ColorFormats holds a color in every supported format
+type ColorFormats struct {
+	Hex   string `json:"hex"`
+	RGB   string `json:"rgb"`
+	HSL   string `json:"hsl"`
+	HSV   string `json:"hsv"`
+	HWB   string `json:"hwb"`
+	CMYK  string `json:"cmyk"`
+	Lab   string `json:"lab"`
+	LCH   string `json:"lch"`
+	Oklab string `json:"oklab"`
+	Oklch string `json:"oklch"`
+	Name  string `json:"name,omitempty"` // CSS named color with exactly this value
+}
+
+type ColorContrastRequest struct {
+	Foreground string `json:"foreground" binding:"required,max=100"`
+	Background string `json:"background" binding:"required,max=100"`
+}
+
+type ColorContrastResponse struct {
+	Foreground string      `json:"foreground"` // hex after compositing translucent colors
+	Background string      `json:"background"`
+	Ratio      float64     `json:"ratio"` // WCAG 2.x contrast ratio from 1 to 21
+	WCAG       WCAGResults `json:"wcag"`
+	APCA       float64     `json:"apca"` // APCA lightness contrast (Lc), negative for light text on dark backgrounds
+}
+
+// WCAGResults reports the WCAG 2.x success criteria a contrast ratio meets
+type WCAGResults struct {
+	AANormal  bool `json:"aa_normal"`  // 4.5:1
+	AALarge   bool `json:"aa_large"`   // 3:1, also applies to UI components
+	AAANormal bool `json:"aaa_normal"` // 7:1
+	AAALarge  bool `json:"aaa_large"`  // 4.5:1
+}
+
+type ColorPaletteRequest struct {
+	Color  string  `json:"color" binding:"required,max=100"`
+	Scheme string  `json:"scheme" binding:"required,oneof=complementary split-complementary triadic tetradic analogous tints shades scale"`
+	Count  *int    `json:"count" binding:"omitempty,min=2,max=20"` // colors in analogous, tints, shades and scale schemes, default 5
+	Format *string `json:"format" binding:"omitempty,oneof=hex rgb hsl hsv hwb cmyk lab lch oklab oklch"`
+}
+
+type ColorPaletteResponse struct {
+	Scheme string   `json:"scheme"`
+	Colors []string `json:"colors"`
+}
+
+type SimulateColorBlindnessRequest struct {
+	Color    string   `json:"color" binding:"required,max=100"`
+	Severity *float64 `json:"severity" binding:"omitempty,min=0,max=1"` // severity of the anomalous trichromacies, default 0.6
+}
+
+type SimulateColorBlindnessResponse struct {
+	Original      string `json:"original"` // hex
+	Protanopia    string `json:"protanopia"`
+	Protanomaly   string `json:"protanomaly"`
+	Deuteranopia  string `json:"deuteranopia"`
+	Deuteranomaly string `json:"deuteranomaly"`
+	Tritanopia    string `json:"tritanopia"`
+	Tritanomaly   string `json:"tritanomaly"`
+	Achromatopsia string `json:"achromatopsia"`
 }
 
 type ConvertTimeRequest struct {
-	Value string `json:"value" binding:"required"`
-	From  string `json:"from" binding:"required,oneof=unix iso8601"`
-	To    string `json:"to" binding:"required,oneof=unix iso8601 human"`
+	Value      string  `json:"value" binding:"required,max=200"`
+	From       *string `json:"from" binding:"omitempty,oneof=auto unix unix_ms unix_us unix_ns iso8601 rfc1123 rfc822 iso_week layout strftime"` // detected when omitted
+	To         string  `json:"to" binding:"required,oneof=unix unix_ms unix_us unix_ns iso8601 rfc1123 rfc822 iso_week human relative layout strftime"`
+	FromLayout *string `json:"from_layout" binding:"omitempty,max=100"` // Go layout or strftime format of the input
+	ToLayout   *string `json:"to_layout" binding:"omitempty,max=100"`   // Go layout or strftime format of the result
+	FromTZ     *string `json:"from_tz" binding:"omitempty,max=64"`      // IANA zone of inputs without an offset, default UTC
+	ToTZ       *string `json:"to_tz" binding:"omitempty,max=64"`        // IANA zone of the result, default the input's zone
 }
 
 type ConvertTimeResponse struct {
 	Original string `json:"original"`
 	Result   string `json:"result"`
+	Detected string `json:"detected"` // format of the input
+	Timezone string `json:"timezone"` // zone of the result
+}
+
+type CronParseRequest struct {
+	Expression string  `json:"expression" binding:"required,max=200"`   // 5 or 6 fields (with seconds) or a macro such as @daily or @every 1h30m
+	Count      *int    `json:"count" binding:"omitempty,min=1,max=100"` // next runs to list, default 5
+	Timezone   *string `json:"timezone" binding:"omitempty,max=64"`     // IANA zone the schedule runs in, default UTC
+	Start      *string `json:"start" binding:"omitempty,max=200"`       // list runs after this time in any format convert/time detects, default now
+}
+
+type CronParseResponse struct {
+	Expression  string      `json:"expression"`
+	Description string      `json:"description"`
+	Fields      *CronFields `json:"fields,omitempty"`   // omitted for @every
+	Interval    string      `json:"interval,omitempty"` // @every only
+	Timezone    string      `json:"timezone"`
+	NextRuns    []string    `json:"next_runs"` // RFC 3339 in the schedule's zone
+}
+
+type CronFields struct {
+	Second     string `json:"second"`
+	Minute     string `json:"minute"`
+	Hour       string `json:"hour"`
+	DayOfMonth string `json:"day_of_month"`
+	Month      string `json:"month"`
+	DayOfWeek  string `json:"day_of_week"`
+}
+
+type TimeCalcRequest struct {
+	Operation string   `json:"operation" binding:"required,oneof=add subtract diff convert"`
+	Time      *string  `json:"time" binding:"omitempty,max=200"`                               // start time in any format convert/time detects, default now
+	End       *string  `json:"end" binding:"omitempty,max=200"`                                // end time for diff, default now
+	Duration  *string  `json:"duration" binding:"omitempty,max=100"`                           // Go (1h30m) or ISO 8601 (P1DT2H) duration for add, subtract and convert
+	Timezone  *string  `json:"timezone" binding:"omitempty,max=64"`                            // IANA zone of inputs without an offset, results and day counts
+	Holidays  []string `json:"holidays" binding:"omitempty,max=1000,dive,datetime=2006-01-02"` // dates excluded from business days
+	Format    *string  `json:"format" binding:"omitempty,oneof=unix unix_ms unix_us unix_ns iso8601 rfc1123 rfc822 iso_week human relative layout strftime"`
+	Layout    *string  `json:"layout" binding:"omitempty,max=100"` // Go layout or strftime format of the result
+}
+
+type TimeCalcResponse struct {
+	Operation    string         `json:"operation"`
+	Result       string         `json:"result,omitempty"`        // add and subtract
+	Timezone     string         `json:"timezone,omitempty"`      // zone of the result or day counts
+	Duration     *DurationUnits `json:"duration,omitempty"`      // diff and convert
+	CalendarDays *int           `json:"calendar_days,omitempty"` // diff
+	BusinessDays *int           `json:"business_days,omitempty"` // diff, Monday to Friday excluding holidays
+}
+
+type DurationUnits struct {
+	Go           string  `json:"go"`
+	ISO8601      string  `json:"iso8601"`
+	Nanoseconds  int64   `json:"nanoseconds"`
+	Milliseconds float64 `json:"milliseconds"`
+	Seconds      float64 `json:"seconds"`
+	Minutes      float64 `json:"minutes"`
+	Hours        float64 `json:"hours"`
+	Days         float64 `json:"days"`
+	Weeks        float64 `json:"weeks"`
 }
 
 type FormatJSONRequest struct {
-	JSON   string `json:"json" binding:"required"`
-	Minify *bool  `json:"minify"`
-	Indent *int   `json:"indent" binding:"omitempty,min=0,max=8"`
+	JSON          string  `json:"json" binding:"required"`
+	Minify        *bool   `json:"minify"`
+	Indent        *int    `json:"indent" binding:"omitempty,min=0,max=8"`
+	UseTabs       *bool   `json:"use_tabs"`                                          // indent with one tab per level
+	SortKeys      *bool   `json:"sort_keys"`                                         // sort object keys recursively, original order by default
+	Syntax        *string `json:"syntax" binding:"omitempty,oneof=json jsonc json5"` // input syntax, json by default
+	StripComments *bool   `json:"strip_comments"`                                    // drop comments of jsonc and json5 input
 }
 
 type FormatJSONResponse struct {
 	Result string `json:"result"`
 }
 
+type JSONQueryRequest struct {
+	JSON     string  `json:"json" binding:"required,max=1048576"`
+	Query    string  `json:"query" binding:"required,max=4096"`
+	Language *string `json:"language" binding:"omitempty,oneof=jsonpath jq"` // jsonpath by default
+}
+
+type JSONQueryResponse struct {
+	Language  string            `json:"language"`
+	Results   []json.RawMessage `json:"results"`
+	Paths     []string          `json:"paths,omitempty"` // normalized paths of JSONPath results
+	Count     int               `json:"count"`
+	Truncated bool              `json:"truncated"` // results past the count or size limit were dropped
+}
+
+type JSONValidateRequest struct {
+	JSON         string  `json:"json" binding:"required,max=1048576"`
+	Schema       string  `json:"schema" binding:"required,max=1048576"`
+	Draft        *string `json:"draft" binding:"omitempty,oneof=draft-07 2020-12"` // used when the schema has no $schema, 2020-12 by default
+	AssertFormat *bool   `json:"assert_format"`                                    // assert "format" under 2020-12 too, draft-07 always does
+}
+
+type JSONValidateResponse struct {
+	Valid  bool              `json:"valid"`
+	Errors []JSONSchemaError `json:"errors"`
+}
+
+type JSONSchemaError struct {
+	InstancePath string `json:"instance_path"` // JSON Pointer into the document
+	SchemaPath   string `json:"schema_path"`   // JSON Pointer to the failing keyword
+	Message      string `json:"message"`
+}
+
+type JSONSchemaInferRequest struct {
+	Samples []string `json:"samples" binding:"required,min=1,max=100,dive,max=65536"`
+	Draft   *string  `json:"draft" binding:"omitempty,oneof=draft-07 2020-12"` // 2020-12 by default
+	Formats *bool    `json:"formats"`                                          // detect string formats, true by default
+}
+
+type JSONSchemaInferResponse struct {
+	Schema json.RawMessage `json:"schema"`
+}
+
 // Generator models
 type GenerateUUIDRequest struct {
 	Version *int `json:"version" binding:"omitempty,oneof=1 4 7"`
@@ -208,12 +634,15 @@ type GenerateRandomNumberResponse struct {
 
 // Format models
 type ConvertYAMLRequest struct {
-	Content string `json:"content" binding:"required"`
-	To      string `json:"to" binding:"required,oneof=json yaml"`
+	Content   string  `json:"content" binding:"required,max=1048576"`
+	From      *string `json:"from" binding:"omitempty,oneof=json yaml toml xml csv tsv ini env hcl"` // detected as JSON, XML or YAML when omitted
+	To        string  `json:"to" binding:"required,oneof=json yaml toml xml csv tsv ini env hcl"`
+	CSVHeader *bool   `json:"csv_header"` // whether CSV/TSV input starts with a header row, inferred when omitted
 }
 
 type ConvertYAMLResponse struct {
 	Result string `json:"result"`
+	From   string `json:"from"` // format the content was read as
 }
 
 // Crypto models
@@ -254,3 +683,46 @@ type HMACResponse struct {
 	Signature *string `json:"signature,omitempty"`
 	Valid     *bool   `json:"valid,omitempty"`
 }
+
+// Pipeline models
+type PipelineStep struct {
+	Op   string          `json:"op" binding:"required"`
+	Args json.RawMessage `json:"args,omitempty" swaggertype:"object"` // options of the step's operation
+}
+
+type PipelineRequest struct {
+	Input          string         `json:"input" binding:"max=1048576"`
+	InputEncoding  *string        `json:"input_encoding" binding:"omitempty,oneof=text hex base64"`
+	BinaryEncoding *string        `json:"binary_encoding" binding:"omitempty,oneof=hex base64"` // encoding of binary outputs, default base64
+	Steps          []PipelineStep `json:"steps" binding:"required_without=Recipe,omitempty,max=20,dive"`
+	Recipe         *string        `json:"recipe" binding:"omitempty,max=100"` // name of a saved recipe to run instead of steps
+}
+
+type PipelineResponse struct {
+	Output     string               `json:"output"`
+	Binary     bool                 `json:"binary,omitempty"`
+	Encoding   string               `json:"encoding,omitempty"`
+	Steps      []PipelineStepResult `json:"steps"`
+	DurationMs float64              `json:"duration_ms"`
+}
+
+type PipelineStepResult struct {
+	Op         string  `json:"op"`
+	Output     string  `json:"output"`
+	Binary     bool    `json:"binary,omitempty"`
+	Encoding   string  `json:"encoding,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+type PipelineRecipe struct {
+	ID        int64          `json:"id"`
+	UserID    int64          `json:"user_id"`
+	Name      string         `json:"name"`
+	Steps     []PipelineStep `json:"steps"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type SavePipelineRecipeRequest struct {
+	Steps []PipelineStep `json:"steps" binding:"required,min=1,max=20,dive"`
+}
@@ -1,8 +1,36 @@
 package handler
 
-import "strconv"
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+)
 
 // parseIntQueryParam parses an integer query parameter
 func parseIntQueryParam(s string) (int, error) {
 	return strconv.Atoi(s)
 }
+
+// readUploadedFile reads a multipart file, rejecting files larger than maxSize bytes
+func readUploadedFile(file *multipart.FileHeader, maxSize int64) ([]byte, error) {
+	if file.Size > maxSize {
+		return nil, fmt.Errorf("file %s exceeds maximum size of %d bytes", file.Filename, maxSize)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file %s exceeds maximum size of %d bytes", file.Filename, maxSize)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/codewithwan/gopilot/internal/middleware"
+	"github.com/codewithwan/gopilot/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PipelineHandler struct {
+	service *service.PipelineService
+}
+
+func NewPipelineHandler(service *service.PipelineService) *PipelineHandler {
+	return &PipelineHandler{service: service}
+}
+
+// pipelineErrorStatus maps pipeline errors to HTTP status codes
+func pipelineErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidPipeline):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrPipelineRecipeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrPipelineAuthRequired):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RunPipeline godoc
+// @Summary Run a transformation pipeline
+// @Description Run an ordered list of steps over the input, each step receiving the previous step's output.
+// @Description Operations: encode, hash, hmac, aes, rsa, format-json, convert-yaml, convert-base, convert-time,
+// @Description convert-color, generate-uuid, generate-token, generate-password, generate-lorem, gzip and gunzip.
+// @Description Step args take the fields of the matching endpoint's request without its input field.
+// @Description Set recipe instead of steps to run a saved recipe of the authenticated user.
+// @Tags pipeline
+// @Accept json
+// @Produce json
+// @Param request body domain.PipelineRequest true "Pipeline request"
+// @Success 200 {object} domain.PipelineResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /v1/pipeline [post]
+func (h *PipelineHandler) RunPipeline(c *gin.Context) {
+	var req domain.PipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var userID *int64
+	if uid, err := middleware.GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	result, err := h.service.RunPipeline(c.Request.Context(), &req, userID)
+	if err != nil {
+		c.JSON(pipelineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListRecipes godoc
+// @Summary List pipeline recipes
+// @Description List the saved pipeline recipes of the authenticated user
+// @Tags pipeline
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /v1/pipeline/recipes [get]
+func (h *PipelineHandler) ListRecipes(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	recipes, err := h.service.ListRecipes(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list recipes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recipes": recipes})
+}
+
+// SaveRecipe godoc
+// @Summary Save a pipeline recipe
+// @Description Create or replace a named pipeline recipe of the authenticated user
+// @Tags pipeline
+// @Accept json
+// @Produce json
+// @Param name path string true "Recipe name"
+// @Param request body domain.SavePipelineRecipeRequest true "Recipe steps"
+// @Success 200 {object} domain.PipelineRecipe
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /v1/pipeline/recipes/{name} [put]
+func (h *PipelineHandler) SaveRecipe(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req domain.SavePipelineRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, err := h.service.SaveRecipe(c.Request.Context(), userID, c.Param("name"), &req)
+	if err != nil {
+		c.JSON(pipelineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// GetRecipe godoc
+// @Summary Get a pipeline recipe
+// @Description Get a saved pipeline recipe of the authenticated user by name
+// @Tags pipeline
+// @Produce json
+// @Param name path string true "Recipe name"
+// @Success 200 {object} domain.PipelineRecipe
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /v1/pipeline/recipes/{name} [get]
+func (h *PipelineHandler) GetRecipe(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	recipe, err := h.service.GetRecipe(c.Request.Context(), userID, c.Param("name"))
+	if err != nil {
+		c.JSON(pipelineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// DeleteRecipe godoc
+// @Summary Delete a pipeline recipe
+// @Description Delete a saved pipeline recipe of the authenticated user by name
+// @Tags pipeline
+// @Param name path string true "Recipe name"
+// @Success 204 "No Content"
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /v1/pipeline/recipes/{name} [delete]
+func (h *PipelineHandler) DeleteRecipe(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.service.DeleteRecipe(c.Request.Context(), userID, c.Param("name")); err != nil {
+		c.JSON(pipelineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/codewithwan/gopilot/internal/middleware"
 	"github.com/codewithwan/gopilot/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -16,11 +21,24 @@ func NewQRCodeHandler(service *service.QRCodeService) *QRCodeHandler {
 	return &QRCodeHandler{service: service}
 }
 
+// maxQRLogoUploadSize limits the size of uploaded logo files, matching the
+// limit on base64 logos in GenerateQRRequest
+const maxQRLogoUploadSize = 2 << 20
+
+// maxQRScanUploadSize limits the size of images uploaded for decoding
+const maxQRScanUploadSize = 10 << 20
+
 // GenerateQR godoc
 // @Summary Generate QR code
-// @Description Generate a QR code from text
+// @Description Generate a QR code from text or a structured payload (wifi, vcard, mecard, geo, vevent, sms, mailto, epc)
+// @Description with optional error correction level, colors, quiet zone and center logo. The response text is the encoded payload.
+// @Description Send JSON (logo as base64) or multipart/form-data with the logo as a "logo" file.
+// @Description With "dynamic": true the code encodes an editable short URL for the text URL; change its
+// @Description destination later with PUT /v1/shorten/{short_code} and the returned edit_token.
+// @Description Requests with a bearer token are owned by that user; expire_in sets a TTL in hours.
 // @Tags qr-code
 // @Accept json
+// @Accept multipart/form-data
 // @Produce json
 // @Param request body domain.GenerateQRRequest true "QR code request"
 // @Success 200 {object} domain.QRCode
@@ -29,43 +47,297 @@ func NewQRCodeHandler(service *service.QRCodeService) *QRCodeHandler {
 // @Router /v1/qr [post]
 func (h *QRCodeHandler) GenerateQR(c *gin.Context) {
 	var req domain.GenerateQRRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	qr, err := h.service.GenerateQR(c.Request.Context(), &req)
+	if file, err := c.FormFile("logo"); err == nil {
+		logo, readErr := readUploadedFile(file, maxQRLogoUploadSize)
+		if readErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": readErr.Error()})
+			return
+		}
+		req.Logo = logo
+	}
+
+	// Authenticated requests own the QR code
+	var owner *int64
+	if userID, err := middleware.GetUserID(c); err == nil {
+		owner = &userID
+	}
+
+	qr, err := h.service.GenerateQR(c.Request.Context(), &req, owner)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidQRCode) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"id":     qr.ID,
+		"type":   qr.Type,
 		"text":   qr.Text,
 		"format": qr.Format,
 		"size":   qr.Size,
-	})
+	}
+	if qr.ExpiresAt != nil {
+		response["expires_at"] = qr.ExpiresAt
+	}
+	if qr.ShortCode != nil {
+		response["short_code"] = qr.ShortCode
+		response["destination"] = qr.Destination
+		response["edit_token"] = qr.EditToken
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GenerateBarcode godoc
+// @Summary Generate barcode
+// @Description Generate a linear (code128, code39, ean8, ean13, upca) or 2D (datamatrix, aztec, pdf417) barcode as PNG or SVG.
+// @Description EAN/UPC check digits are appended when omitted and verified when present. Barcodes are stored like
+// @Description QR codes: retrieve with GET /v1/barcode/{id} (size sets the width) and delete with DELETE /v1/barcode/{id}.
+// @Tags qr-code
+// @Accept json
+// @Produce json
+// @Param request body domain.GenerateBarcodeRequest true "Barcode request"
+// @Success 200 {object} domain.QRCode
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /v1/barcode [post]
+func (h *QRCodeHandler) GenerateBarcode(c *gin.Context) {
+	var req domain.GenerateBarcodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Authenticated requests own the barcode
+	var owner *int64
+	if userID, err := middleware.GetUserID(c); err == nil {
+		owner = &userID
+	}
+
+	bc, err := h.service.GenerateBarcode(c.Request.Context(), &req, owner)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidQRCode) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bc)
+}
+
+// qrRenderMaxAge is how long clients and proxies may cache rendered QR codes
+const qrRenderMaxAge = "86400"
+
+// RenderQR godoc
+// @Summary Render QR code
+// @Description Render a QR code for text and stream it without storing it. Output is deterministic for the
+// @Description query parameters, so responses carry an ETag and Cache-Control header and honor If-None-Match.
+// @Tags qr-code
+// @Produce png
+// @Produce image/svg+xml
+// @Produce application/postscript
+// @Produce plain
+// @Param text query string true "Text to encode"
+// @Param size query int false "Size in pixels (64-2048)"
+// @Param format query string false "Output format (png, svg, eps, text, utf8)"
+// @Param error_correction query string false "Error correction level (L, M, Q, H)"
+// @Param foreground query string false "Foreground color (hex or rgb())"
+// @Param background query string false "Background color (hex or rgb())"
+// @Param quiet_zone query int false "Quiet zone in modules (0-16)"
+// @Success 200 {file} image/png
+// @Success 304 "Not modified"
+// @Failure 400 {object} map[string]string
+// @Router /v1/qr/render [get]
+func (h *QRCodeHandler) RenderQR(c *gin.Context) {
+	var req domain.RenderQRRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Encode sorts the parameters, so equivalent URLs share an ETag
+	sum := sha256.Sum256([]byte(c.Request.URL.Query().Encode()))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Header("Cache-Control", "public, max-age="+qrRenderMaxAge)
+		c.Header("ETag", etag)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	imageData, format, err := h.service.RenderQR(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age="+qrRenderMaxAge)
+	c.Header("ETag", etag)
+	c.Data(http.StatusOK, service.QRCodeContentType(format), imageData)
+}
+
+// DecodeQR godoc
+// @Summary Decode QR codes
+// @Description Locate and decode all QR codes in a PNG, JPEG or GIF image. Each result includes the decoded text,
+// @Description the finder pattern centers and a pixel bounding box. An image without a readable code returns an empty list.
+// @Description Send multipart/form-data with an "image" file or JSON with the image as base64.
+// @Tags qr-code
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param request body domain.DecodeQRRequest true "Decode request"
+// @Success 200 {object} domain.DecodeQRResponse
+// @Failure 400 {object} map[string]string
+// @Router /v1/qr/decode [post]
+func (h *QRCodeHandler) DecodeQR(c *gin.Context) {
+	var req domain.DecodeQRRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if file, err := c.FormFile("image"); err == nil {
+		image, readErr := readUploadedFile(file, maxQRScanUploadSize)
+		if readErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": readErr.Error()})
+			return
+		}
+		req.Image = image
+	}
+
+	result, err := h.service.DecodeQR(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
 // GetQRCode godoc
 // @Summary Get QR code
-// @Description Get a previously generated QR code
+// @Description Render a previously generated QR code, served with the Content-Type of its stored format.
+// @Description The image is rendered from the stored text and style, at the stored size unless size is given.
 // @Tags qr-code
 // @Produce png
+// @Produce image/svg+xml
+// @Produce application/postscript
+// @Produce plain
 // @Param id path string true "QR code ID"
+// @Param size query int false "Size in pixels (64-2048)"
 // @Success 200 {file} image/png
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /v1/qr/{id} [get]
 func (h *QRCodeHandler) GetQRCode(c *gin.Context) {
 	id := c.Param("id")
 
-	qr, err := h.service.GetQRCode(c.Request.Context(), id)
+	var req domain.GetQRCodeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	qr, err := h.service.GetQRCode(c.Request.Context(), id, req.Size)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrQRCodeNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, service.QRCodeContentType(qr.Format), qr.ImageData)
+}
+
+// ListQRCodes godoc
+// @Summary List QR codes
+// @Description List the unexpired QR codes owned by the authenticated user
+// @Tags qr-code
+// @Produce json
+// @Param limit query int false "Limit" default(10)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /v1/qr [get]
+func (h *QRCodeHandler) ListQRCodes(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "10")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, err := strconv.ParseInt(limitStr, 10, 32)
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	offset, err := strconv.ParseInt(offsetStr, 10, 32)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	codes, total, err := h.service.ListQRCodes(c.Request.Context(), userID, int32(limit), int32(offset))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list QR codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"qr_codes": codes,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// DeleteQRCode godoc
+// @Summary Delete QR code
+// @Description Delete a QR code by ID. Only QR codes created with a bearer token can be deleted, by their owner;
+// @Description anonymous QR codes are removed when they expire.
+// @Tags qr-code
+// @Param id path string true "QR code ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /v1/qr/{id} [delete]
+func (h *QRCodeHandler) DeleteQRCode(c *gin.Context) {
+	id := c.Param("id")
+
+	var userID *int64
+	if uid, err := middleware.GetUserID(c); err == nil {
+		userID = &uid
+	}
+
+	if err := h.service.DeleteQRCode(c.Request.Context(), id, userID); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrQRCodeForbidden):
+			status = http.StatusForbidden
+		case errors.Is(err, service.ErrQRCodeNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.Data(http.StatusOK, "image/png", qr.ImageData)
+	c.Status(http.StatusNoContent)
 }
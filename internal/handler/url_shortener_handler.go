@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/codewithwan/gopilot/internal/domain"
@@ -45,7 +46,7 @@ func (h *URLShortenerHandler) CreateShortURL(c *gin.Context) {
 
 // GetShortURL godoc
 // @Summary Get short URL details
-// @Description Get details and statistics of a short URL
+// @Description Get details and statistics of a short URL, with human clicks and bot/prefetch clicks counted separately
 // @Tags url-shortener
 // @Produce json
 // @Param code path string true "Short URL code"
@@ -65,6 +66,47 @@ func (h *URLShortenerHandler) GetShortURL(c *gin.Context) {
 	c.JSON(http.StatusOK, shortURL)
 }
 
+// UpdateShortURL godoc
+// @Summary Change short URL destination
+// @Description Change the destination of a short URL created with "editable": true, authorized by its edit token.
+// @Description The new destination must be an http or https URL.
+// @Description Dynamic QR codes encode such a short URL, so their target can change without reprinting.
+// @Tags url-shortener
+// @Accept json
+// @Produce json
+// @Param code path string true "Short URL code"
+// @Param request body domain.UpdateShortURLRequest true "Update request"
+// @Success 200 {object} domain.ShortURL
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /v1/shorten/{code} [put]
+func (h *URLShortenerHandler) UpdateShortURL(c *gin.Context) {
+	code := c.Param("code")
+
+	var req domain.UpdateShortURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shortURL, err := h.service.UpdateShortURL(c.Request.Context(), code, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDestination) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrShortURLNotEditable) || errors.Is(err, service.ErrInvalidEditToken) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, shortURL)
+}
+
 // RedirectShortURL godoc
 // @Summary Redirect to original URL
 // @Description Redirect to the original URL and record click statistics
@@ -87,8 +129,12 @@ func (h *URLShortenerHandler) RedirectShortURL(c *gin.Context) {
 	referrer := c.Request.Referer()
 	userAgent := c.Request.UserAgent()
 	ipAddress := c.ClientIP()
+	purpose := c.GetHeader("Sec-Purpose")
+	if purpose == "" {
+		purpose = c.GetHeader("Purpose")
+	}
 
-	if err := h.service.RecordClick(c.Request.Context(), shortURL, referrer, userAgent, ipAddress); err != nil {
+	if err := h.service.RecordClick(c.Request.Context(), shortURL, referrer, userAgent, ipAddress, purpose); err != nil {
 		// Log error but don't fail the redirect
 		_ = c.Error(err)
 	}
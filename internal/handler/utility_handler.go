@@ -1,13 +1,22 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/codewithwan/gopilot/internal/domain"
 	"github.com/codewithwan/gopilot/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
+// maxHashUploadSize limits the size of files streamed through the hash endpoint
+const maxHashUploadSize = 1 << 30
+
+// maxHashFieldSize limits the size of non-file form fields in hash uploads
+const maxHashFieldSize = 1 << 10
+
 type UtilityHandler struct {
 	hashService      *service.HashService
 	converterService *service.ConverterService
@@ -44,6 +53,10 @@ func (h *UtilityHandler) Hash(c *gin.Context) {
 
 	result, err := h.hashService.Hash(&req)
 	if err != nil {
+		if errors.Is(err, service.ErrPasswordHashWithDigests) || errors.Is(err, service.ErrHashCostTooHigh) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -51,16 +64,127 @@ func (h *UtilityHandler) Hash(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// VerifyHash godoc
+// @Summary Verify a password hash
+// @Description Check text against a bcrypt, argon2 (PHC), scrypt or PBKDF2 hash. The algorithm is detected from the hash format.
+// @Tags hash-encode
+// @Accept json
+// @Produce json
+// @Param request body domain.VerifyHashRequest true "Verify request"
+// @Success 200 {object} domain.VerifyHashResponse
+// @Failure 400 {object} map[string]string
+// @Router /v1/hash/verify [post]
+func (h *UtilityHandler) VerifyHash(c *gin.Context) {
+	var req domain.VerifyHashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.hashService.VerifyHash(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AnalyzePassword godoc
+// @Summary Analyze password strength
+// @Description Estimate password strength with entropy, pattern matching against common passwords, words, names, keyboard walks, dates and repeats, crack times and feedback
+// @Tags hash-encode
+// @Accept json
+// @Produce json
+// @Param request body domain.AnalyzePasswordRequest true "Analyze request"
+// @Success 200 {object} domain.PasswordAnalysis
+// @Failure 400 {object} map[string]string
+// @Router /v1/password/analyze [post]
+func (h *UtilityHandler) AnalyzePassword(c *gin.Context) {
+	var req domain.AnalyzePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.hashService.AnalyzePassword(&req))
+}
+
+// HashFile godoc
+// @Summary Hash a file
+// @Description Stream an uploaded file through one or more digest algorithms in a single pass. Algorithms are given as a comma-separated list in the algorithms query parameter or a form field sent before the file.
+// @Tags hash-encode
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File to hash"
+// @Param algorithms query string false "Comma-separated algorithms (default sha256)"
+// @Success 200 {object} domain.HashFileResponse
+// @Failure 400 {object} map[string]string
+// @Router /v1/hash/file [post]
+func (h *UtilityHandler) HashFile(c *gin.Context) {
+	// Security: Cap the request body; the file itself is never buffered
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxHashUploadSize)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	algorithms := parseAlgorithmList(c.Query("algorithms"))
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch part.FormName() {
+		case "algorithms":
+			value, readErr := io.ReadAll(io.LimitReader(part, maxHashFieldSize))
+			if readErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": readErr.Error()})
+				return
+			}
+			algorithms = append(algorithms, parseAlgorithmList(string(value))...)
+		case "file":
+			result, hashErr := h.hashService.HashFile(part, part.FileName(), algorithms)
+			if hashErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": hashErr.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, result)
+			return
+		}
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+}
+
+// parseAlgorithmList splits a comma-separated list of algorithm names
+func parseAlgorithmList(s string) []string {
+	var algorithms []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			algorithms = append(algorithms, name)
+		}
+	}
+	return algorithms
+}
+
 // Encode godoc
 // @Summary Encode/decode text
-// @Description Encode or decode text using specified operation
+// @Description Encode or decode text using specified operation. Results that are not valid UTF-8 are returned hex or base64 encoded with binary set. With raw=true or an Accept header of application/octet-stream the result bytes are returned as is.
 // @Tags hash-encode
 // @Accept json
-// @Produce json
+// @Produce json,octet-stream
 // @Param request body domain.EncodeRequest true "Encode request"
+// @Param raw query bool false "Return the raw result bytes"
 // @Success 200 {object} domain.EncodeResponse
 // @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
 // @Router /v1/encode [post]
 func (h *UtilityHandler) Encode(c *gin.Context) {
 	var req domain.EncodeRequest
@@ -69,9 +193,19 @@ func (h *UtilityHandler) Encode(c *gin.Context) {
 		return
 	}
 
+	if c.Query("raw") == "true" || c.GetHeader("Accept") == "application/octet-stream" {
+		output, err := h.hashService.EncodeRaw(&req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "application/octet-stream", output)
+		return
+	}
+
 	result, err := h.hashService.Encode(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -80,7 +214,8 @@ func (h *UtilityHandler) Encode(c *gin.Context) {
 
 // GeneratePassword godoc
 // @Summary Generate password
-// @Description Generate a random secure password
+// @Description Generate a random secure password. Options a mode cannot honor, such as include_symbols for
+// @Description passphrases or include_lower=false for pronounceable passwords, are rejected with 400.
 // @Tags hash-encode
 // @Accept json
 // @Produce json
@@ -98,7 +233,11 @@ func (h *UtilityHandler) GeneratePassword(c *gin.Context) {
 
 	result, err := h.hashService.GeneratePassword(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrUnsupportedPasswordOption) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -107,14 +246,15 @@ func (h *UtilityHandler) GeneratePassword(c *gin.Context) {
 
 // ConvertBase godoc
 // @Summary Convert number base
-// @Description Convert numbers between different bases
+// @Description Convert numbers of any size between bases 2 and 64 with a selectable digit alphabet
+// @Description (standard, base62, base64, base64url, crockford). Fractional parts are converted up to
+// @Description precision digits; bit_width outputs two's complement and signed_input reads it.
 // @Tags converter
 // @Accept json
 // @Produce json
 // @Param request body domain.ConvertBaseRequest true "Base conversion request"
 // @Success 200 {object} domain.ConvertBaseResponse
 // @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
 // @Router /v1/convert/base [post]
 func (h *UtilityHandler) ConvertBase(c *gin.Context) {
 	var req domain.ConvertBaseRequest
@@ -125,7 +265,7 @@ func (h *UtilityHandler) ConvertBase(c *gin.Context) {
 
 	result, err := h.converterService.ConvertBase(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -134,14 +274,15 @@ func (h *UtilityHandler) ConvertBase(c *gin.Context) {
 
 // ConvertColor godoc
 // @Summary Convert color format
-// @Description Convert colors between different formats
+// @Description Convert a color between hex (3, 4, 6 or 8 digits), rgb(a), hsl(a), hsv, hwb, cmyk, lab, lch,
+// @Description oklab, oklch and CSS named colors. The input format is detected unless from is set, and the
+// @Description color is returned in every format alongside the requested one.
 // @Tags converter
 // @Accept json
 // @Produce json
 // @Param request body domain.ConvertColorRequest true "Color conversion request"
 // @Success 200 {object} domain.ConvertColorResponse
 // @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
 // @Router /v1/convert/color [post]
 func (h *UtilityHandler) ConvertColor(c *gin.Context) {
 	var req domain.ConvertColorRequest
@@ -152,7 +293,88 @@ func (h *UtilityHandler) ConvertColor(c *gin.Context) {
 
 	result, err := h.converterService.ConvertColor(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ColorContrast godoc
+// @Summary Check color contrast
+// @Description Compute the WCAG 2.x contrast ratio with AA/AAA results for normal and large text, and the APCA
+// @Description lightness contrast (Lc) of foreground text on a background. Colors accept any /v1/convert/color format.
+// @Tags color
+// @Accept json
+// @Produce json
+// @Param request body domain.ColorContrastRequest true "Contrast request"
+// @Success 200 {object} domain.ColorContrastResponse
+// @Failure 400 {object} map[string]string
+// @Router /v1/color/contrast [post]
+func (h *UtilityHandler) ColorContrast(c *gin.Context) {
+	var req domain.ColorContrastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.converterService.ColorContrast(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ColorPalette godoc
+// @Summary Generate a color palette
+// @Description Generate complementary, split-complementary, triadic, tetradic or analogous harmonies,
+// @Description or tints, shades and a light-to-dark scale of a color
+// @Tags color
+// @Accept json
+// @Produce json
+// @Param request body domain.ColorPaletteRequest true "Palette request"
+// @Success 200 {object} domain.ColorPaletteResponse
+// @Failure 400 {object} map[string]string
+// @Router /v1/color/palette [post]
+func (h *UtilityHandler) ColorPalette(c *gin.Context) {
+	var req domain.ColorPaletteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.converterService.ColorPalette(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SimulateColorBlindness godoc
+// @Summary Simulate color blindness
+// @Description Show how a color appears with protanopia, deuteranopia, tritanopia, their anomalous
+// @Description trichromacies at the given severity, and achromatopsia
+// @Tags color
+// @Accept json
+// @Produce json
+// @Param request body domain.SimulateColorBlindnessRequest true "Simulation request"
+// @Success 200 {object} domain.SimulateColorBlindnessResponse
+// @Failure 400 {object} map[string]string
+// @Router /v1/color/simulate [post]
+func (h *UtilityHandler) SimulateColorBlindness(c *gin.Context) {
+	var req domain.SimulateColorBlindnessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.converterService.SimulateColorBlindness(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -161,14 +383,15 @@ func (h *UtilityHandler) ConvertColor(c *gin.Context) {
 
 // ConvertTime godoc
 // @Summary Convert time format
-// @Description Convert time between different formats
+// @Description Convert time between Unix epochs (s, ms, µs, ns), ISO 8601, RFC 1123, RFC 822, ISO week dates,
+// @Description Go or strftime layouts, human readable and relative output. The input format is detected when
+// @Description from is omitted; from_tz and to_tz take IANA time zone names.
 // @Tags converter
 // @Accept json
 // @Produce json
 // @Param request body domain.ConvertTimeRequest true "Time conversion request"
 // @Success 200 {object} domain.ConvertTimeResponse
 // @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
 // @Router /v1/convert/time [post]
 func (h *UtilityHandler) ConvertTime(c *gin.Context) {
 	var req domain.ConvertTimeRequest
@@ -179,7 +402,63 @@ func (h *UtilityHandler) ConvertTime(c *gin.Context) {
 
 	result, err := h.converterService.ConvertTime(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ParseCron godoc
+// @Summary Parse cron expression
+// @Description Validate a 5-field, 6-field (seconds first) or macro (@daily, @every 1h30m) cron expression,
+// @Description describe it and list its next runs in an IANA time zone. Across daylight saving time changes,
+// @Description fixed-time jobs skipped by the change run at the change and repeated ones run once.
+// @Tags converter
+// @Accept json
+// @Produce json
+// @Param request body domain.CronParseRequest true "Cron parse request"
+// @Success 200 {object} domain.CronParseResponse
+// @Failure 400 {object} map[string]string
+// @Router /v1/cron/parse [post]
+func (h *UtilityHandler) ParseCron(c *gin.Context) {
+	var req domain.CronParseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.converterService.ParseCron(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CalculateTime godoc
+// @Summary Calculate with times and durations
+// @Description Add or subtract Go (1h30m) or ISO 8601 (P1M2DT3H) durations, measure the difference between two
+// @Description times in calendar and business days with optional holidays, or convert a duration between units.
+// @Description Times are read in any format convert/time detects.
+// @Tags converter
+// @Accept json
+// @Produce json
+// @Param request body domain.TimeCalcRequest true "Time calculation request"
+// @Success 200 {object} domain.TimeCalcResponse
+// @Failure 400 {object} map[string]string
+// @Router /v1/time/calc [post]
+func (h *UtilityHandler) CalculateTime(c *gin.Context) {
+	var req domain.TimeCalcRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.converterService.CalculateTime(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -188,14 +467,16 @@ func (h *UtilityHandler) ConvertTime(c *gin.Context) {
 
 // FormatJSON godoc
 // @Summary Format JSON
-// @Description Format or minify JSON
+// @Description Format or minify JSON token by token, keeping the original key order and number literals.
+// @Description Keys can be sorted recursively and indentation can use spaces or tabs.
+// @Description With syntax jsonc or json5 the input may have comments and trailing commas, and JSON5 strings, keys and numbers; the output is standard JSON plus the comments unless strip_comments is set.
+// @Description Parse errors include the line and column of the problem.
 // @Tags formatter
 // @Accept json
 // @Produce json
 // @Param request body domain.FormatJSONRequest true "JSON format request"
 // @Success 200 {object} domain.FormatJSONResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} map[string]interface{}
 // @Router /v1/format/json [post]
 func (h *UtilityHandler) FormatJSON(c *gin.Context) {
 	var req domain.FormatJSONRequest
@@ -206,7 +487,96 @@ func (h *UtilityHandler) FormatJSON(c *gin.Context) {
 
 	result, err := h.converterService.FormatJSON(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		dataError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// QueryJSON godoc
+// @Summary Query JSON
+// @Description Evaluate a JSONPath (RFC 9535) or jq filter against a JSON document and return the results.
+// @Description JSONPath results come with their normalized paths, such as $['store']['book'][0].
+// @Description Queries time out after 2 seconds, and results past 10000 or 4 MiB of output are dropped with truncated set.
+// @Description jq filters may build at most 64 MiB of values with + and *.
+// @Description Query and document parse errors include the line and column of the problem.
+// @Tags formatter
+// @Accept json
+// @Produce json
+// @Param request body domain.JSONQueryRequest true "JSON query request"
+// @Success 200 {object} domain.JSONQueryResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /v1/json/query [post]
+func (h *UtilityHandler) QueryJSON(c *gin.Context) {
+	var req domain.JSONQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.converterService.QueryJSON(c.Request.Context(), &req)
+	if err != nil {
+		dataError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ValidateJSON godoc
+// @Summary Validate JSON against a JSON Schema
+// @Description Validate a JSON document against a JSON Schema, draft-07 or 2020-12.
+// @Description The draft comes from $schema, falling back to draft (2020-12 by default).
+// @Description Every failing keyword is reported with its instance path and schema path as JSON Pointers.
+// @Description Only $refs within the schema are resolved, and validation times out after 2 seconds.
+// @Description Parse errors include the line and column of the problem.
+// @Tags formatter
+// @Accept json
+// @Produce json
+// @Param request body domain.JSONValidateRequest true "JSON validation request"
+// @Success 200 {object} domain.JSONValidateResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /v1/json/validate [post]
+func (h *UtilityHandler) ValidateJSON(c *gin.Context) {
+	var req domain.JSONValidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.converterService.ValidateJSON(c.Request.Context(), &req)
+	if err != nil {
+		dataError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// InferJSONSchema godoc
+// @Summary Infer a JSON Schema
+// @Description Infer a JSON Schema, draft-07 or 2020-12, that every sample satisfies.
+// @Description Types are merged across samples, keys present in every object are required and array items share one schema.
+// @Description String formats such as date-time, email, uri and uuid are detected when every string matches.
+// @Description Inference times out after 2 seconds.
+// @Tags formatter
+// @Accept json
+// @Produce json
+// @Param request body domain.JSONSchemaInferRequest true "Schema inference request"
+// @Success 200 {object} domain.JSONSchemaInferResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /v1/json/schema/infer [post]
+func (h *UtilityHandler) InferJSONSchema(c *gin.Context) {
+	var req domain.JSONSchemaInferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.converterService.InferJSONSchema(c.Request.Context(), &req)
+	if err != nil {
+		dataError(c, err)
 		return
 	}
 
@@ -214,16 +584,20 @@ func (h *UtilityHandler) FormatJSON(c *gin.Context) {
 }
 
 // ConvertYAML godoc
-// @Summary Convert YAML/JSON
-// @Description Convert between YAML and JSON formats
+// @Summary Convert structured data
+// @Description Convert between JSON, YAML, TOML, XML, CSV, TSV, INI, .env and HCL.
+// @Description The input format is detected when from is omitted, as JSON, YAML or XML.
+// @Description Multi-document YAML becomes an array and arrays become multi-document YAML.
+// @Description CSV and TSV rows become objects when the first row looks like a header, and nested objects are flattened to dotted columns.
+// @Description Parse errors include the line and column of the problem.
 // @Tags formatter
 // @Accept json
 // @Produce json
-// @Param request body domain.ConvertYAMLRequest true "YAML conversion request"
+// @Param request body domain.ConvertYAMLRequest true "Data conversion request"
 // @Success 200 {object} domain.ConvertYAMLResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} map[string]interface{}
 // @Router /v1/format/yaml [post]
+// @Router /v1/convert/data [post]
 func (h *UtilityHandler) ConvertYAML(c *gin.Context) {
 	var req domain.ConvertYAMLRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -233,13 +607,23 @@ func (h *UtilityHandler) ConvertYAML(c *gin.Context) {
 
 	result, err := h.converterService.ConvertYAML(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		dataError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
 }
 
+// dataError responds with a bad request, including the line and column of parse errors
+func dataError(c *gin.Context, err error) {
+	var parseErr *service.DataParseError
+	if errors.As(err, &parseErr) && parseErr.Line > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "line": parseErr.Line, "column": parseErr.Column})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
 // GenerateUUID godoc
 // @Summary Generate UUID
 // @Description Generate UUIDs
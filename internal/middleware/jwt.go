@@ -49,33 +49,53 @@ func (j *JWTMiddleware) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
-			c.Abort()
+		j.authenticate(c, authHeader)
+	}
+}
+
+// OptionalAuthMiddleware identifies the user when an Authorization header is
+// present and lets anonymous requests through. A malformed or invalid token
+// is still rejected so requests are never silently treated as anonymous.
+func (j *JWTMiddleware) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.Next()
 			return
 		}
 
-		tokenString := parts[1]
-		claims := &Claims{}
+		j.authenticate(c, authHeader)
+	}
+}
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return j.secret, nil
-		})
+// authenticate validates the bearer token and stores its claims in the context
+func (j *JWTMiddleware) authenticate(c *gin.Context, authHeader string) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+		c.Abort()
+		return
+	}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
-			c.Abort()
-			return
+	tokenString := parts[1]
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
 		}
+		return j.secret, nil
+	})
 
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Next()
+	if err != nil || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		c.Abort()
+		return
 	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Next()
 }
 
 func GetUserID(c *gin.Context) (int64, error) {
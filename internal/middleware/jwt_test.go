@@ -91,6 +91,49 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	}
 }
 
+func TestOptionalAuthMiddleware(t *testing.T) {
+	secret := "test-secret"
+	jwtMiddleware := NewJWTMiddleware(secret)
+	token, _ := jwtMiddleware.GenerateToken(7, "testuser", 24*time.Hour)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(jwtMiddleware.OptionalAuthMiddleware())
+	router.GET("/test", func(c *gin.Context) {
+		userID, err := GetUserID(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "anonymous": err != nil})
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"anonymous", "", http.StatusOK, `{"anonymous":true,"user_id":0}`},
+		{"valid token", "Bearer " + token, http.StatusOK, `{"anonymous":false,"user_id":7}`},
+		{"invalid token", "Bearer invalid-token", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %s, got %s", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestGetUserID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	c, _ := gin.CreateTestContext(httptest.NewRecorder())
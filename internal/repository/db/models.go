@@ -20,25 +20,47 @@ type Paste struct {
 	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
 }
 
-type QrCode struct {
-	ID        string           `json:"id"`
-	Text      string           `json:"text"`
-	Format    string           `json:"format"`
-	Size      int32            `json:"size"`
-	ImageData []byte           `json:"image_data"`
+type PipelineRecipe struct {
+	ID        int64            `json:"id"`
+	UserID    int64            `json:"user_id"`
+	Name      string           `json:"name"`
+	Steps     []byte           `json:"steps"`
 	CreatedAt pgtype.Timestamp `json:"created_at"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+}
+
+type QrCode struct {
+	ID              string           `json:"id"`
+	Text            string           `json:"text"`
+	Format          string           `json:"format"`
+	Size            int32            `json:"size"`
+	ImageData       []byte           `json:"image_data"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	Type            string           `json:"type"`
+	ErrorCorrection string           `json:"error_correction"`
+	Foreground      pgtype.Text      `json:"foreground"`
+	Background      pgtype.Text      `json:"background"`
+	QuietZone       int32            `json:"quiet_zone"`
+	Logo            []byte           `json:"logo"`
+	ShortCode       pgtype.Text      `json:"short_code"`
+	UserID          pgtype.Int8      `json:"user_id"`
+	ExpiresAt       pgtype.Timestamp `json:"expires_at"`
+	Symbology       string           `json:"symbology"`
+	Height          pgtype.Int4      `json:"height"`
 }
 
 type ShortUrl struct {
-	ID          int64            `json:"id"`
-	Code        string           `json:"code"`
-	OriginalUrl string           `json:"original_url"`
-	Alias       pgtype.Text      `json:"alias"`
-	Clicks      int64            `json:"clicks"`
-	IsPublic    bool             `json:"is_public"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+	ID            int64            `json:"id"`
+	Code          string           `json:"code"`
+	OriginalUrl   string           `json:"original_url"`
+	Alias         pgtype.Text      `json:"alias"`
+	Clicks        int64            `json:"clicks"`
+	IsPublic      bool             `json:"is_public"`
+	ExpiresAt     pgtype.Timestamp `json:"expires_at"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+	BotClicks     int64            `json:"bot_clicks"`
+	EditTokenHash pgtype.Text      `json:"edit_token_hash"`
 }
 
 type Todo struct {
@@ -58,6 +80,7 @@ type UrlClick struct {
 	UserAgent  pgtype.Text      `json:"user_agent"`
 	IpAddress  pgtype.Text      `json:"ip_address"`
 	ClickedAt  pgtype.Timestamp `json:"clicked_at"`
+	IsBot      bool             `json:"is_bot"`
 }
 
 type User struct {
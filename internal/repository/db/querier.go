@@ -6,9 +6,12 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	CountQRCodesByUser(ctx context.Context, userID pgtype.Int8) (int64, error)
 	CountTodos(ctx context.Context, userID int64) (int64, error)
 	// Pastebin Queries
 	CreatePaste(ctx context.Context, arg CreatePasteParams) (Paste, error)
@@ -20,19 +23,29 @@ type Querier interface {
 	CreateURLClick(ctx context.Context, arg CreateURLClickParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
 	DeleteExpiredPastes(ctx context.Context) error
+	DeleteExpiredQRCodes(ctx context.Context) error
 	DeleteExpiredShortURLs(ctx context.Context) error
 	DeletePaste(ctx context.Context, id string) error
+	DeletePipelineRecipe(ctx context.Context, arg DeletePipelineRecipeParams) (int64, error)
+	DeleteQRCode(ctx context.Context, id string) error
 	DeleteTodo(ctx context.Context, arg DeleteTodoParams) error
 	GetPasteByID(ctx context.Context, id string) (Paste, error)
-	GetQRCodeByID(ctx context.Context, id string) (QrCode, error)
+	GetPipelineRecipe(ctx context.Context, arg GetPipelineRecipeParams) (PipelineRecipe, error)
+	GetQRCodeByID(ctx context.Context, id string) (GetQRCodeByIDRow, error)
 	GetShortURLByCode(ctx context.Context, code string) (ShortUrl, error)
 	GetTodoByID(ctx context.Context, arg GetTodoByIDParams) (Todo, error)
 	GetUserByID(ctx context.Context, id int64) (GetUserByIDRow, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	IncrementShortURLBotClicks(ctx context.Context, id int64) error
 	IncrementShortURLClicks(ctx context.Context, id int64) error
+	ListPipelineRecipesByUser(ctx context.Context, userID int64) ([]PipelineRecipe, error)
+	ListQRCodesByUser(ctx context.Context, arg ListQRCodesByUserParams) ([]ListQRCodesByUserRow, error)
 	ListRecentPastes(ctx context.Context, limit int32) ([]Paste, error)
 	ListTodos(ctx context.Context, arg ListTodosParams) ([]Todo, error)
+	UpdateShortURLDestination(ctx context.Context, arg UpdateShortURLDestinationParams) (ShortUrl, error)
 	UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, error)
+	// Pipeline Recipe Queries
+	UpsertPipelineRecipe(ctx context.Context, arg UpsertPipelineRecipeParams) (UpsertPipelineRecipeRow, error)
 }
 
 var _ Querier = (*Queries)(nil)
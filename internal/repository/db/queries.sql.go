@@ -11,6 +11,18 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countQRCodesByUser = `-- name: CountQRCodesByUser :one
+SELECT COUNT(*) FROM qr_codes
+WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+`
+
+func (q *Queries) CountQRCodesByUser(ctx context.Context, userID pgtype.Int8) (int64, error) {
+	row := q.db.QueryRow(ctx, countQRCodesByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countTodos = `-- name: CountTodos :one
 SELECT COUNT(*)
 FROM todos
@@ -67,24 +79,31 @@ func (q *Queries) CreatePaste(ctx context.Context, arg CreatePasteParams) (Paste
 }
 
 const createQRCode = `-- name: CreateQRCode :one
-INSERT INTO qr_codes (id, text, format, size, image_data)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, text, format, size, created_at
+INSERT INTO qr_codes (id, symbology, type, text, format, size, height, error_correction, foreground, background, quiet_zone, logo, short_code, user_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id, created_at
 `
 
 type CreateQRCodeParams struct {
-	ID        string `json:"id"`
-	Text      string `json:"text"`
-	Format    string `json:"format"`
-	Size      int32  `json:"size"`
-	ImageData []byte `json:"image_data"`
+	ID              string           `json:"id"`
+	Symbology       string           `json:"symbology"`
+	Type            string           `json:"type"`
+	Text            string           `json:"text"`
+	Format          string           `json:"format"`
+	Size            int32            `json:"size"`
+	Height          pgtype.Int4      `json:"height"`
+	ErrorCorrection string           `json:"error_correction"`
+	Foreground      pgtype.Text      `json:"foreground"`
+	Background      pgtype.Text      `json:"background"`
+	QuietZone       int32            `json:"quiet_zone"`
+	Logo            []byte           `json:"logo"`
+	ShortCode       pgtype.Text      `json:"short_code"`
+	UserID          pgtype.Int8      `json:"user_id"`
+	ExpiresAt       pgtype.Timestamp `json:"expires_at"`
 }
 
 type CreateQRCodeRow struct {
 	ID        string           `json:"id"`
-	Text      string           `json:"text"`
-	Format    string           `json:"format"`
-	Size      int32            `json:"size"`
 	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
 
@@ -92,35 +111,40 @@ type CreateQRCodeRow struct {
 func (q *Queries) CreateQRCode(ctx context.Context, arg CreateQRCodeParams) (CreateQRCodeRow, error) {
 	row := q.db.QueryRow(ctx, createQRCode,
 		arg.ID,
+		arg.Symbology,
+		arg.Type,
 		arg.Text,
 		arg.Format,
 		arg.Size,
-		arg.ImageData,
+		arg.Height,
+		arg.ErrorCorrection,
+		arg.Foreground,
+		arg.Background,
+		arg.QuietZone,
+		arg.Logo,
+		arg.ShortCode,
+		arg.UserID,
+		arg.ExpiresAt,
 	)
 	var i CreateQRCodeRow
-	err := row.Scan(
-		&i.ID,
-		&i.Text,
-		&i.Format,
-		&i.Size,
-		&i.CreatedAt,
-	)
+	err := row.Scan(&i.ID, &i.CreatedAt)
 	return i, err
 }
 
 const createShortURL = `-- name: CreateShortURL :one
-INSERT INTO short_urls (code, original_url, alias, clicks, is_public, expires_at)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at
+INSERT INTO short_urls (code, original_url, alias, clicks, is_public, expires_at, edit_token_hash)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at, bot_clicks, edit_token_hash
 `
 
 type CreateShortURLParams struct {
-	Code        string           `json:"code"`
-	OriginalUrl string           `json:"original_url"`
-	Alias       pgtype.Text      `json:"alias"`
-	Clicks      int64            `json:"clicks"`
-	IsPublic    bool             `json:"is_public"`
-	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+	Code          string           `json:"code"`
+	OriginalUrl   string           `json:"original_url"`
+	Alias         pgtype.Text      `json:"alias"`
+	Clicks        int64            `json:"clicks"`
+	IsPublic      bool             `json:"is_public"`
+	ExpiresAt     pgtype.Timestamp `json:"expires_at"`
+	EditTokenHash pgtype.Text      `json:"edit_token_hash"`
 }
 
 // URL Shortener Queries
@@ -132,6 +156,7 @@ func (q *Queries) CreateShortURL(ctx context.Context, arg CreateShortURLParams)
 		arg.Clicks,
 		arg.IsPublic,
 		arg.ExpiresAt,
+		arg.EditTokenHash,
 	)
 	var i ShortUrl
 	err := row.Scan(
@@ -144,6 +169,8 @@ func (q *Queries) CreateShortURL(ctx context.Context, arg CreateShortURLParams)
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.BotClicks,
+		&i.EditTokenHash,
 	)
 	return i, err
 }
@@ -182,8 +209,8 @@ func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) (Todo, e
 }
 
 const createURLClick = `-- name: CreateURLClick :exec
-INSERT INTO url_clicks (short_url_id, referrer, user_agent, ip_address)
-VALUES ($1, $2, $3, $4)
+INSERT INTO url_clicks (short_url_id, referrer, user_agent, ip_address, is_bot)
+VALUES ($1, $2, $3, $4, $5)
 `
 
 type CreateURLClickParams struct {
@@ -191,6 +218,7 @@ type CreateURLClickParams struct {
 	Referrer   pgtype.Text `json:"referrer"`
 	UserAgent  pgtype.Text `json:"user_agent"`
 	IpAddress  pgtype.Text `json:"ip_address"`
+	IsBot      bool        `json:"is_bot"`
 }
 
 func (q *Queries) CreateURLClick(ctx context.Context, arg CreateURLClickParams) error {
@@ -199,6 +227,7 @@ func (q *Queries) CreateURLClick(ctx context.Context, arg CreateURLClickParams)
 		arg.Referrer,
 		arg.UserAgent,
 		arg.IpAddress,
+		arg.IsBot,
 	)
 	return err
 }
@@ -243,6 +272,16 @@ func (q *Queries) DeleteExpiredPastes(ctx context.Context) error {
 	return err
 }
 
+const deleteExpiredQRCodes = `-- name: DeleteExpiredQRCodes :exec
+DELETE FROM qr_codes
+WHERE expires_at IS NOT NULL AND expires_at < CURRENT_TIMESTAMP
+`
+
+func (q *Queries) DeleteExpiredQRCodes(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredQRCodes)
+	return err
+}
+
 const deleteExpiredShortURLs = `-- name: DeleteExpiredShortURLs :exec
 DELETE FROM short_urls
 WHERE expires_at IS NOT NULL AND expires_at < CURRENT_TIMESTAMP
@@ -263,6 +302,34 @@ func (q *Queries) DeletePaste(ctx context.Context, id string) error {
 	return err
 }
 
+const deletePipelineRecipe = `-- name: DeletePipelineRecipe :execrows
+DELETE FROM pipeline_recipes
+WHERE user_id = $1 AND name = $2
+`
+
+type DeletePipelineRecipeParams struct {
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) DeletePipelineRecipe(ctx context.Context, arg DeletePipelineRecipeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePipelineRecipe, arg.UserID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteQRCode = `-- name: DeleteQRCode :exec
+DELETE FROM qr_codes
+WHERE id = $1
+`
+
+func (q *Queries) DeleteQRCode(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, deleteQRCode, id)
+	return err
+}
+
 const deleteTodo = `-- name: DeleteTodo :exec
 DELETE FROM todos
 WHERE id = $1 AND user_id = $2
@@ -301,28 +368,82 @@ func (q *Queries) GetPasteByID(ctx context.Context, id string) (Paste, error) {
 	return i, err
 }
 
+const getPipelineRecipe = `-- name: GetPipelineRecipe :one
+SELECT id, user_id, name, steps, created_at, updated_at
+FROM pipeline_recipes
+WHERE user_id = $1 AND name = $2
+`
+
+type GetPipelineRecipeParams struct {
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) GetPipelineRecipe(ctx context.Context, arg GetPipelineRecipeParams) (PipelineRecipe, error) {
+	row := q.db.QueryRow(ctx, getPipelineRecipe, arg.UserID, arg.Name)
+	var i PipelineRecipe
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Steps,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getQRCodeByID = `-- name: GetQRCodeByID :one
-SELECT id, text, format, size, image_data, created_at
+SELECT id, symbology, type, text, format, size, height, error_correction, foreground, background, quiet_zone, logo, short_code, user_id, expires_at, created_at
 FROM qr_codes
 WHERE id = $1
 `
 
-func (q *Queries) GetQRCodeByID(ctx context.Context, id string) (QrCode, error) {
+type GetQRCodeByIDRow struct {
+	ID              string           `json:"id"`
+	Symbology       string           `json:"symbology"`
+	Type            string           `json:"type"`
+	Text            string           `json:"text"`
+	Format          string           `json:"format"`
+	Size            int32            `json:"size"`
+	Height          pgtype.Int4      `json:"height"`
+	ErrorCorrection string           `json:"error_correction"`
+	Foreground      pgtype.Text      `json:"foreground"`
+	Background      pgtype.Text      `json:"background"`
+	QuietZone       int32            `json:"quiet_zone"`
+	Logo            []byte           `json:"logo"`
+	ShortCode       pgtype.Text      `json:"short_code"`
+	UserID          pgtype.Int8      `json:"user_id"`
+	ExpiresAt       pgtype.Timestamp `json:"expires_at"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) GetQRCodeByID(ctx context.Context, id string) (GetQRCodeByIDRow, error) {
 	row := q.db.QueryRow(ctx, getQRCodeByID, id)
-	var i QrCode
+	var i GetQRCodeByIDRow
 	err := row.Scan(
 		&i.ID,
+		&i.Symbology,
+		&i.Type,
 		&i.Text,
 		&i.Format,
 		&i.Size,
-		&i.ImageData,
+		&i.Height,
+		&i.ErrorCorrection,
+		&i.Foreground,
+		&i.Background,
+		&i.QuietZone,
+		&i.Logo,
+		&i.ShortCode,
+		&i.UserID,
+		&i.ExpiresAt,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getShortURLByCode = `-- name: GetShortURLByCode :one
-SELECT id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at
+SELECT id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at, bot_clicks, edit_token_hash
 FROM short_urls
 WHERE code = $1
 `
@@ -340,6 +461,8 @@ func (q *Queries) GetShortURLByCode(ctx context.Context, code string) (ShortUrl,
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.BotClicks,
+		&i.EditTokenHash,
 	)
 	return i, err
 }
@@ -414,6 +537,17 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 	return i, err
 }
 
+const incrementShortURLBotClicks = `-- name: IncrementShortURLBotClicks :exec
+UPDATE short_urls
+SET bot_clicks = bot_clicks + 1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) IncrementShortURLBotClicks(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, incrementShortURLBotClicks, id)
+	return err
+}
+
 const incrementShortURLClicks = `-- name: IncrementShortURLClicks :exec
 UPDATE short_urls
 SET clicks = clicks + 1, updated_at = CURRENT_TIMESTAMP
@@ -425,6 +559,108 @@ func (q *Queries) IncrementShortURLClicks(ctx context.Context, id int64) error {
 	return err
 }
 
+const listPipelineRecipesByUser = `-- name: ListPipelineRecipesByUser :many
+SELECT id, user_id, name, steps, created_at, updated_at
+FROM pipeline_recipes
+WHERE user_id = $1
+ORDER BY name
+`
+
+func (q *Queries) ListPipelineRecipesByUser(ctx context.Context, userID int64) ([]PipelineRecipe, error) {
+	rows, err := q.db.Query(ctx, listPipelineRecipesByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PipelineRecipe
+	for rows.Next() {
+		var i PipelineRecipe
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Steps,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQRCodesByUser = `-- name: ListQRCodesByUser :many
+SELECT id, symbology, type, text, format, size, height, error_correction, foreground, background, quiet_zone, short_code, user_id, expires_at, created_at
+FROM qr_codes
+WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListQRCodesByUserParams struct {
+	UserID pgtype.Int8 `json:"user_id"`
+	Limit  int32       `json:"limit"`
+	Offset int32       `json:"offset"`
+}
+
+type ListQRCodesByUserRow struct {
+	ID              string           `json:"id"`
+	Symbology       string           `json:"symbology"`
+	Type            string           `json:"type"`
+	Text            string           `json:"text"`
+	Format          string           `json:"format"`
+	Size            int32            `json:"size"`
+	Height          pgtype.Int4      `json:"height"`
+	ErrorCorrection string           `json:"error_correction"`
+	Foreground      pgtype.Text      `json:"foreground"`
+	Background      pgtype.Text      `json:"background"`
+	QuietZone       int32            `json:"quiet_zone"`
+	ShortCode       pgtype.Text      `json:"short_code"`
+	UserID          pgtype.Int8      `json:"user_id"`
+	ExpiresAt       pgtype.Timestamp `json:"expires_at"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) ListQRCodesByUser(ctx context.Context, arg ListQRCodesByUserParams) ([]ListQRCodesByUserRow, error) {
+	rows, err := q.db.Query(ctx, listQRCodesByUser, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListQRCodesByUserRow
+	for rows.Next() {
+		var i ListQRCodesByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Symbology,
+			&i.Type,
+			&i.Text,
+			&i.Format,
+			&i.Size,
+			&i.Height,
+			&i.ErrorCorrection,
+			&i.Foreground,
+			&i.Background,
+			&i.QuietZone,
+			&i.ShortCode,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRecentPastes = `-- name: ListRecentPastes :many
 SELECT id, title, content, syntax, is_public, is_compressed, expires_at, created_at, updated_at
 FROM pastes
@@ -505,6 +741,37 @@ func (q *Queries) ListTodos(ctx context.Context, arg ListTodosParams) ([]Todo, e
 	return items, nil
 }
 
+const updateShortURLDestination = `-- name: UpdateShortURLDestination :one
+UPDATE short_urls
+SET original_url = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, code, original_url, alias, clicks, is_public, expires_at, created_at, updated_at, bot_clicks, edit_token_hash
+`
+
+type UpdateShortURLDestinationParams struct {
+	ID          int64  `json:"id"`
+	OriginalUrl string `json:"original_url"`
+}
+
+func (q *Queries) UpdateShortURLDestination(ctx context.Context, arg UpdateShortURLDestinationParams) (ShortUrl, error) {
+	row := q.db.QueryRow(ctx, updateShortURLDestination, arg.ID, arg.OriginalUrl)
+	var i ShortUrl
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.OriginalUrl,
+		&i.Alias,
+		&i.Clicks,
+		&i.IsPublic,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.BotClicks,
+		&i.EditTokenHash,
+	)
+	return i, err
+}
+
 const updateTodo = `-- name: UpdateTodo :one
 UPDATE todos
 SET title = $1, description = $2, completed = $3, updated_at = CURRENT_TIMESTAMP
@@ -540,3 +807,30 @@ func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, e
 	)
 	return i, err
 }
+
+const upsertPipelineRecipe = `-- name: UpsertPipelineRecipe :one
+INSERT INTO pipeline_recipes (user_id, name, steps)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, name) DO UPDATE SET steps = EXCLUDED.steps, updated_at = CURRENT_TIMESTAMP
+RETURNING id, created_at, updated_at
+`
+
+type UpsertPipelineRecipeParams struct {
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	Steps  []byte `json:"steps"`
+}
+
+type UpsertPipelineRecipeRow struct {
+	ID        int64            `json:"id"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+}
+
+// Pipeline Recipe Queries
+func (q *Queries) UpsertPipelineRecipe(ctx context.Context, arg UpsertPipelineRecipeParams) (UpsertPipelineRecipeRow, error) {
+	row := q.db.QueryRow(ctx, upsertPipelineRecipe, arg.UserID, arg.Name, arg.Steps)
+	var i UpsertPipelineRecipeRow
+	err := row.Scan(&i.ID, &i.CreatedAt, &i.UpdatedAt)
+	return i, err
+}
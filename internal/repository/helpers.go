@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"math"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -22,6 +23,36 @@ func fromNullString(t pgtype.Text) *string {
 	return &t.String
 }
 
+func toNullInt32(i *int) pgtype.Int4 {
+	// Security: Values outside the int32 range are stored as NULL rather than truncated
+	if i == nil || *i < math.MinInt32 || *i > math.MaxInt32 {
+		return pgtype.Int4{Valid: false}
+	}
+	return pgtype.Int4{Int32: int32(*i), Valid: true} // #nosec G115 - range checked above
+}
+
+func fromNullInt32(i pgtype.Int4) *int {
+	if !i.Valid {
+		return nil
+	}
+	v := int(i.Int32)
+	return &v
+}
+
+func toNullInt64(i *int64) pgtype.Int8 {
+	if i == nil {
+		return pgtype.Int8{Valid: false}
+	}
+	return pgtype.Int8{Int64: *i, Valid: true}
+}
+
+func fromNullInt64(i pgtype.Int8) *int64 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int64
+}
+
 func toNullTime(t *time.Time) pgtype.Timestamp {
 	if t == nil {
 		return pgtype.Timestamp{Valid: false}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/codewithwan/gopilot/internal/repository/db"
+	"github.com/jackc/pgx/v5"
+)
+
+type PipelineRepository struct {
+	queries *db.Queries
+}
+
+func NewPipelineRepository(queries *db.Queries) *PipelineRepository {
+	return &PipelineRepository{queries: queries}
+}
+
+func (r *PipelineRepository) SaveRecipe(ctx context.Context, recipe *domain.PipelineRecipe) error {
+	steps, err := json.Marshal(recipe.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to encode steps: %w", err)
+	}
+
+	result, err := r.queries.UpsertPipelineRecipe(ctx, db.UpsertPipelineRecipeParams{
+		UserID: recipe.UserID,
+		Name:   recipe.Name,
+		Steps:  steps,
+	})
+	if err != nil {
+		return err
+	}
+
+	recipe.ID = result.ID
+	recipe.CreatedAt = result.CreatedAt.Time
+	recipe.UpdatedAt = result.UpdatedAt.Time
+
+	return nil
+}
+
+// GetRecipe returns nil when the user has no recipe with that name
+func (r *PipelineRepository) GetRecipe(ctx context.Context, userID int64, name string) (*domain.PipelineRecipe, error) {
+	result, err := r.queries.GetPipelineRecipe(ctx, db.GetPipelineRecipeParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainPipelineRecipe(result)
+}
+
+func (r *PipelineRepository) ListRecipes(ctx context.Context, userID int64) ([]*domain.PipelineRecipe, error) {
+	results, err := r.queries.ListPipelineRecipesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := make([]*domain.PipelineRecipe, len(results))
+	for i, result := range results {
+		if recipes[i], err = toDomainPipelineRecipe(result); err != nil {
+			return nil, err
+		}
+	}
+
+	return recipes, nil
+}
+
+// DeleteRecipe deletes a recipe and reports whether it existed
+func (r *PipelineRepository) DeleteRecipe(ctx context.Context, userID int64, name string) (bool, error) {
+	rows, err := r.queries.DeletePipelineRecipe(ctx, db.DeletePipelineRecipeParams{
+		UserID: userID,
+		Name:   name,
+	})
+	return rows > 0, err
+}
+
+func toDomainPipelineRecipe(result db.PipelineRecipe) (*domain.PipelineRecipe, error) {
+	recipe := &domain.PipelineRecipe{
+		ID:        result.ID,
+		UserID:    result.UserID,
+		Name:      result.Name,
+		CreatedAt: result.CreatedAt.Time,
+		UpdatedAt: result.UpdatedAt.Time,
+	}
+	if err := json.Unmarshal(result.Steps, &recipe.Steps); err != nil {
+		return nil, fmt.Errorf("failed to decode steps: %w", err)
+	}
+
+	return recipe, nil
+}
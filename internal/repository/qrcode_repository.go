@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/codewithwan/gopilot/internal/domain"
 	"github.com/codewithwan/gopilot/internal/repository/db"
+	"github.com/jackc/pgx/v5"
 )
 
 type QRCodeRepository struct {
@@ -20,13 +22,27 @@ func (r *QRCodeRepository) CreateQRCode(ctx context.Context, qr *domain.QRCode)
 	if qr.Size < 0 || qr.Size > 2048 {
 		qr.Size = 256 // default safe size
 	}
+	// Security: Validate quiet zone is within safe range for int32 conversion
+	if qr.QuietZone < 0 || qr.QuietZone > 20 {
+		qr.QuietZone = 4 // default quiet zone
+	}
 
 	params := db.CreateQRCodeParams{
-		ID:        qr.ID,
-		Text:      qr.Text,
-		Format:    qr.Format,
-		Size:      int32(qr.Size), // #nosec G115 - size is validated to be within safe range
-		ImageData: qr.ImageData,
+		ID:              qr.ID,
+		Symbology:       qr.Symbology,
+		Type:            qr.Type,
+		Text:            qr.Text,
+		Format:          qr.Format,
+		Size:            int32(qr.Size), // #nosec G115 - size is validated to be within safe range
+		Height:          toNullInt32(qr.Height),
+		ErrorCorrection: qr.ErrorCorrection,
+		Foreground:      toNullString(qr.Foreground),
+		Background:      toNullString(qr.Background),
+		QuietZone:       int32(qr.QuietZone), // #nosec G115 - quiet zone is validated to be within safe range
+		Logo:            qr.Logo,
+		ShortCode:       toNullString(qr.ShortCode),
+		UserID:          toNullInt64(qr.UserID),
+		ExpiresAt:       toNullTime(qr.ExpiresAt),
 	}
 
 	result, err := r.queries.CreateQRCode(ctx, params)
@@ -41,16 +57,75 @@ func (r *QRCodeRepository) CreateQRCode(ctx context.Context, qr *domain.QRCode)
 
 func (r *QRCodeRepository) GetQRCodeByID(ctx context.Context, id string) (*domain.QRCode, error) {
 	result, err := r.queries.GetQRCodeByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return &domain.QRCode{
-		ID:        result.ID,
-		Text:      result.Text,
-		Format:    result.Format,
-		Size:      int(result.Size),
-		ImageData: result.ImageData,
-		CreatedAt: result.CreatedAt.Time,
+		ID:              result.ID,
+		Symbology:       result.Symbology,
+		Type:            result.Type,
+		Text:            result.Text,
+		Format:          result.Format,
+		Size:            int(result.Size),
+		Height:          fromNullInt32(result.Height),
+		ErrorCorrection: result.ErrorCorrection,
+		Foreground:      fromNullString(result.Foreground),
+		Background:      fromNullString(result.Background),
+		QuietZone:       int(result.QuietZone),
+		Logo:            result.Logo,
+		ShortCode:       fromNullString(result.ShortCode),
+		UserID:          fromNullInt64(result.UserID),
+		ExpiresAt:       fromNullTime(result.ExpiresAt),
+		CreatedAt:       result.CreatedAt.Time,
 	}, nil
 }
+
+func (r *QRCodeRepository) ListQRCodesByUser(ctx context.Context, userID int64, limit, offset int32) ([]*domain.QRCode, error) {
+	results, err := r.queries.ListQRCodesByUser(ctx, db.ListQRCodesByUserParams{
+		UserID: toNullInt64(&userID),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]*domain.QRCode, len(results))
+	for i, result := range results {
+		codes[i] = &domain.QRCode{
+			ID:              result.ID,
+			Symbology:       result.Symbology,
+			Type:            result.Type,
+			Text:            result.Text,
+			Format:          result.Format,
+			Size:            int(result.Size),
+			Height:          fromNullInt32(result.Height),
+			ErrorCorrection: result.ErrorCorrection,
+			Foreground:      fromNullString(result.Foreground),
+			Background:      fromNullString(result.Background),
+			QuietZone:       int(result.QuietZone),
+			ShortCode:       fromNullString(result.ShortCode),
+			UserID:          fromNullInt64(result.UserID),
+			ExpiresAt:       fromNullTime(result.ExpiresAt),
+			CreatedAt:       result.CreatedAt.Time,
+		}
+	}
+
+	return codes, nil
+}
+
+func (r *QRCodeRepository) CountQRCodesByUser(ctx context.Context, userID int64) (int64, error) {
+	return r.queries.CountQRCodesByUser(ctx, toNullInt64(&userID))
+}
+
+func (r *QRCodeRepository) DeleteQRCode(ctx context.Context, id string) error {
+	return r.queries.DeleteQRCode(ctx, id)
+}
+
+func (r *QRCodeRepository) DeleteExpiredQRCodes(ctx context.Context) error {
+	return r.queries.DeleteExpiredQRCodes(ctx)
+}
@@ -17,12 +17,13 @@ func NewURLShortenerRepository(queries *db.Queries) *URLShortenerRepository {
 
 func (r *URLShortenerRepository) CreateShortURL(ctx context.Context, shortURL *domain.ShortURL) error {
 	params := db.CreateShortURLParams{
-		Code:        shortURL.Code,
-		OriginalUrl: shortURL.OriginalURL,
-		Alias:       toNullString(shortURL.Alias),
-		Clicks:      shortURL.Clicks,
-		IsPublic:    shortURL.IsPublic,
-		ExpiresAt:   toNullTime(shortURL.ExpiresAt),
+		Code:          shortURL.Code,
+		OriginalUrl:   shortURL.OriginalURL,
+		Alias:         toNullString(shortURL.Alias),
+		Clicks:        shortURL.Clicks,
+		IsPublic:      shortURL.IsPublic,
+		ExpiresAt:     toNullTime(shortURL.ExpiresAt),
+		EditTokenHash: toNullString(shortURL.EditTokenHash),
 	}
 
 	result, err := r.queries.CreateShortURL(ctx, params)
@@ -43,29 +44,53 @@ func (r *URLShortenerRepository) GetShortURLByCode(ctx context.Context, code str
 		return nil, err
 	}
 
+	return toDomainShortURL(result), nil
+}
+
+func (r *URLShortenerRepository) UpdateDestination(ctx context.Context, id int64, originalURL string) (*domain.ShortURL, error) {
+	result, err := r.queries.UpdateShortURLDestination(ctx, db.UpdateShortURLDestinationParams{
+		ID:          id,
+		OriginalUrl: originalURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainShortURL(result), nil
+}
+
+func toDomainShortURL(result db.ShortUrl) *domain.ShortURL {
 	return &domain.ShortURL{
-		ID:          result.ID,
-		Code:        result.Code,
-		OriginalURL: result.OriginalUrl,
-		Alias:       fromNullString(result.Alias),
-		Clicks:      result.Clicks,
-		IsPublic:    result.IsPublic,
-		ExpiresAt:   fromNullTime(result.ExpiresAt),
-		CreatedAt:   result.CreatedAt.Time,
-		UpdatedAt:   result.UpdatedAt.Time,
-	}, nil
+		ID:            result.ID,
+		Code:          result.Code,
+		OriginalURL:   result.OriginalUrl,
+		Alias:         fromNullString(result.Alias),
+		Clicks:        result.Clicks,
+		BotClicks:     result.BotClicks,
+		IsPublic:      result.IsPublic,
+		ExpiresAt:     fromNullTime(result.ExpiresAt),
+		Editable:      result.EditTokenHash.Valid,
+		EditTokenHash: fromNullString(result.EditTokenHash),
+		CreatedAt:     result.CreatedAt.Time,
+		UpdatedAt:     result.UpdatedAt.Time,
+	}
 }
 
 func (r *URLShortenerRepository) IncrementClicks(ctx context.Context, id int64) error {
 	return r.queries.IncrementShortURLClicks(ctx, id)
 }
 
+func (r *URLShortenerRepository) IncrementBotClicks(ctx context.Context, id int64) error {
+	return r.queries.IncrementShortURLBotClicks(ctx, id)
+}
+
 func (r *URLShortenerRepository) LogClick(ctx context.Context, click *domain.URLClickLog) error {
 	params := db.CreateURLClickParams{
 		ShortUrlID: click.ShortURLID,
 		Referrer:   toNullString(click.Referrer),
 		UserAgent:  toNullString(click.UserAgent),
 		IpAddress:  toNullString(click.IPAddress),
+		IsBot:      click.IsBot,
 	}
 
 	return r.queries.CreateURLClick(ctx, params)
@@ -18,18 +18,20 @@ type AuthService interface {
 }
 
 type authService struct {
-	userRepo      repository.UserRepository
-	jwtMiddleware *middleware.JWTMiddleware
-	jwtExpiration time.Duration
-	logger        *zap.Logger
+	userRepo         repository.UserRepository
+	jwtMiddleware    *middleware.JWTMiddleware
+	jwtExpiration    time.Duration
+	minPasswordScore int
+	logger           *zap.Logger
 }
 
-func NewAuthService(userRepo repository.UserRepository, jwtMiddleware *middleware.JWTMiddleware, jwtExpiration time.Duration, logger *zap.Logger) AuthService {
+func NewAuthService(userRepo repository.UserRepository, jwtMiddleware *middleware.JWTMiddleware, jwtExpiration time.Duration, minPasswordScore int, logger *zap.Logger) AuthService {
 	return &authService{
-		userRepo:      userRepo,
-		jwtMiddleware: jwtMiddleware,
-		jwtExpiration: jwtExpiration,
-		logger:        logger,
+		userRepo:         userRepo,
+		jwtMiddleware:    jwtMiddleware,
+		jwtExpiration:    jwtExpiration,
+		minPasswordScore: minPasswordScore,
+		logger:           logger,
 	}
 }
 
@@ -40,6 +42,11 @@ func (s *authService) Register(ctx context.Context, req *domain.RegisterRequest)
 		return nil, ErrUserAlreadyExists
 	}
 
+	// Reject weak passwords, treating the username as a guessable word
+	if err := checkPasswordStrength(req.Password, s.minPasswordScore, req.Username); err != nil {
+		return nil, err
+	}
+
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/codewithwan/gopilot/internal/middleware"
+	"go.uber.org/zap"
+)
+
+// memoryUserRepository keeps users in memory for tests
+type memoryUserRepository struct {
+	users map[string]*domain.User
+}
+
+func newMemoryUserRepository() *memoryUserRepository {
+	return &memoryUserRepository{users: make(map[string]*domain.User)}
+}
+
+func (r *memoryUserRepository) Create(_ context.Context, user *domain.User) (*domain.User, error) {
+	created := *user
+	created.ID = int64(len(r.users) + 1)
+	r.users[user.Username] = &created
+	return &created, nil
+}
+
+func (r *memoryUserRepository) GetByUsername(_ context.Context, username string) (*domain.User, error) {
+	user, ok := r.users[username]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepository) GetByID(_ context.Context, id int64) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func TestRegister_PasswordStrength(t *testing.T) {
+	const minScore = 2
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		minScore int
+		wantErr  error
+	}{
+		{"common password", "alice", "password123", minScore, ErrWeakPassword},
+		{"keyboard pattern", "alice", "qwertyuiop", minScore, ErrWeakPassword},
+		{"username as password", "marjoram", "marjoram2024", minScore, ErrWeakPassword},
+		{"strong password", "alice", "correct-horse-battery-staple", minScore, nil},
+		{"check disabled", "alice", "password123", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryUserRepository()
+			svc := NewAuthService(repo, middleware.NewJWTMiddleware("test-secret"), time.Hour, tt.minScore, zap.NewNop())
+
+			user, err := svc.Register(context.Background(), &domain.RegisterRequest{Username: tt.username, Password: tt.password})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+
+			_, stored := repo.users[tt.username]
+			if tt.wantErr != nil {
+				if stored {
+					t.Error("Expected a rejected user not to be stored")
+				}
+				return
+			}
+			if !stored || user.Username != tt.username {
+				t.Errorf("Expected user %q to be created", tt.username)
+			}
+			if user.Password == tt.password {
+				t.Error("Expected the password to be hashed")
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/boombuler/barcode"
+)
+
+// barcodeBitmap samples an unscaled barcode into a bitmap of dark modules
+func barcodeBitmap(bc barcode.Barcode) [][]bool {
+	b := bc.Bounds()
+	bitmap := make([][]bool, b.Dy())
+	for y := range bitmap {
+		bitmap[y] = make([]bool, b.Dx())
+		for x := range bitmap[y] {
+			gray := color.GrayModel.Convert(bc.At(b.Min.X+x, b.Min.Y+y)).(color.Gray)
+			bitmap[y][x] = gray.Y < 0x80
+		}
+	}
+	return bitmap
+}
+
+// renderBarcode renders a barcode bitmap at width x height pixels. Modules are
+// scaled by whole pixels and centered so bar widths stay uniform; the image
+// grows if it is too small to fit one pixel per module, up to
+// maxBarcodeDimension. Linear barcodes have a
+// single row which is stretched to the full height.
+func renderBarcode(bitmap [][]bool, linear bool, format string, width, height int, style qrStyle) ([]byte, error) {
+	switch format {
+	case QRFormatPNG:
+		return renderBarcodePNG(bitmap, linear, width, height, style)
+	case QRFormatSVG:
+		return renderBarcodeSVG(bitmap, linear, width, height, style), nil
+	default:
+		return nil, fmt.Errorf("unsupported barcode format: %s", format)
+	}
+}
+
+// renderBarcodePNG rasterizes a barcode bitmap to PNG
+func renderBarcodePNG(bitmap [][]bool, linear bool, width, height int, style qrStyle) ([]byte, error) {
+	modW, modH := len(bitmap[0]), len(bitmap)
+
+	xScale := max(1, width/modW)
+	yScale := max(1, height/modH)
+	if !linear {
+		// Two-dimensional codes need square modules
+		xScale = max(1, min(width/modW, height/modH))
+		yScale = xScale
+	}
+
+	w, h := max(width, modW*xScale), max(height, modH*yScale)
+	if w > maxBarcodeDimension || h > maxBarcodeDimension {
+		return nil, fmt.Errorf("barcode needs %dx%d pixels, more than the %d pixel maximum", w, h, maxBarcodeDimension)
+	}
+	ox, oy := (w-modW*xScale)/2, (h-modH*yScale)/2
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: style.Background}, image.Point{}, draw.Src)
+
+	fg := &image.Uniform{C: style.Foreground}
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				module := image.Rect(ox+x*xScale, oy+y*yScale, ox+(x+1)*xScale, oy+(y+1)*yScale)
+				draw.Draw(img, module, fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderBarcodeSVG renders a barcode bitmap as an SVG document. Linear codes
+// are stretched to fill the viewport; two-dimensional codes keep square
+// modules and are centered.
+func renderBarcodeSVG(bitmap [][]bool, linear bool, width, height int, style qrStyle) []byte {
+	modW, modH := len(bitmap[0]), len(bitmap)
+
+	aspect := "xMidYMid meet"
+	if linear {
+		aspect = "none"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" preserveAspectRatio="%s" shape-rendering="crispEdges" style="background-color:%s">`+"\n",
+		width, height, modW, modH, aspect, hexColor(style.Background))
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`+"\n", modW, modH, hexColor(style.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hexColor(style.Foreground))
+	for _, r := range darkRuns(bitmap) {
+		fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", r.x, r.y, r.width, r.width)
+	}
+	buf.WriteString(`"/>` + "\n</svg>\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/aztec"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/code39"
+	"github.com/boombuler/barcode/datamatrix"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/pdf417"
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+// Barcode symbologies
+const (
+	SymbologyQR         = "qr"
+	SymbologyCode128    = "code128"
+	SymbologyCode39     = "code39"
+	SymbologyEAN8       = "ean8"
+	SymbologyEAN13      = "ean13"
+	SymbologyUPCA       = "upca"
+	SymbologyDataMatrix = "datamatrix"
+	SymbologyAztec      = "aztec"
+	SymbologyPDF417     = "pdf417"
+)
+
+// barcodeQuietZones are the default quiet zones in modules. Linear barcodes
+// only get them on the left and right.
+var barcodeQuietZones = map[string]int{
+	SymbologyCode128:    10,
+	SymbologyCode39:     10,
+	SymbologyEAN8:       7,
+	SymbologyEAN13:      11,
+	SymbologyUPCA:       9,
+	SymbologyDataMatrix: 1,
+	SymbologyAztec:      1,
+	SymbologyPDF417:     2,
+}
+
+// maxBarcodeDimension caps the rendered width and height of a barcode in pixels
+const maxBarcodeDimension = 2048
+
+// defaultPDF417SecurityLevel is the PDF417 error correction level used when none is requested
+const defaultPDF417SecurityLevel = 2
+
+// code39Alphabet lists the Code 39 characters in check character order
+const code39Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ-. $/+%"
+
+// isLinearSymbology reports whether a symbology is a one-dimensional barcode
+func isLinearSymbology(symbology string) bool {
+	switch symbology {
+	case SymbologyCode128, SymbologyCode39, SymbologyEAN8, SymbologyEAN13, SymbologyUPCA:
+		return true
+	default:
+		return false
+	}
+}
+
+// GenerateBarcode generates a linear or two-dimensional barcode owned by
+// userID, which is nil for anonymous requests. Barcodes share storage and
+// retrieval with QR codes.
+func (s *QRCodeService) GenerateBarcode(ctx context.Context, req *domain.GenerateBarcodeRequest, userID *int64) (*domain.QRCode, error) {
+	content, err := normalizeBarcodeText(req.Symbology, req.Text, req.Checksum)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
+	}
+
+	format := QRFormatPNG
+	if req.Format != nil {
+		format = *req.Format
+	}
+
+	quietZone := barcodeQuietZones[req.Symbology]
+	if req.QuietZone != nil {
+		quietZone = *req.QuietZone
+	}
+
+	errorCorrection := ""
+	if req.Symbology == SymbologyPDF417 {
+		level := defaultPDF417SecurityLevel
+		if req.SecurityLevel != nil {
+			level = *req.SecurityLevel
+		}
+		errorCorrection = strconv.Itoa(level)
+	}
+
+	var expiresAt *time.Time
+	if req.ExpireIn != nil {
+		expiry := time.Now().Add(time.Duration(*req.ExpireIn) * time.Hour)
+		expiresAt = &expiry
+	}
+
+	qr := &domain.QRCode{
+		ID:              s.generateID(10),
+		Symbology:       req.Symbology,
+		Type:            QRTypeText,
+		Text:            content,
+		Format:          format,
+		ErrorCorrection: errorCorrection,
+		Foreground:      req.Foreground,
+		Background:      req.Background,
+		QuietZone:       quietZone,
+		UserID:          userID,
+		ExpiresAt:       expiresAt,
+	}
+
+	bitmap, err := s.barcodeModules(qr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
+	}
+	// Every module needs at least one pixel
+	if len(bitmap[0]) > maxBarcodeDimension || len(bitmap) > maxBarcodeDimension {
+		return nil, fmt.Errorf("%w: barcode is %dx%d modules, more than the %d pixel maximum, shorten the text or quiet zone",
+			ErrInvalidQRCode, len(bitmap[0]), len(bitmap), maxBarcodeDimension)
+	}
+
+	// Default to 2 pixels per module for linear codes and 4 for 2D codes
+	width, height := len(bitmap[0])*2, 100
+	if !isLinearSymbology(req.Symbology) {
+		width, height = len(bitmap[0])*4, len(bitmap)*4
+	}
+	if req.Width != nil {
+		width = *req.Width
+	}
+	if req.Height != nil {
+		height = *req.Height
+	}
+	qr.Size = min(width, maxBarcodeDimension)
+	height = min(height, maxBarcodeDimension)
+	qr.Height = &height
+
+	qr.ImageData, err = s.renderBarcode(qr, bitmap)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateQRCode(ctx, qr); err != nil {
+		return nil, fmt.Errorf("failed to save barcode: %w", err)
+	}
+
+	return qr, nil
+}
+
+// barcodeModules encodes a stored barcode and returns its module bitmap including the quiet zone
+func (s *QRCodeService) barcodeModules(qr *domain.QRCode) ([][]bool, error) {
+	bc, err := encodeBarcode(qr.Symbology, qr.Text, qr.ErrorCorrection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate barcode: %w", err)
+	}
+
+	bitmap := barcodeBitmap(bc)
+	if isLinearSymbology(qr.Symbology) {
+		return withMargins(bitmap, qr.QuietZone, 0), nil
+	}
+	return withQuietZone(bitmap, qr.QuietZone), nil
+}
+
+// renderBarcode renders a barcode bitmap with the stored size, format and colors
+func (s *QRCodeService) renderBarcode(qr *domain.QRCode, bitmap [][]bool) ([]byte, error) {
+	style, err := s.buildStyle(&domain.GenerateQRRequest{
+		Foreground: qr.Foreground,
+		Background: qr.Background,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
+	}
+
+	height := qr.Size
+	if qr.Height != nil {
+		height = *qr.Height
+	}
+
+	imageData, err := renderBarcode(bitmap, isLinearSymbology(qr.Symbology), qr.Format, qr.Size, height, style)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render barcode: %w", err)
+	}
+
+	return imageData, nil
+}
+
+// renderStoredBarcode re-renders a stored barcode
+func (s *QRCodeService) renderStoredBarcode(qr *domain.QRCode) ([]byte, error) {
+	bitmap, err := s.barcodeModules(qr)
+	if err != nil {
+		return nil, err
+	}
+	return s.renderBarcode(qr, bitmap)
+}
+
+// encodeBarcode encodes normalized content in the given symbology.
+// errorCorrection holds the PDF417 security level.
+func encodeBarcode(symbology, content, errorCorrection string) (barcode.Barcode, error) {
+	switch symbology {
+	case SymbologyCode128:
+		return code128.Encode(content)
+	case SymbologyCode39:
+		// Any check character is already part of the normalized content
+		return code39.Encode(content, false, false)
+	case SymbologyEAN8, SymbologyEAN13:
+		return ean.Encode(content)
+	case SymbologyUPCA:
+		// UPC-A is an EAN-13 with a leading zero
+		return ean.Encode("0" + content)
+	case SymbologyDataMatrix:
+		return datamatrix.Encode(content)
+	case SymbologyAztec:
+		return aztec.Encode([]byte(content), aztec.DEFAULT_EC_PERCENT, aztec.DEFAULT_LAYERS)
+	case SymbologyPDF417:
+		level, err := strconv.Atoi(errorCorrection)
+		if err != nil || level < 0 || level > 8 {
+			level = defaultPDF417SecurityLevel
+		}
+		return pdf417.Encode(content, byte(level)) // #nosec G115 - level is between 0 and 8
+	default:
+		return nil, fmt.Errorf("unsupported symbology: %s", symbology)
+	}
+}
+
+// normalizeBarcodeText validates text for a symbology and returns the content
+// to encode. Check digits are appended to EAN/UPC numbers given without one
+// and verified when present; checksum appends the Code 39 check character.
+func normalizeBarcodeText(symbology, text string, checksum bool) (string, error) {
+	switch symbology {
+	case SymbologyEAN8:
+		return normalizeGTIN(text, 8, "EAN-8")
+	case SymbologyEAN13:
+		return normalizeGTIN(text, 13, "EAN-13")
+	case SymbologyUPCA:
+		return normalizeGTIN(text, 12, "UPC-A")
+	case SymbologyCode39:
+		for _, r := range text {
+			if !strings.ContainsRune(code39Alphabet, r) {
+				return "", fmt.Errorf("invalid Code 39 character %q: only 0-9, A-Z, space and -.$/+%% are allowed", r)
+			}
+		}
+		if checksum {
+			text += string(code39CheckCharacter(text))
+		}
+		return text, nil
+	case SymbologyCode128:
+		for _, r := range text {
+			if r > 127 {
+				return "", fmt.Errorf("invalid Code 128 character %q: only ASCII is allowed", r)
+			}
+		}
+		return text, nil
+	default:
+		return text, nil
+	}
+}
+
+// normalizeGTIN validates an EAN/UPC number of the given full length, appending
+// the check digit when it is omitted and verifying it when present
+func normalizeGTIN(text string, length int, name string) (string, error) {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%s must contain only digits", name)
+		}
+	}
+
+	switch len(text) {
+	case length - 1:
+		return text + string(gtinCheckDigit(text)), nil
+	case length:
+		expected := gtinCheckDigit(text[:length-1])
+		if text[length-1] != expected {
+			return "", fmt.Errorf("invalid %s check digit: expected %c, got %c", name, expected, text[length-1])
+		}
+		return text, nil
+	default:
+		return "", fmt.Errorf("%s requires %d digits, or %d without the check digit", name, length, length-1)
+	}
+}
+
+// gtinCheckDigit computes the GS1 mod 10 check digit, weighting digits 3 and 1
+// alternately starting from the right
+func gtinCheckDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// code39CheckCharacter computes the optional Code 39 mod 43 check character
+func code39CheckCharacter(text string) byte {
+	sum := 0
+	for _, r := range text {
+		sum += strings.IndexRune(code39Alphabet, r)
+	}
+	return code39Alphabet[sum%43]
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	"github.com/makiuchi-d/gozxing/oned"
+)
+
+// TestGenerateBarcode_DecodesBack self-tests generated barcodes by scanning them
+func TestGenerateBarcode_DecodesBack(t *testing.T) {
+	tests := []struct {
+		symbology string
+		text      string
+		checksum  bool
+		reader    gozxing.Reader
+		want      string
+	}{
+		{SymbologyCode128, "WH-0042/bin 7", false, oned.NewCode128Reader(), "WH-0042/bin 7"},
+		{SymbologyCode39, "PALLET 12", true, oned.NewCode39Reader(), "PALLET 12"},
+		{SymbologyEAN8, "9638507", false, oned.NewEAN8Reader(), "96385074"},
+		{SymbologyEAN13, "4006381333931", false, oned.NewEAN13Reader(), "4006381333931"},
+		{SymbologyUPCA, "03600029145", false, oned.NewUPCAReader(), "036000291452"},
+		{SymbologyDataMatrix, "LOT 2024-11 EXP 2026", false, datamatrix.NewDataMatrixReader(), "LOT 2024-11 EXP 2026"},
+		{SymbologyAztec, "boarding pass 42", false, aztec.NewAztecReader(), "boarding pass 42"},
+	}
+
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+	for _, tt := range tests {
+		t.Run(tt.symbology, func(t *testing.T) {
+			bc, err := svc.GenerateBarcode(context.Background(), &domain.GenerateBarcodeRequest{
+				Symbology: tt.symbology,
+				Text:      tt.text,
+				Checksum:  tt.checksum,
+			}, nil)
+			if err != nil {
+				t.Fatalf("Failed to generate barcode: %v", err)
+			}
+
+			stored, err := svc.GetQRCode(context.Background(), bc.ID, nil)
+			if err != nil {
+				t.Fatalf("Failed to get barcode: %v", err)
+			}
+			if !bytes.Equal(stored.ImageData, bc.ImageData) {
+				t.Error("Re-rendered image differs from generated image")
+			}
+
+			img, err := png.Decode(bytes.NewReader(stored.ImageData))
+			if err != nil {
+				t.Fatalf("Failed to read barcode: %v", err)
+			}
+			bitmap, _ := gozxing.NewBinaryBitmapFromImage(img)
+			result, err := tt.reader.Decode(bitmap, map[gozxing.DecodeHintType]interface{}{
+				gozxing.DecodeHintType_PURE_BARCODE: true,
+			})
+			if err != nil {
+				t.Fatalf("Failed to decode barcode: %v", err)
+			}
+
+			got := result.GetText()
+			if tt.checksum {
+				// The reader leaves the check character in place
+				got = got[:len(got)-1]
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeBarcodeText(t *testing.T) {
+	tests := []struct {
+		symbology string
+		text      string
+		checksum  bool
+		want      string
+		wantErr   string
+	}{
+		{SymbologyEAN13, "400638133393", false, "4006381333931", ""},
+		{SymbologyEAN13, "4006381333932", false, "", "expected 1"},
+		{SymbologyEAN13, "40063813339", false, "", "requires 13 digits"},
+		{SymbologyEAN8, "9638507A", false, "", "only digits"},
+		{SymbologyUPCA, "036000291452", false, "036000291452", ""},
+		{SymbologyCode39, "CODE 39", true, "CODE 39R", ""},
+		{SymbologyCode39, "lower", false, "", "invalid Code 39 character"},
+		{SymbologyCode128, "café", false, "", "only ASCII"},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeBarcodeText(tt.symbology, tt.text, tt.checksum)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s %q: expected error containing %q, got %v", tt.symbology, tt.text, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: unexpected error: %v", tt.symbology, tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %q: expected %q, got %q", tt.symbology, tt.text, tt.want, got)
+		}
+	}
+}
+
+func TestGenerateBarcode_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  domain.GenerateBarcodeRequest
+	}{
+		{"bad check digit", domain.GenerateBarcodeRequest{Symbology: SymbologyEAN13, Text: "4006381333932"}},
+		{"bad color", domain.GenerateBarcodeRequest{Symbology: SymbologyCode128, Text: "ok", Foreground: stringPtr("nope")}},
+		{"wider than the maximum", domain.GenerateBarcodeRequest{Symbology: SymbologyCode128, Text: strings.Repeat("x", 400), Width: intPtr(2048)}},
+	}
+
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.GenerateBarcode(context.Background(), &tt.req, nil); !errors.Is(err, ErrInvalidQRCode) {
+				t.Errorf("Expected ErrInvalidQRCode, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// Digit alphabets for base conversion
+const (
+	AlphabetStandard  = "standard"
+	AlphabetBase62    = "base62"
+	AlphabetBase64    = "base64"
+	AlphabetBase64URL = "base64url"
+	AlphabetCrockford = "crockford"
+)
+
+// baseAlphabets lists the digits of each alphabet in value order. Bases use
+// the first base digits of their alphabet.
+var baseAlphabets = map[string]string{
+	// The digits of strconv and math/big: case-insensitive up to base 36
+	AlphabetStandard:  "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	AlphabetBase62:    "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	AlphabetBase64:    "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/",
+	AlphabetBase64URL: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+	AlphabetCrockford: "0123456789ABCDEFGHJKMNPQRSTVWXYZ",
+}
+
+// defaultBasePrecision is the number of fractional digits output when none is requested
+const defaultBasePrecision = 20
+
+// digitAlphabet maps digits of a base to their values and back
+type digitAlphabet struct {
+	name   string
+	base   int
+	digits string
+	values map[rune]int
+}
+
+// newDigitAlphabet returns the digits of base in the named alphabet. Without
+// a name, bases up to 62 use the standard alphabet and larger bases base64.
+func newDigitAlphabet(name *string, base int) (*digitAlphabet, error) {
+	alphabetName := AlphabetStandard
+	if name != nil {
+		alphabetName = *name
+	} else if base > len(baseAlphabets[AlphabetStandard]) {
+		alphabetName = AlphabetBase64
+	}
+
+	digits, ok := baseAlphabets[alphabetName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported alphabet: %s", alphabetName)
+	}
+	if base > len(digits) {
+		return nil, fmt.Errorf("%s alphabet supports bases up to %d", alphabetName, len(digits))
+	}
+
+	a := &digitAlphabet{
+		name:   alphabetName,
+		base:   base,
+		digits: digits[:base],
+		values: make(map[rune]int, base*2),
+	}
+	for i, r := range a.digits {
+		a.values[r] = i
+	}
+
+	switch {
+	case alphabetName == AlphabetStandard && base <= 36:
+		for i, r := range a.digits {
+			a.values[unicode.ToUpper(r)] = i
+		}
+	case alphabetName == AlphabetCrockford:
+		// Crockford decoding is case-insensitive and reads I and L as 1 and O as 0
+		for i, r := range a.digits {
+			a.values[unicode.ToLower(r)] = i
+		}
+		for _, r := range "iIlL" {
+			a.values[r] = 1
+		}
+		for _, r := range "oO" {
+			a.values[r] = 0
+		}
+	}
+
+	return a, nil
+}
+
+// isDigit reports whether r is a digit of the alphabet
+func (a *digitAlphabet) isDigit(r rune) bool {
+	_, ok := a.values[r]
+	return ok
+}
+
+// parse reads a signed number with an optional fractional part
+func (a *digitAlphabet) parse(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+
+	negative := false
+	if value != "" && (value[0] == '-' || value[0] == '+') && !a.isDigit(rune(value[0])) {
+		negative = value[0] == '-'
+		value = value[1:]
+	}
+	if a.name == AlphabetCrockford {
+		// Crockford allows hyphens as visual separators
+		value = strings.ReplaceAll(value, "-", "")
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(value, ".")
+	if intPart == "" && fracPart == "" {
+		return nil, fmt.Errorf("no digits in value")
+	}
+
+	base := big.NewInt(int64(a.base))
+	num := new(big.Int)
+	denom := big.NewInt(1)
+	digit := new(big.Int)
+	for _, r := range intPart + fracPart {
+		v, ok := a.values[r]
+		if !ok {
+			if r == '.' && hasPoint {
+				return nil, fmt.Errorf("value has more than one radix point")
+			}
+			return nil, fmt.Errorf("invalid digit %q for base %d", r, a.base)
+		}
+		num.Mul(num, base)
+		num.Add(num, digit.SetInt64(int64(v)))
+	}
+	for range len([]rune(fracPart)) {
+		denom.Mul(denom, base)
+	}
+
+	if negative {
+		num.Neg(num)
+	}
+	return new(big.Rat).SetFrac(num, denom), nil
+}
+
+// formatInt writes a non-negative integer, left-padded with zeros to minDigits
+func (a *digitAlphabet) formatInt(n *big.Int, minDigits int) string {
+	var digits []byte
+	base := big.NewInt(int64(a.base))
+	q, rem := new(big.Int).Set(n), new(big.Int)
+	for q.Sign() > 0 {
+		q.QuoRem(q, base, rem)
+		digits = append(digits, a.digits[rem.Int64()])
+	}
+	for len(digits) < max(minDigits, 1) {
+		digits = append(digits, a.digits[0])
+	}
+
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// format writes a number with at most precision fractional digits and
+// reports whether fractional digits were cut off
+func (a *digitAlphabet) format(x *big.Rat, precision int) (string, bool) {
+	var sb strings.Builder
+	if x.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+
+	intPart, rem := new(big.Int).QuoRem(new(big.Int).Abs(x.Num()), x.Denom(), new(big.Int))
+	sb.WriteString(a.formatInt(intPart, 1))
+	if rem.Sign() == 0 {
+		return sb.String(), false
+	}
+
+	// Multiply the remainder by the base to shift out one digit at a time
+	base := big.NewInt(int64(a.base))
+	digit := new(big.Int)
+	for i := 0; i < precision && rem.Sign() != 0; i++ {
+		if i == 0 {
+			sb.WriteByte('.')
+		}
+		rem.Mul(rem, base)
+		digit.QuoRem(rem, x.Denom(), rem)
+		sb.WriteByte(a.digits[digit.Int64()])
+	}
+
+	return sb.String(), rem.Sign() != 0
+}
+
+// fromTwosComplement reads x as a two's complement value of width bits
+func fromTwosComplement(x *big.Rat, width int) (*big.Rat, error) {
+	if !x.IsInt() || x.Sign() < 0 || x.Num().BitLen() > width {
+		return nil, fmt.Errorf("signed input must be a non-negative integer of at most %d bits", width)
+	}
+
+	n := new(big.Int).Set(x.Num())
+	if n.Bit(width-1) == 1 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(width))) // #nosec G115 - width is validated to be positive
+	}
+	return new(big.Rat).SetInt(n), nil
+}
+
+// toTwosComplement returns the width-bit two's complement of x, which must be
+// an integer from -2^(width-1) to 2^width-1
+func toTwosComplement(x *big.Rat, width int) (*big.Int, error) {
+	if !x.IsInt() {
+		return nil, fmt.Errorf("two's complement requires an integer value")
+	}
+
+	modulus := new(big.Int).Lsh(big.NewInt(1), uint(width)) // #nosec G115 - width is validated to be positive
+	minValue := new(big.Int).Neg(new(big.Int).Rsh(modulus, 1))
+	n := new(big.Int).Set(x.Num())
+	if n.Cmp(minValue) < 0 || n.Cmp(modulus) >= 0 {
+		return nil, fmt.Errorf("value does not fit in %d bits", width)
+	}
+
+	if n.Sign() < 0 {
+		n.Add(n, modulus)
+	}
+	return n, nil
+}
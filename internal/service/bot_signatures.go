@@ -0,0 +1,110 @@
+package service
+
+import "strings"
+
+// botUserAgentSignatures lists lower-cased user agent fragments of link
+// unfurlers, crawlers and monitoring tools whose requests should not be
+// counted as human clicks. Keep entries specific enough that they cannot
+// match a regular browser user agent.
+var botUserAgentSignatures = []string{
+	// Chat and social link unfurlers
+	"slackbot",
+	"slack-imgproxy",
+	"skypeuripreview",
+	"microsoftpreview",
+	"discordbot",
+	"telegrambot",
+	"twitterbot",
+	"facebookexternalhit",
+	"facebookcatalog",
+	"linkedinbot",
+	"pinterestbot",
+	"redditbot",
+	"mastodon",
+	"embedly",
+	"iframely",
+	"vkshare",
+	"line-poker",
+	"bitlybot",
+	"google-pagerenderer",
+
+	// Search engine crawlers
+	"googlebot",
+	"google-inspectiontool",
+	"adsbot-google",
+	"mediapartners-google",
+	"bingbot",
+	"bingpreview",
+	"duckduckbot",
+	"baiduspider",
+	"yandexbot",
+	"applebot",
+	"petalbot",
+	"sogou web spider",
+	"exabot",
+	"ahrefsbot",
+	"semrushbot",
+	"mj12bot",
+	"dotbot",
+
+	// Link checkers, HTTP libraries and monitoring
+	"headlesschrome",
+	"lighthouse",
+	"pingdom",
+	"uptimerobot",
+	"statuscake",
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"go-http-client",
+	"okhttp",
+	"java/",
+	"libwww-perl",
+	"httpclient",
+	"axios/",
+	"node-fetch",
+
+	// Generic markers
+	"bot/",
+	"bot;",
+	"crawler",
+	"spider",
+	"link preview",
+	"web preview",
+}
+
+// botUserAgentPrefixes lists lower-cased user agent prefixes of unfurlers
+// whose app name also appears at the end of their in-app browser user agent
+var botUserAgentPrefixes = []string{
+	"whatsapp/",
+	"viber/",
+}
+
+// isPrefetchPurpose reports whether a Purpose / Sec-Purpose header value
+// marks the request as a speculative prefetch or prerender
+func isPrefetchPurpose(purpose string) bool {
+	purpose = strings.ToLower(purpose)
+	return strings.Contains(purpose, "prefetch") || strings.Contains(purpose, "prerender")
+}
+
+// isBotUserAgent reports whether the user agent matches a known bot signature
+func isBotUserAgent(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, prefix := range botUserAgentPrefixes {
+		if strings.HasPrefix(ua, prefix) {
+			return true
+		}
+	}
+	for _, signature := range botUserAgentSignatures {
+		if strings.Contains(ua, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBotClick classifies a click as automated based on its user agent and purpose header
+func isBotClick(userAgent, purpose string) bool {
+	return isPrefetchPurpose(purpose) || isBotUserAgent(userAgent)
+}
@@ -0,0 +1,89 @@
+package service
+
+import "testing"
+
+const (
+	chromeUserAgent        = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	chromeAndroidUserAgent = "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+	safariUserAgent        = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15"
+	safariIOSUserAgent     = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1"
+	firefoxUserAgent       = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+)
+
+func TestIsBotUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      bool
+	}{
+		{"chrome", chromeUserAgent, false},
+		{"chrome android", chromeAndroidUserAgent, false},
+		{"safari", safariUserAgent, false},
+		{"safari ios", safariIOSUserAgent, false},
+		{"firefox", firefoxUserAgent, false},
+		{"viber in-app browser", chromeAndroidUserAgent + " Viber/21.0.0.0", false},
+		{"whatsapp in-app browser", safariIOSUserAgent + " WhatsApp/23.20.79", false},
+		{"empty", "", false},
+		{"slack", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"whatsapp", "WhatsApp/2.23.20.0 A", true},
+		{"viber", "Viber/20.8.0", true},
+		{"facebook", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"twitter", "Twitterbot/1.0", true},
+		{"googlebot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", true},
+		{"bing preview", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) BingPreview/1.0b", true},
+		{"yahoo link preview", "Mozilla/5.0 (compatible; Yahoo Link Preview; https://help.yahoo.com/kb/mail/yahoo-link-preview-SLN23615.html)", true},
+		{"curl", "curl/8.4.0", true},
+		{"headless chrome", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBotUserAgent(tt.userAgent); got != tt.want {
+				t.Errorf("Expected %v for %q, got %v", tt.want, tt.userAgent, got)
+			}
+		})
+	}
+}
+
+func TestIsPrefetchPurpose(t *testing.T) {
+	tests := []struct {
+		purpose string
+		want    bool
+	}{
+		{"", false},
+		{"prefetch", true},
+		{"Prefetch", true},
+		{"prefetch;prerender", true},
+		{"prefetch;anonymous-client-ip", true},
+		{"preview", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPrefetchPurpose(tt.purpose); got != tt.want {
+			t.Errorf("Expected %v for purpose %q, got %v", tt.want, tt.purpose, got)
+		}
+	}
+}
+
+func TestIsBotClick(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		purpose   string
+		want      bool
+	}{
+		{"browser click", chromeUserAgent, "", false},
+		{"browser prefetch", chromeUserAgent, "prefetch", true},
+		{"bot without purpose", "Twitterbot/1.0", "", true},
+		{"firefox click", firefoxUserAgent, "", false},
+		{"safari prerender", safariIOSUserAgent, "prefetch;prerender", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBotClick(tt.userAgent, tt.purpose); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
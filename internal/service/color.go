@@ -0,0 +1,663 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Color formats
+const (
+	ColorFormatHex   = "hex"
+	ColorFormatRGB   = "rgb"
+	ColorFormatHSL   = "hsl"
+	ColorFormatHSV   = "hsv"
+	ColorFormatHWB   = "hwb"
+	ColorFormatCMYK  = "cmyk"
+	ColorFormatLab   = "lab"
+	ColorFormatLCH   = "lch"
+	ColorFormatOklab = "oklab"
+	ColorFormatOklch = "oklch"
+	ColorFormatName  = "name"
+)
+
+// colorFunctions maps CSS color function names to their formats
+var colorFunctions = map[string]string{
+	"rgb":         ColorFormatRGB,
+	"rgba":        ColorFormatRGB,
+	"hsl":         ColorFormatHSL,
+	"hsla":        ColorFormatHSL,
+	"hsv":         ColorFormatHSV,
+	"hsva":        ColorFormatHSV,
+	"hsb":         ColorFormatHSV,
+	"hwb":         ColorFormatHWB,
+	"cmyk":        ColorFormatCMYK,
+	"device-cmyk": ColorFormatCMYK,
+	"lab":         ColorFormatLab,
+	"lch":         ColorFormatLCH,
+	"oklab":       ColorFormatOklab,
+	"oklch":       ColorFormatOklch,
+}
+
+// rgbColor is an sRGB color with gamma-encoded channels and alpha from 0 to 1.
+// Channels of colors converted from Lab or OKLab can lie outside 0-1.
+type rgbColor struct {
+	R, G, B, A float64
+}
+
+// inGamut reports whether the color is within the sRGB gamut, allowing for rounding
+func (c rgbColor) inGamut() bool {
+	const tolerance = 0.5 / 255
+	for _, v := range []float64{c.R, c.G, c.B} {
+		if v < -tolerance || v > 1+tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// clipped clips the channels to the sRGB gamut
+func (c rgbColor) clipped() rgbColor {
+	return rgbColor{R: clamp01(c.R), G: clamp01(c.G), B: clamp01(c.B), A: clamp01(c.A)}
+}
+
+// bytes returns the clipped 8-bit channels
+func (c rgbColor) bytes() (uint8, uint8, uint8, uint8) {
+	c = c.clipped()
+	// #nosec G115 - channels are clipped to 0-255
+	return uint8(math.Round(c.R * 255)), uint8(math.Round(c.G * 255)), uint8(math.Round(c.B * 255)), uint8(math.Round(c.A * 255))
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// parseColor parses a color in the given format, or detects the format when
+// it is empty, and returns the color with its format
+func parseColor(value, format string) (rgbColor, string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return rgbColor{}, "", fmt.Errorf("empty color")
+	}
+
+	detected := detectColorFormat(value)
+	if detected == "" {
+		return rgbColor{}, "", fmt.Errorf("unrecognized color: %s", value)
+	}
+	if format != "" && format != detected {
+		return rgbColor{}, "", fmt.Errorf("color %s is not in %s format", value, format)
+	}
+
+	var c rgbColor
+	var err error
+	switch detected {
+	case ColorFormatHex:
+		c, err = parseHexColor(value)
+	case ColorFormatName:
+		c, err = parseHexColor(cssNamedColors[value])
+	default:
+		c, err = parseColorFunction(value, detected)
+	}
+	if err != nil {
+		return rgbColor{}, "", err
+	}
+
+	return c, detected, nil
+}
+
+// detectColorFormat returns the format of a lower-case color, or an empty string
+func detectColorFormat(value string) string {
+	if _, ok := cssNamedColors[value]; ok {
+		return ColorFormatName
+	}
+	if name, _, ok := strings.Cut(value, "("); ok {
+		return colorFunctions[strings.TrimSpace(name)]
+	}
+
+	digits := strings.TrimPrefix(value, "#")
+	switch len(digits) {
+	case 3, 4, 6, 8:
+		if strings.Trim(digits, "0123456789abcdef") == "" {
+			return ColorFormatHex
+		}
+	}
+	return ""
+}
+
+// parseHexColor parses #rgb, #rgba, #rrggbb or #rrggbbaa, with or without the #
+func parseHexColor(value string) (rgbColor, error) {
+	digits := strings.TrimPrefix(value, "#")
+	if len(digits) == 3 || len(digits) == 4 {
+		expanded := make([]byte, 0, len(digits)*2)
+		for i := range len(digits) {
+			expanded = append(expanded, digits[i], digits[i])
+		}
+		digits = string(expanded)
+	}
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+	if len(digits) != 8 {
+		return rgbColor{}, fmt.Errorf("invalid hex color format")
+	}
+
+	n, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return rgbColor{}, fmt.Errorf("invalid hex color: %w", err)
+	}
+	return rgbColor{
+		R: float64(n>>24&0xff) / 255,
+		G: float64(n>>16&0xff) / 255,
+		B: float64(n>>8&0xff) / 255,
+		A: float64(n&0xff) / 255,
+	}, nil
+}
+
+// parseColorFunction parses a CSS color function in comma-separated legacy or
+// space-separated modern syntax, with an optional alpha
+func parseColorFunction(value, format string) (rgbColor, error) {
+	name, inner, _ := strings.Cut(value, "(")
+	inner, ok := strings.CutSuffix(strings.TrimSpace(inner), ")")
+	if !ok {
+		return rgbColor{}, fmt.Errorf("invalid %s color: missing closing parenthesis", name)
+	}
+
+	want := 3
+	if format == ColorFormatCMYK {
+		want = 4
+	}
+
+	var args []string
+	alpha := ""
+	if strings.Contains(inner, ",") {
+		for _, arg := range strings.Split(inner, ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+		if len(args) == want+1 {
+			alpha, args = args[want], args[:want]
+		}
+	} else {
+		channels, alphaPart, hasAlpha := strings.Cut(inner, "/")
+		args = strings.Fields(channels)
+		if hasAlpha {
+			alpha = strings.TrimSpace(alphaPart)
+		}
+	}
+	if len(args) != want {
+		return rgbColor{}, fmt.Errorf("invalid %s color: expected %d components, got %d", name, want, len(args))
+	}
+
+	a := 1.0
+	if alpha != "" {
+		var err error
+		if a, err = parseColorComponent(alpha, 1, 0, 1); err != nil {
+			return rgbColor{}, fmt.Errorf("invalid %s alpha: %w", name, err)
+		}
+	}
+
+	c, err := colorFromComponents(format, args)
+	if err != nil {
+		return rgbColor{}, fmt.Errorf("invalid %s color: %w", name, err)
+	}
+	c.A = a
+	return c, nil
+}
+
+// colorFromComponents converts the components of a color function to sRGB
+func colorFromComponents(format string, args []string) (rgbColor, error) {
+	var v [4]float64
+	var err error
+	component := func(i int, full, lo, hi float64) {
+		if err == nil {
+			v[i], err = parseColorComponent(args[i], full, lo, hi)
+		}
+	}
+	hue := func(i int) {
+		if err == nil {
+			v[i], err = parseHue(args[i])
+		}
+	}
+
+	switch format {
+	case ColorFormatRGB:
+		component(0, 255, 0, 255)
+		component(1, 255, 0, 255)
+		component(2, 255, 0, 255)
+		return rgbColor{R: v[0] / 255, G: v[1] / 255, B: v[2] / 255}, err
+	case ColorFormatHSL, ColorFormatHSV, ColorFormatHWB:
+		hue(0)
+		component(1, 100, 0, 100)
+		component(2, 100, 0, 100)
+		switch format {
+		case ColorFormatHSL:
+			return hslToRGB(v[0], v[1]/100, v[2]/100), err
+		case ColorFormatHSV:
+			return hsvToRGB(v[0], v[1]/100, v[2]/100), err
+		default:
+			return hwbToRGB(v[0], v[1]/100, v[2]/100), err
+		}
+	case ColorFormatCMYK:
+		for i := range 4 {
+			component(i, 1, 0, 1)
+		}
+		return cmykToRGB(v[0], v[1], v[2], v[3]), err
+	case ColorFormatLab:
+		component(0, 100, 0, 100)
+		component(1, 125, math.Inf(-1), math.Inf(1))
+		component(2, 125, math.Inf(-1), math.Inf(1))
+		return labToRGB(v[0], v[1], v[2]), err
+	case ColorFormatLCH:
+		component(0, 100, 0, 100)
+		component(1, 150, 0, math.Inf(1))
+		hue(2)
+		a, b := polarToCartesian(v[1], v[2])
+		return labToRGB(v[0], a, b), err
+	case ColorFormatOklab:
+		component(0, 1, 0, 1)
+		component(1, 0.4, math.Inf(-1), math.Inf(1))
+		component(2, 0.4, math.Inf(-1), math.Inf(1))
+		return oklabToRGB(v[0], v[1], v[2]), err
+	case ColorFormatOklch:
+		component(0, 1, 0, 1)
+		component(1, 0.4, 0, math.Inf(1))
+		hue(2)
+		a, b := polarToCartesian(v[1], v[2])
+		return oklabToRGB(v[0], a, b), err
+	default:
+		return rgbColor{}, fmt.Errorf("unsupported color format: %s", format)
+	}
+}
+
+// parseColorComponent parses a number or a percentage of full, which must lie
+// between lo and hi. "none" is zero as in CSS.
+func parseColorComponent(s string, full, lo, hi float64) (float64, error) {
+	if s == "none" {
+		return 0, nil
+	}
+
+	number, percent := strings.CutSuffix(s, "%")
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if percent {
+		v = v / 100 * full
+	}
+	if v < lo || v > hi {
+		return 0, fmt.Errorf("%s is out of range", s)
+	}
+	return v, nil
+}
+
+// parseHue parses a hue angle in degrees, or with a deg, rad, grad or turn unit
+func parseHue(s string) (float64, error) {
+	if s == "none" {
+		return 0, nil
+	}
+
+	scale := 1.0
+	for _, unit := range []struct {
+		suffix string
+		scale  float64
+	}{{"deg", 1}, {"grad", 0.9}, {"rad", 180 / math.Pi}, {"turn", 360}} {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, scale = number, unit.scale
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid hue %q", s)
+	}
+	return normalizeHue(v * scale), nil
+}
+
+// normalizeHue wraps a hue angle into [0, 360)
+func normalizeHue(h float64) float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func polarToCartesian(chroma, hue float64) (float64, float64) {
+	rad := hue * math.Pi / 180
+	return chroma * math.Cos(rad), chroma * math.Sin(rad)
+}
+
+func cartesianToPolar(a, b float64) (float64, float64) {
+	chroma := math.Hypot(a, b)
+	// The hue of achromatic colors is meaningless and reported as zero
+	if chroma < 1e-4 {
+		return chroma, 0
+	}
+	return chroma, normalizeHue(math.Atan2(b, a) * 180 / math.Pi)
+}
+
+func hslToRGB(h, s, l float64) rgbColor {
+	f := func(n float64) float64 {
+		k := math.Mod(n+h/30, 12)
+		a := s * math.Min(l, 1-l)
+		return l - a*math.Max(-1, math.Min(math.Min(k-3, 9-k), 1))
+	}
+	return rgbColor{R: f(0), G: f(8), B: f(4)}
+}
+
+func hsvToRGB(h, s, v float64) rgbColor {
+	f := func(n float64) float64 {
+		k := math.Mod(n+h/60, 6)
+		return v - v*s*math.Max(0, math.Min(math.Min(k, 4-k), 1))
+	}
+	return rgbColor{R: f(5), G: f(3), B: f(1)}
+}
+
+func hwbToRGB(h, w, b float64) rgbColor {
+	if w+b >= 1 {
+		gray := w / (w + b)
+		return rgbColor{R: gray, G: gray, B: gray}
+	}
+	c := hslToRGB(h, 1, 0.5)
+	scale := 1 - w - b
+	return rgbColor{R: c.R*scale + w, G: c.G*scale + w, B: c.B*scale + w}
+}
+
+func cmykToRGB(c, m, y, k float64) rgbColor {
+	return rgbColor{R: (1 - c) * (1 - k), G: (1 - m) * (1 - k), B: (1 - y) * (1 - k)}
+}
+
+// hue returns the hue of a clipped color with its maximum and minimum channels
+func (c rgbColor) hue() (float64, float64, float64) {
+	maxC := math.Max(c.R, math.Max(c.G, c.B))
+	minC := math.Min(c.R, math.Min(c.G, c.B))
+	d := maxC - minC
+
+	var h float64
+	switch {
+	case d == 0:
+		h = 0
+	case maxC == c.R:
+		h = math.Mod((c.G-c.B)/d, 6)
+	case maxC == c.G:
+		h = (c.B-c.R)/d + 2
+	default:
+		h = (c.R-c.G)/d + 4
+	}
+	return normalizeHue(h * 60), maxC, minC
+}
+
+func (c rgbColor) hsl() (float64, float64, float64) {
+	h, maxC, minC := c.hue()
+	l := (maxC + minC) / 2
+	s := 0.0
+	if d := maxC - minC; d > 0 {
+		s = d / (1 - math.Abs(2*l-1))
+	}
+	return h, s, l
+}
+
+func (c rgbColor) hsv() (float64, float64, float64) {
+	h, maxC, minC := c.hue()
+	s := 0.0
+	if maxC > 0 {
+		s = (maxC - minC) / maxC
+	}
+	return h, s, maxC
+}
+
+func (c rgbColor) hwb() (float64, float64, float64) {
+	h, maxC, minC := c.hue()
+	return h, minC, 1 - maxC
+}
+
+func (c rgbColor) cmyk() (float64, float64, float64, float64) {
+	k := 1 - math.Max(c.R, math.Max(c.G, c.B))
+	if k >= 1 {
+		return 0, 0, 0, 1
+	}
+	return (1 - c.R - k) / (1 - k), (1 - c.G - k) / (1 - k), (1 - c.B - k) / (1 - k), k
+}
+
+// srgbToLinear removes the sRGB transfer function, extended to negative values
+func srgbToLinear(v float64) float64 {
+	abs := math.Abs(v)
+	if abs <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Copysign(math.Pow((abs+0.055)/1.055, 2.4), v)
+}
+
+// linearToSRGB applies the sRGB transfer function, extended to negative values
+func linearToSRGB(v float64) float64 {
+	abs := math.Abs(v)
+	if abs <= 0.0031308 {
+		return v * 12.92
+	}
+	return math.Copysign(1.055*math.Pow(abs, 1/2.4)-0.055, v)
+}
+
+// linear returns the linear-light sRGB channels
+func (c rgbColor) linear() [3]float64 {
+	return [3]float64{srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B)}
+}
+
+func rgbFromLinear(v [3]float64) rgbColor {
+	return rgbColor{R: linearToSRGB(v[0]), G: linearToSRGB(v[1]), B: linearToSRGB(v[2])}
+}
+
+func mulMatrix(m [3][3]float64, v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+// Conversion matrices from CSS Color Module Level 4. CIELAB uses the D50
+// white point, so XYZ is chromatically adapted with the Bradford transform.
+var (
+	linearSRGBToXYZD65 = [3][3]float64{
+		{0.41239079926595934, 0.357584339383878, 0.1804807884018343},
+		{0.21263900587151027, 0.715168678767756, 0.07219231536073371},
+		{0.01933081871559182, 0.11919477979462598, 0.9505321522496607},
+	}
+	xyzD65ToLinearSRGB = [3][3]float64{
+		{3.2409699419045226, -1.537383177570094, -0.4986107602930034},
+		{-0.9692436362808796, 1.8759675015077202, 0.04155505740717559},
+		{0.05563007969699366, -0.20397695888897652, 1.0569715142428786},
+	}
+	xyzD65ToD50 = [3][3]float64{
+		{1.0479297925449969, 0.022946870601609652, -0.05019226628920524},
+		{0.02962780877005599, 0.9904344267538799, -0.017073799063418826},
+		{-0.009243040646204504, 0.015055191490298152, 0.7518742814281371},
+	}
+	xyzD50ToD65 = [3][3]float64{
+		{0.955473421488075, -0.02309845494876471, 0.06325924320057072},
+		{-0.0283697093338637, 1.0099953980813041, 0.021041441191917323},
+		{0.012314014864481998, -0.020507649298898964, 1.330365926242124},
+	}
+	whiteD50 = [3]float64{0.3457 / 0.3585, 1, (1 - 0.3457 - 0.3585) / 0.3585}
+)
+
+// CIELAB constants
+const (
+	labEpsilon = 216.0 / 24389
+	labKappa   = 24389.0 / 27
+)
+
+func (c rgbColor) lab() (float64, float64, float64) {
+	xyz := mulMatrix(xyzD65ToD50, mulMatrix(linearSRGBToXYZD65, c.linear()))
+
+	var f [3]float64
+	for i, v := range xyz {
+		v /= whiteD50[i]
+		if v > labEpsilon {
+			f[i] = math.Cbrt(v)
+		} else {
+			f[i] = (labKappa*v + 16) / 116
+		}
+	}
+	return 116*f[1] - 16, 500 * (f[0] - f[1]), 200 * (f[1] - f[2])
+}
+
+func labToRGB(l, a, b float64) rgbColor {
+	fy := (l + 16) / 116
+	fx := a/500 + fy
+	fz := fy - b/200
+
+	inverse := func(f float64) float64 {
+		if f3 := f * f * f; f3 > labEpsilon {
+			return f3
+		}
+		return (116*f - 16) / labKappa
+	}
+	y := l / labKappa
+	if l > labKappa*labEpsilon {
+		y = fy * fy * fy
+	}
+
+	xyz := [3]float64{inverse(fx) * whiteD50[0], y * whiteD50[1], inverse(fz) * whiteD50[2]}
+	return rgbFromLinear(mulMatrix(xyzD65ToLinearSRGB, mulMatrix(xyzD50ToD65, xyz)))
+}
+
+// oklab converts to OKLab with the matrices of its reference implementation
+func (c rgbColor) oklab() (float64, float64, float64) {
+	v := c.linear()
+	l := math.Cbrt(0.4122214708*v[0] + 0.5363325363*v[1] + 0.0514459929*v[2])
+	m := math.Cbrt(0.2119034982*v[0] + 0.6806995451*v[1] + 0.1073969566*v[2])
+	s := math.Cbrt(0.0883024619*v[0] + 0.2817188376*v[1] + 0.6299787005*v[2])
+
+	return 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s
+}
+
+func oklabToRGB(lightness, a, b float64) rgbColor {
+	l := lightness + 0.3963377774*a + 0.2158037573*b
+	m := lightness - 0.1055613458*a - 0.0638541728*b
+	s := lightness - 0.0894841775*a - 1.2914855480*b
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	return rgbFromLinear([3]float64{
+		4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s,
+	})
+}
+
+// colorNamesByHex maps opaque hex values to the first CSS name in alphabetical order
+var colorNamesByHex = sync.OnceValue(func() map[string]string {
+	names := make([]string, 0, len(cssNamedColors))
+	for name := range cssNamedColors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	byHex := make(map[string]string, len(names))
+	for _, name := range names {
+		if _, ok := byHex[cssNamedColors[name]]; !ok {
+			byHex[cssNamedColors[name]] = name
+		}
+	}
+	return byHex
+})
+
+// formatNumber formats v with at most decimals fractional digits
+func formatNumber(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// formatHue formats a hue angle, wrapping angles that round to 360 to zero
+func formatHue(h float64, decimals int) string {
+	s := formatNumber(h, decimals)
+	if s == "360" {
+		return "0"
+	}
+	return s
+}
+
+func formatPercent(v float64) string {
+	return formatNumber(v*100, 1) + "%"
+}
+
+// formatColor writes a color in a format. Formats limited to sRGB are written
+// from the clipped color.
+func formatColor(c rgbColor, format string) (string, error) {
+	clipped := c.clipped()
+	opaque := clipped.A >= 1
+	alpha := formatNumber(clipped.A, 3)
+
+	// modern appends the alpha of translucent colors in space-separated syntax
+	modern := func(name string, parts ...string) string {
+		s := name + "(" + strings.Join(parts, " ")
+		if !opaque {
+			s += " / " + alpha
+		}
+		return s + ")"
+	}
+	// legacy writes comma-separated syntax, switching to the alpha function name
+	legacy := func(name string, parts ...string) string {
+		if !opaque {
+			return name + "a(" + strings.Join(append(parts, alpha), ", ") + ")"
+		}
+		return name + "(" + strings.Join(parts, ", ") + ")"
+	}
+
+	switch format {
+	case ColorFormatHex:
+		r, g, b, a := c.bytes()
+		if opaque {
+			return fmt.Sprintf("#%02x%02x%02x", r, g, b), nil
+		}
+		return fmt.Sprintf("#%02x%02x%02x%02x", r, g, b, a), nil
+	case ColorFormatRGB:
+		r, g, b, _ := c.bytes()
+		return legacy("rgb", strconv.Itoa(int(r)), strconv.Itoa(int(g)), strconv.Itoa(int(b))), nil
+	case ColorFormatHSL:
+		h, s, l := clipped.hsl()
+		return legacy("hsl", formatHue(h, 1), formatPercent(s), formatPercent(l)), nil
+	case ColorFormatHSV:
+		h, s, v := clipped.hsv()
+		return legacy("hsv", formatHue(h, 1), formatPercent(s), formatPercent(v)), nil
+	case ColorFormatHWB:
+		h, w, b := clipped.hwb()
+		return modern("hwb", formatHue(h, 1), formatPercent(w), formatPercent(b)), nil
+	case ColorFormatCMYK:
+		cy, m, y, k := clipped.cmyk()
+		return modern("device-cmyk", formatPercent(cy), formatPercent(m), formatPercent(y), formatPercent(k)), nil
+	case ColorFormatLab:
+		l, a, b := c.lab()
+		return modern("lab", formatNumber(l, 2), formatNumber(a, 2), formatNumber(b, 2)), nil
+	case ColorFormatLCH:
+		l, a, b := c.lab()
+		chroma, hue := cartesianToPolar(a, b)
+		return modern("lch", formatNumber(l, 2), formatNumber(chroma, 2), formatHue(hue, 2)), nil
+	case ColorFormatOklab:
+		l, a, b := c.oklab()
+		return modern("oklab", formatNumber(l, 4), formatNumber(a, 4), formatNumber(b, 4)), nil
+	case ColorFormatOklch:
+		l, a, b := c.oklab()
+		chroma, hue := cartesianToPolar(a, b)
+		return modern("oklch", formatNumber(l, 4), formatNumber(chroma, 4), formatHue(hue, 2)), nil
+	case ColorFormatName:
+		hex, _ := formatColor(c, ColorFormatHex)
+		name, ok := colorNamesByHex()[hex]
+		if !ok {
+			return "", fmt.Errorf("no CSS named color matches %s", hex)
+		}
+		return name, nil
+	default:
+		return "", fmt.Errorf("unsupported color format: %s", format)
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+// Palette schemes
+const (
+	PaletteComplementary      = "complementary"
+	PaletteSplitComplementary = "split-complementary"
+	PaletteTriadic            = "triadic"
+	PaletteTetradic           = "tetradic"
+	PaletteAnalogous          = "analogous"
+	PaletteTints              = "tints"
+	PaletteShades             = "shades"
+	PaletteScale              = "scale"
+)
+
+// paletteHueOffsets are the hue rotations of the color harmony schemes
+var paletteHueOffsets = map[string][]float64{
+	PaletteComplementary:      {0, 180},
+	PaletteSplitComplementary: {0, 150, 210},
+	PaletteTriadic:            {0, 120, 240},
+	PaletteTetradic:           {0, 90, 180, 270},
+}
+
+// Palette defaults
+const (
+	defaultPaletteCount = 5
+	analogousHueStep    = 30
+)
+
+// defaultAnomalySeverity is the simulated severity of anomalous trichromacy
+const defaultAnomalySeverity = 0.6
+
+// colorBlindnessMatrices simulate dichromacy in linear RGB (Machado, Oliveira
+// and Fernandes, 2009, severity 1.0)
+var colorBlindnessMatrices = map[string][3][3]float64{
+	"protan": {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	"deutan": {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	"tritan": {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// APCA constants of version 0.0.98G-4g
+const (
+	apcaBlackThreshold = 0.022
+	apcaBlackClamp     = 1.414
+	apcaDeltaYMin      = 0.0005
+	apcaScale          = 1.14
+	apcaOffset         = 0.027
+	apcaLowClip        = 0.1
+)
+
+// ColorContrast computes the WCAG 2.x contrast ratio and APCA lightness
+// contrast of foreground text on a background. Translucent backgrounds are
+// composited over white and translucent text over the background.
+func (s *ConverterService) ColorContrast(req *domain.ColorContrastRequest) (*domain.ColorContrastResponse, error) {
+	fg, _, err := parseColor(req.Foreground, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid foreground color: %w", err)
+	}
+	bg, _, err := parseColor(req.Background, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid background color: %w", err)
+	}
+
+	bg = composite(bg.clipped(), rgbColor{R: 1, G: 1, B: 1, A: 1})
+	fg = composite(fg.clipped(), bg)
+
+	ratio := contrastRatio(fg, bg)
+	fgHex, _ := formatColor(fg, ColorFormatHex)
+	bgHex, _ := formatColor(bg, ColorFormatHex)
+
+	return &domain.ColorContrastResponse{
+		Foreground: fgHex,
+		Background: bgHex,
+		Ratio:      math.Round(ratio*100) / 100,
+		WCAG: domain.WCAGResults{
+			AANormal:  ratio >= 4.5,
+			AALarge:   ratio >= 3,
+			AAANormal: ratio >= 7,
+			AAALarge:  ratio >= 4.5,
+		},
+		APCA: math.Round(apcaContrast(fg, bg)*100) / 100,
+	}, nil
+}
+
+// composite blends a translucent color over an opaque backdrop
+func composite(c, backdrop rgbColor) rgbColor {
+	return rgbColor{
+		R: c.R*c.A + backdrop.R*(1-c.A),
+		G: c.G*c.A + backdrop.G*(1-c.A),
+		B: c.B*c.A + backdrop.B*(1-c.A),
+		A: 1,
+	}
+}
+
+// relativeLuminance is the WCAG relative luminance of an sRGB color
+func relativeLuminance(c rgbColor) float64 {
+	v := c.linear()
+	return 0.2126*v[0] + 0.7152*v[1] + 0.0722*v[2]
+}
+
+// contrastRatio is the WCAG 2.x contrast ratio of two colors
+func contrastRatio(a, b rgbColor) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// apcaContrast is the APCA lightness contrast (Lc) of text on a background
+func apcaContrast(text, background rgbColor) float64 {
+	luminance := func(c rgbColor) float64 {
+		y := 0.2126729*math.Pow(c.R, 2.4) + 0.7151522*math.Pow(c.G, 2.4) + 0.0721750*math.Pow(c.B, 2.4)
+		// Soft clamp near black
+		if y < apcaBlackThreshold {
+			y += math.Pow(apcaBlackThreshold-y, apcaBlackClamp)
+		}
+		return y
+	}
+
+	yText, yBackground := luminance(text), luminance(background)
+	if math.Abs(yBackground-yText) < apcaDeltaYMin {
+		return 0
+	}
+
+	if yBackground > yText {
+		// Dark text on a light background
+		sapc := (math.Pow(yBackground, 0.56) - math.Pow(yText, 0.57)) * apcaScale
+		if sapc < apcaLowClip {
+			return 0
+		}
+		return (sapc - apcaOffset) * 100
+	}
+
+	// Light text on a dark background
+	sapc := (math.Pow(yBackground, 0.65) - math.Pow(yText, 0.62)) * apcaScale
+	if sapc > -apcaLowClip {
+		return 0
+	}
+	return (sapc + apcaOffset) * 100
+}
+
+// ColorPalette generates a color harmony by rotating the HSL hue, or a scale
+// of tints and shades by mixing with white and black
+func (s *ConverterService) ColorPalette(req *domain.ColorPaletteRequest) (*domain.ColorPaletteResponse, error) {
+	base, _, err := parseColor(req.Color, "")
+	if err != nil {
+		return nil, err
+	}
+	base = base.clipped()
+
+	count := defaultPaletteCount
+	if req.Count != nil {
+		count = *req.Count
+	}
+	format := ColorFormatHex
+	if req.Format != nil {
+		format = *req.Format
+	}
+
+	var colors []rgbColor
+	white := rgbColor{R: 1, G: 1, B: 1, A: base.A}
+	black := rgbColor{A: base.A}
+
+	switch req.Scheme {
+	case PaletteComplementary, PaletteSplitComplementary, PaletteTriadic, PaletteTetradic:
+		for _, offset := range paletteHueOffsets[req.Scheme] {
+			colors = append(colors, rotateHue(base, offset))
+		}
+	case PaletteAnalogous:
+		// Centered on the base color
+		for i := range count {
+			colors = append(colors, rotateHue(base, (float64(i)-float64(count-1)/2)*analogousHueStep))
+		}
+	case PaletteTints, PaletteShades:
+		target := white
+		if req.Scheme == PaletteShades {
+			target = black
+		}
+		for i := range count {
+			colors = append(colors, mixColors(base, target, float64(i)/float64(count)))
+		}
+	case PaletteScale:
+		// From light to dark through the base color, excluding white and black
+		for i := range count {
+			t := -1 + 2*float64(i+1)/float64(count+1)
+			if t < 0 {
+				colors = append(colors, mixColors(base, white, -t))
+			} else {
+				colors = append(colors, mixColors(base, black, t))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported palette scheme: %s", req.Scheme)
+	}
+
+	response := &domain.ColorPaletteResponse{
+		Scheme: req.Scheme,
+		Colors: make([]string, len(colors)),
+	}
+	for i, c := range colors {
+		if response.Colors[i], err = formatColor(c, format); err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// rotateHue rotates the HSL hue of a color
+func rotateHue(c rgbColor, degrees float64) rgbColor {
+	h, s, l := c.hsl()
+	rotated := hslToRGB(normalizeHue(h+degrees), s, l)
+	rotated.A = c.A
+	return rotated
+}
+
+// mixColors mixes weight of b into a in sRGB, as tint and shade functions do
+func mixColors(a, b rgbColor, weight float64) rgbColor {
+	return rgbColor{
+		R: a.R + (b.R-a.R)*weight,
+		G: a.G + (b.G-a.G)*weight,
+		B: a.B + (b.B-a.B)*weight,
+		A: a.A + (b.A-a.A)*weight,
+	}
+}
+
+// SimulateColorBlindness shows how a color appears with each type of color
+// vision deficiency. Anomalous trichromacy interpolates between normal vision
+// and the matching dichromacy by severity.
+func (s *ConverterService) SimulateColorBlindness(req *domain.SimulateColorBlindnessRequest) (*domain.SimulateColorBlindnessResponse, error) {
+	c, _, err := parseColor(req.Color, "")
+	if err != nil {
+		return nil, err
+	}
+	c = c.clipped()
+
+	severity := defaultAnomalySeverity
+	if req.Severity != nil {
+		severity = *req.Severity
+	}
+
+	hex := func(c rgbColor) string {
+		s, _ := formatColor(c, ColorFormatHex)
+		return s
+	}
+	y := linearToSRGB(relativeLuminance(c))
+
+	return &domain.SimulateColorBlindnessResponse{
+		Original:      hex(c),
+		Protanopia:    hex(simulateDeficiency(c, "protan", 1)),
+		Protanomaly:   hex(simulateDeficiency(c, "protan", severity)),
+		Deuteranopia:  hex(simulateDeficiency(c, "deutan", 1)),
+		Deuteranomaly: hex(simulateDeficiency(c, "deutan", severity)),
+		Tritanopia:    hex(simulateDeficiency(c, "tritan", 1)),
+		Tritanomaly:   hex(simulateDeficiency(c, "tritan", severity)),
+		Achromatopsia: hex(rgbColor{R: y, G: y, B: y, A: c.A}),
+	}, nil
+}
+
+// simulateDeficiency applies a color blindness matrix at the given severity
+func simulateDeficiency(c rgbColor, deficiency string, severity float64) rgbColor {
+	matrix := colorBlindnessMatrices[deficiency]
+	for i := range 3 {
+		for j := range 3 {
+			identity := 0.0
+			if i == j {
+				identity = 1
+			}
+			matrix[i][j] = identity + (matrix[i][j]-identity)*severity
+		}
+	}
+
+	simulated := rgbFromLinear(mulMatrix(matrix, c.linear())).clipped()
+	simulated.A = c.A
+	return simulated
+}
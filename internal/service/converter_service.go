@@ -1,14 +1,13 @@
 package service
 
 import (
-	"encoding/json"
 	"fmt"
-	"strconv"
+	"image/color"
+	"math/big"
 	"strings"
 	"time"
 
 	"github.com/codewithwan/gopilot/internal/domain"
-	"gopkg.in/yaml.v3"
 )
 
 // ConverterService handles data conversion operations
@@ -19,239 +18,223 @@ func NewConverterService() *ConverterService {
 	return &ConverterService{}
 }
 
-// ConvertBase converts numbers between different bases
+// ConvertBase converts numbers of any size between bases 2 to 64, including
+// fractional parts and two's complement values
 func (s *ConverterService) ConvertBase(req *domain.ConvertBaseRequest) (*domain.ConvertBaseResponse, error) {
 	fromBase := 10
 	if req.FromBase != nil {
 		fromBase = *req.FromBase
 	}
 
+	from, err := newDigitAlphabet(req.FromAlphabet, fromBase)
+	if err != nil {
+		return nil, err
+	}
+	to, err := newDigitAlphabet(req.ToAlphabet, req.ToBase)
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse the input value
-	num, err := strconv.ParseInt(req.Value, fromBase, 64)
+	value, err := from.parse(req.Value)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse value: %w", err)
 	}
 
-	// Convert to target base
-	var result string
-	switch req.ToBase {
-	case 2:
-		result = strconv.FormatInt(num, 2)
-	case 8:
-		result = strconv.FormatInt(num, 8)
-	case 10:
-		result = strconv.FormatInt(num, 10)
-	case 16:
-		result = strconv.FormatInt(num, 16)
-	default:
-		result = strconv.FormatInt(num, req.ToBase)
-	}
-
-	return &domain.ConvertBaseResponse{
+	signedInput := req.SignedInput != nil && *req.SignedInput
+	if signedInput && req.BitWidth == nil {
+		return nil, fmt.Errorf("signed_input requires bit_width")
+	}
+	if signedInput {
+		if value, err = fromTwosComplement(value, *req.BitWidth); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &domain.ConvertBaseResponse{
 		Original: req.Value,
-		Result:   result,
 		FromBase: fromBase,
 		ToBase:   req.ToBase,
-	}, nil
-}
+	}
 
-// ConvertColor converts colors between different formats
-func (s *ConverterService) ConvertColor(req *domain.ConvertColorRequest) (*domain.ConvertColorResponse, error) {
-	value := strings.TrimSpace(req.Value)
-
-	// Simple color conversion (basic implementation)
-	var result string
-
-	switch {
-	case strings.HasPrefix(value, "#"):
-		// HEX to RGB or HSL
-		if req.To == "rgb" {
-			r, g, b, err := s.hexToRGB(value)
-			if err != nil {
-				return nil, err
-			}
-			result = fmt.Sprintf("rgb(%d, %d, %d)", r, g, b)
-		} else {
-			return nil, fmt.Errorf("conversion from hex to %s not yet supported", req.To)
-		}
-	case strings.HasPrefix(value, "rgb"):
-		// RGB to HEX
-		if req.To == "hex" {
-			hex, err := s.rgbToHex(value)
-			if err != nil {
-				return nil, err
-			}
-			result = hex
-		} else {
-			return nil, fmt.Errorf("conversion from rgb to %s not yet supported", req.To)
+	// Convert to target base
+	if req.BitWidth != nil && !signedInput {
+		n, err := toTwosComplement(value, *req.BitWidth)
+		if err != nil {
+			return nil, err
 		}
-	default:
-		return nil, fmt.Errorf("unsupported color format")
+		// Pad to the digits of the largest value of the width
+		maxValue := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(*req.BitWidth)), big.NewInt(1)) // #nosec G115 - bit width is validated by binding
+		response.Result = to.formatInt(n, len(to.formatInt(maxValue, 1)))
+		return response, nil
 	}
 
-	return &domain.ConvertColorResponse{
-		Original: req.Value,
-		Result:   result,
-		Format:   req.To,
-	}, nil
+	precision := defaultBasePrecision
+	if req.Precision != nil {
+		precision = *req.Precision
+	}
+	response.Result, response.Truncated = to.format(value, precision)
+
+	return response, nil
 }
 
-// hexToRGB converts hex color to RGB
-func (s *ConverterService) hexToRGB(hex string) (int, int, int, error) {
-	hex = strings.TrimPrefix(hex, "#")
-	if len(hex) != 6 {
-		return 0, 0, 0, fmt.Errorf("invalid hex color format")
+// ConvertColor converts a color in any supported format, detected unless
+// given, to the requested format and returns every other format with it
+func (s *ConverterService) ConvertColor(req *domain.ConvertColorRequest) (*domain.ConvertColorResponse, error) {
+	from := ""
+	if req.From != nil {
+		from = *req.From
+	}
+	to := ColorFormatHex
+	if req.To != nil {
+		to = *req.To
 	}
 
-	r, err := strconv.ParseInt(hex[0:2], 16, 64)
+	c, detected, err := parseColor(req.Value, from)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("invalid hex color: %w", err)
+		return nil, err
 	}
-	g, err := strconv.ParseInt(hex[2:4], 16, 64)
+
+	result, err := formatColor(c, to)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("invalid hex color: %w", err)
+		return nil, err
 	}
-	b, err := strconv.ParseInt(hex[4:6], 16, 64)
-	if err != nil {
-		return 0, 0, 0, fmt.Errorf("invalid hex color: %w", err)
+
+	return &domain.ConvertColorResponse{
+		Original: req.Value,
+		Result:   result,
+		Format:   to,
+		Detected: detected,
+		InGamut:  c.inGamut(),
+		Formats:  colorFormats(c),
+	}, nil
+}
+
+// colorFormats writes a color in every format
+func colorFormats(c rgbColor) domain.ColorFormats {
+	format := func(f string) string {
+		s, _ := formatColor(c, f)
+		return s
+	}
+	return domain.ColorFormats{
+		Hex:   format(ColorFormatHex),
+		RGB:   format(ColorFormatRGB),
+		HSL:   format(ColorFormatHSL),
+		HSV:   format(ColorFormatHSV),
+		HWB:   format(ColorFormatHWB),
+		CMYK:  format(ColorFormatCMYK),
+		Lab:   format(ColorFormatLab),
+		LCH:   format(ColorFormatLCH),
+		Oklab: format(ColorFormatOklab),
+		Oklch: format(ColorFormatOklch),
+		Name:  format(ColorFormatName),
 	}
+}
 
-	// Security: Validate range before conversion
-	if r < 0 || r > 255 || g < 0 || g > 255 || b < 0 || b > 255 {
-		return 0, 0, 0, fmt.Errorf("color values out of range")
+// ParseColor parses a color in any supported format. Alpha is ignored.
+func (s *ConverterService) ParseColor(value string) (color.RGBA, error) {
+	c, _, err := parseColor(value, "")
+	if err != nil {
+		return color.RGBA{}, err
 	}
 
-	return int(r), int(g), int(b), nil
+	r, g, b, _ := c.bytes()
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}, nil
 }
 
-// rgbToHex converts RGB color to hex
-func (s *ConverterService) rgbToHex(rgb string) (string, error) {
-	// Parse rgb(r, g, b) format
-	rgb = strings.TrimPrefix(rgb, "rgb(")
-	rgb = strings.TrimSuffix(rgb, ")")
-	parts := strings.Split(rgb, ",")
-
-	if len(parts) != 3 {
-		return "", fmt.Errorf("invalid RGB format")
+// ConvertTime converts a time between epoch, textual and custom formats and
+// time zones. Inputs without an offset are read in from_tz, default UTC, and
+// the result is written in to_tz, default the zone of the input.
+func (s *ConverterService) ConvertTime(req *domain.ConvertTimeRequest) (*domain.ConvertTimeResponse, error) {
+	fromLoc, err := loadTimeZone(req.FromTZ)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from_tz: %w", err)
 	}
 
-	r, err := strconv.Atoi(strings.TrimSpace(parts[0]))
-	if err != nil {
-		return "", fmt.Errorf("invalid red component in RGB: %v", err)
+	var from, fromLayout, toLayout string
+	if req.From != nil {
+		from = *req.From
 	}
-	g, err := strconv.Atoi(strings.TrimSpace(parts[1]))
-	if err != nil {
-		return "", fmt.Errorf("invalid green component in RGB: %v", err)
+	if req.FromLayout != nil {
+		fromLayout = *req.FromLayout
 	}
-	b, err := strconv.Atoi(strings.TrimSpace(parts[2]))
-	if err != nil {
-		return "", fmt.Errorf("invalid blue component in RGB: %v", err)
+	if req.ToLayout != nil {
+		toLayout = *req.ToLayout
 	}
 
-	return fmt.Sprintf("#%02x%02x%02x", r, g, b), nil
-}
+	t, detected, err := parseTimeValue(req.Value, from, fromLayout, fromLoc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse time: %w", err)
+	}
 
-// ConvertTime converts time between different formats
-func (s *ConverterService) ConvertTime(req *domain.ConvertTimeRequest) (*domain.ConvertTimeResponse, error) {
-	var t time.Time
-	var err error
-
-	// Parse input
-	switch req.From {
-	case "unix":
-		var unixTime int64
-		unixTime, err = strconv.ParseInt(req.Value, 10, 64)
+	if req.ToTZ != nil {
+		toLoc, err := loadTimeZone(req.ToTZ)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse unix timestamp: %w", err)
+			return nil, fmt.Errorf("invalid to_tz: %w", err)
 		}
-		t = time.Unix(unixTime, 0)
-	case "iso8601":
-		t, err = time.Parse(time.RFC3339, req.Value)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse ISO8601: %w", err)
-		}
-	default:
-		return nil, fmt.Errorf("unsupported from format: %s", req.From)
+		t = t.In(toLoc)
 	}
 
-	// Convert to output
-	var result string
-	switch req.To {
-	case "unix":
-		result = strconv.FormatInt(t.Unix(), 10)
-	case "iso8601":
-		result = t.Format(time.RFC3339)
-	case "human":
-		result = t.Format("Mon, 02 Jan 2006 15:04:05 MST")
-	default:
-		return nil, fmt.Errorf("unsupported to format: %s", req.To)
+	result, err := formatTimeValue(t, req.To, toLayout, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format time: %w", err)
 	}
 
 	return &domain.ConvertTimeResponse{
 		Original: req.Value,
 		Result:   result,
+		Detected: detected,
+		Timezone: timeZoneName(t),
 	}, nil
 }
 
-// FormatJSON formats or minifies JSON
+// FormatJSON formats or minifies JSON token by token, keeping key order,
+// number literals and the comments of JSONC and JSON5 input
 func (s *ConverterService) FormatJSON(req *domain.FormatJSONRequest) (*domain.FormatJSONResponse, error) {
-	var data interface{}
-	if err := json.Unmarshal([]byte(req.JSON), &data); err != nil {
-		return nil, fmt.Errorf("invalid JSON: %w", err)
+	syntax := DataFormatJSON
+	if req.Syntax != nil {
+		syntax = *req.Syntax
 	}
 
-	minify := false
-	if req.Minify != nil {
-		minify = *req.Minify
+	root, footer, err := parseJSONDocument(req.JSON, syntax)
+	if err != nil {
+		return nil, err
 	}
-
-	var result []byte
-	var err error
-
-	if minify {
-		result, err = json.Marshal(data)
-	} else {
-		indent := 2
-		if req.Indent != nil {
-			indent = *req.Indent
-		}
-		result, err = json.MarshalIndent(data, "", strings.Repeat(" ", indent))
+	if req.SortKeys != nil && *req.SortKeys {
+		sortJSONMembers(root.value)
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to format JSON: %w", err)
+	w := &jsonWriter{indent: strings.Repeat(" ", 2), comments: true}
+	if req.Minify != nil {
+		w.compact = *req.Minify
+	}
+	if req.Indent != nil {
+		w.indent = strings.Repeat(" ", *req.Indent)
+	}
+	if req.UseTabs != nil && *req.UseTabs {
+		w.indent = "\t"
+	}
+	if req.StripComments != nil {
+		w.comments = !*req.StripComments
 	}
 
 	return &domain.FormatJSONResponse{
-		Result: string(result),
+		Result: w.writeDocument(root, footer),
 	}, nil
 }
 
-// ConvertYAML converts between JSON and YAML
+// ConvertYAML converts structured data between JSON, YAML, TOML, XML,
+// CSV, TSV, INI, .env and HCL
 func (s *ConverterService) ConvertYAML(req *domain.ConvertYAMLRequest) (*domain.ConvertYAMLResponse, error) {
-	var data interface{}
+	var from string
+	if req.From != nil {
+		from = *req.From
+	}
 
-	if req.To == "json" {
-		// YAML to JSON
-		if err := yaml.Unmarshal([]byte(req.Content), &data); err != nil {
-			return nil, fmt.Errorf("invalid YAML: %w", err)
-		}
-		result, err := json.MarshalIndent(data, "", "  ")
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert to JSON: %w", err)
-		}
-		return &domain.ConvertYAMLResponse{Result: string(result)}, nil
-	} else if req.To == "yaml" {
-		// JSON to YAML
-		if err := json.Unmarshal([]byte(req.Content), &data); err != nil {
-			return nil, fmt.Errorf("invalid JSON: %w", err)
-		}
-		result, err := yaml.Marshal(data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert to YAML: %w", err)
-		}
-		return &domain.ConvertYAMLResponse{Result: string(result)}, nil
+	result, detected, err := convertData(req.Content, from, req.To, req.CSVHeader)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("unsupported conversion target: %s", req.To)
+	return &domain.ConvertYAMLResponse{Result: result, From: detected}, nil
 }
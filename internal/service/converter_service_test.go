@@ -0,0 +1,1208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestConvertBase(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name      string
+		req       domain.ConvertBaseRequest
+		want      string
+		truncated bool
+	}{
+		{"hex to decimal", domain.ConvertBaseRequest{Value: "FF", FromBase: intPtr(16), ToBase: 10}, "255", false},
+		{"beyond int64", domain.ConvertBaseRequest{Value: "18446744073709551616", ToBase: 16}, "10000000000000000", false},
+		{"negative", domain.ConvertBaseRequest{Value: "-255", ToBase: 2}, "-11111111", false},
+		{"terminating fraction", domain.ConvertBaseRequest{Value: "ff.8", FromBase: intPtr(16), ToBase: 10}, "255.5", false},
+		{"repeating fraction", domain.ConvertBaseRequest{Value: "0.1", ToBase: 2, Precision: intPtr(8)}, "0.00011001", true},
+		{"zero precision", domain.ConvertBaseRequest{Value: "-2.5", ToBase: 10, Precision: intPtr(0)}, "-2", true},
+		{"base 62", domain.ConvertBaseRequest{Value: "zz", FromBase: intPtr(62), FromAlphabet: stringPtr(AlphabetBase62), ToBase: 10}, "3843", false},
+		{"base 64 default alphabet", domain.ConvertBaseRequest{Value: "4095", ToBase: 64}, "//", false},
+		{"base64url minus digit", domain.ConvertBaseRequest{Value: "-", FromBase: intPtr(64), FromAlphabet: stringPtr(AlphabetBase64URL), ToBase: 10}, "62", false},
+		{"crockford decode", domain.ConvertBaseRequest{Value: "o1-iL", FromBase: intPtr(32), FromAlphabet: stringPtr(AlphabetCrockford), ToBase: 10}, "1057", false},
+		{"crockford encode", domain.ConvertBaseRequest{Value: "1057", ToBase: 32, ToAlphabet: stringPtr(AlphabetCrockford)}, "111", false},
+		{"two's complement negative", domain.ConvertBaseRequest{Value: "-1", ToBase: 16, BitWidth: intPtr(8)}, "ff", false},
+		{"two's complement padding", domain.ConvertBaseRequest{Value: "5", ToBase: 2, BitWidth: intPtr(8)}, "00000101", false},
+		{"signed input negative", domain.ConvertBaseRequest{Value: "ff", FromBase: intPtr(16), ToBase: 10, BitWidth: intPtr(8), SignedInput: boolPtr(true)}, "-1", false},
+		{"signed input positive", domain.ConvertBaseRequest{Value: "7f", FromBase: intPtr(16), ToBase: 10, BitWidth: intPtr(8), SignedInput: boolPtr(true)}, "127", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.ConvertBase(&tt.req)
+			if err != nil {
+				t.Fatalf("ConvertBase failed: %v", err)
+			}
+			if result.Result != tt.want || result.Truncated != tt.truncated {
+				t.Errorf("Expected %s (truncated %v), got %s (truncated %v)", tt.want, tt.truncated, result.Result, result.Truncated)
+			}
+		})
+	}
+}
+
+func TestConvertBaseErrors(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name string
+		req  domain.ConvertBaseRequest
+	}{
+		{"invalid digit", domain.ConvertBaseRequest{Value: "fg", FromBase: intPtr(16), ToBase: 10}},
+		{"two radix points", domain.ConvertBaseRequest{Value: "1.2.3", ToBase: 2}},
+		{"no digits", domain.ConvertBaseRequest{Value: "-", ToBase: 2}},
+		{"base beyond alphabet", domain.ConvertBaseRequest{Value: "1", ToBase: 63, ToAlphabet: stringPtr(AlphabetBase62)}},
+		{"crockford beyond base 32", domain.ConvertBaseRequest{Value: "1", ToBase: 36, ToAlphabet: stringPtr(AlphabetCrockford)}},
+		{"too wide for two's complement", domain.ConvertBaseRequest{Value: "-129", ToBase: 16, BitWidth: intPtr(8)}},
+		{"fraction in two's complement", domain.ConvertBaseRequest{Value: "1.5", ToBase: 2, BitWidth: intPtr(8)}},
+		{"signed input without width", domain.ConvertBaseRequest{Value: "ff", FromBase: intPtr(16), ToBase: 10, SignedInput: boolPtr(true)}},
+		{"signed input too wide", domain.ConvertBaseRequest{Value: "1ff", FromBase: intPtr(16), ToBase: 10, BitWidth: intPtr(8), SignedInput: boolPtr(true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.ConvertBase(&tt.req); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestConvertColor(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		value    string
+		to       string
+		want     string
+		detected string
+	}{
+		{"#ff0000", ColorFormatRGB, "rgb(255, 0, 0)", ColorFormatHex},
+		{"F00", ColorFormatHex, "#ff0000", ColorFormatHex},
+		{"#0f08", ColorFormatRGB, "rgba(0, 255, 0, 0.533)", ColorFormatHex},
+		{"rgb(51, 102, 153)", ColorFormatHSL, "hsl(210, 50%, 40%)", ColorFormatRGB},
+		{"rgb(255 128 0 / 50%)", ColorFormatHex, "#ff800080", ColorFormatRGB},
+		{"hsla(210, 50%, 40%, 0.5)", ColorFormatHex, "#33669980", ColorFormatHSL},
+		{"hsl(0.5turn 100% 50%)", ColorFormatHex, "#00ffff", ColorFormatHSL},
+		{"hsv(300, 100%, 50%)", ColorFormatName, "purple", ColorFormatHSV},
+		{"hwb(120 20% 20%)", ColorFormatHex, "#33cc33", ColorFormatHWB},
+		{"#663399", ColorFormatHWB, "hwb(270 20% 40%)", ColorFormatHex},
+		{"device-cmyk(0 1 1 0)", ColorFormatName, "red", ColorFormatCMYK},
+		{"#336699", ColorFormatCMYK, "device-cmyk(66.7% 33.3% 0% 40%)", ColorFormatHex},
+		{"red", ColorFormatLab, "lab(54.29 80.8 69.89)", ColorFormatName},
+		{"red", ColorFormatLCH, "lch(54.29 106.84 40.86)", ColorFormatName},
+		{"red", ColorFormatOklab, "oklab(0.628 0.2249 0.1258)", ColorFormatName},
+		{"red", ColorFormatOklch, "oklch(0.628 0.2577 29.23)", ColorFormatName},
+		{"lch(54.29 106.84 40.85)", ColorFormatHSL, "hsl(0, 100%, 50%)", ColorFormatLCH},
+		{"oklch(0.628 0.2577 29.23)", ColorFormatHex, "#ff0000", ColorFormatOklch},
+		{"White", ColorFormatLab, "lab(100 0 0)", ColorFormatName},
+		{"#00ffff", ColorFormatName, "aqua", ColorFormatHex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" to "+tt.to, func(t *testing.T) {
+			result, err := svc.ConvertColor(&domain.ConvertColorRequest{Value: tt.value, To: stringPtr(tt.to)})
+			if err != nil {
+				t.Fatalf("ConvertColor failed: %v", err)
+			}
+			if result.Result != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, result.Result)
+			}
+			if result.Detected != tt.detected {
+				t.Errorf("Expected detected format %s, got %s", tt.detected, result.Detected)
+			}
+		})
+	}
+}
+
+func TestConvertColorFormats(t *testing.T) {
+	svc := NewConverterService()
+
+	result, err := svc.ConvertColor(&domain.ConvertColorRequest{Value: "rebeccapurple"})
+	if err != nil {
+		t.Fatalf("ConvertColor failed: %v", err)
+	}
+	if result.Result != "#663399" || result.Format != ColorFormatHex {
+		t.Errorf("Expected hex result by default, got %s (%s)", result.Result, result.Format)
+	}
+	if result.Formats.RGB != "rgb(102, 51, 153)" || result.Formats.HSV != "hsv(270, 66.7%, 60%)" || result.Formats.Name != "rebeccapurple" {
+		t.Errorf("Unexpected formats: %+v", result.Formats)
+	}
+
+	// Colors outside sRGB are clipped in sRGB formats
+	result, err = svc.ConvertColor(&domain.ConvertColorRequest{Value: "oklch(0.7 0.3 150)"})
+	if err != nil {
+		t.Fatalf("ConvertColor failed: %v", err)
+	}
+	if result.InGamut || result.Formats.Oklch != "oklch(0.7 0.3 150)" || result.Formats.Name != "" {
+		t.Errorf("Expected out of gamut color to keep its OKLCH value, got %+v", result)
+	}
+}
+
+func TestConvertColorErrors(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name string
+		req  domain.ConvertColorRequest
+	}{
+		{"unknown name", domain.ConvertColorRequest{Value: "blurple"}},
+		{"bad hex length", domain.ConvertColorRequest{Value: "#12345"}},
+		{"rgb out of range", domain.ConvertColorRequest{Value: "rgb(256, 0, 0)"}},
+		{"missing component", domain.ConvertColorRequest{Value: "hsl(120, 50%)"}},
+		{"missing parenthesis", domain.ConvertColorRequest{Value: "rgb(1, 2, 3"}},
+		{"invalid hue", domain.ConvertColorRequest{Value: "hsl(abc 50% 50%)"}},
+		{"format mismatch", domain.ConvertColorRequest{Value: "#ff0000", From: stringPtr(ColorFormatRGB)}},
+		{"no matching name", domain.ConvertColorRequest{Value: "#123456", To: stringPtr(ColorFormatName)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.ConvertColor(&tt.req); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestColorContrast(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		fg, bg string
+		ratio  float64
+		apca   float64
+		wcag   domain.WCAGResults
+	}{
+		{"black", "white", 21, 106.04, domain.WCAGResults{AANormal: true, AALarge: true, AAANormal: true, AAALarge: true}},
+		{"#fff", "#000", 21, -107.88, domain.WCAGResults{AANormal: true, AALarge: true, AAANormal: true, AAALarge: true}},
+		{"#777777", "#ffffff", 4.48, 71.11, domain.WCAGResults{AALarge: true}},
+		{"#767676", "#ffffff", 4.54, 71.57, domain.WCAGResults{AANormal: true, AALarge: true, AAALarge: true}},
+		{"#888", "#fff", 3.54, 63.06, domain.WCAGResults{AALarge: true}},
+		{"red", "red", 1, 0, domain.WCAGResults{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fg+" on "+tt.bg, func(t *testing.T) {
+			result, err := svc.ColorContrast(&domain.ColorContrastRequest{Foreground: tt.fg, Background: tt.bg})
+			if err != nil {
+				t.Fatalf("ColorContrast failed: %v", err)
+			}
+			if result.Ratio != tt.ratio || result.APCA != tt.apca || result.WCAG != tt.wcag {
+				t.Errorf("Expected ratio %v, APCA %v, %+v; got ratio %v, APCA %v, %+v", tt.ratio, tt.apca, tt.wcag, result.Ratio, result.APCA, result.WCAG)
+			}
+		})
+	}
+
+	// Translucent text is composited over the background
+	result, err := svc.ColorContrast(&domain.ColorContrastRequest{Foreground: "rgb(0 0 0 / 50%)", Background: "white"})
+	if err != nil {
+		t.Fatalf("ColorContrast failed: %v", err)
+	}
+	if result.Foreground != "#808080" {
+		t.Errorf("Expected composited foreground #808080, got %s", result.Foreground)
+	}
+}
+
+func TestColorPalette(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		color  string
+		scheme string
+		count  *int
+		want   []string
+	}{
+		{"red", PaletteComplementary, nil, []string{"#ff0000", "#00ffff"}},
+		{"red", PaletteSplitComplementary, nil, []string{"#ff0000", "#00ff80", "#0080ff"}},
+		{"red", PaletteTriadic, nil, []string{"#ff0000", "#00ff00", "#0000ff"}},
+		{"red", PaletteTetradic, nil, []string{"#ff0000", "#80ff00", "#00ffff", "#8000ff"}},
+		{"red", PaletteAnalogous, intPtr(3), []string{"#ff0080", "#ff0000", "#ff8000"}},
+		{"black", PaletteTints, intPtr(2), []string{"#000000", "#808080"}},
+		{"white", PaletteShades, intPtr(4), []string{"#ffffff", "#bfbfbf", "#808080", "#404040"}},
+		{"red", PaletteScale, intPtr(3), []string{"#ff8080", "#ff0000", "#800000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			result, err := svc.ColorPalette(&domain.ColorPaletteRequest{Color: tt.color, Scheme: tt.scheme, Count: tt.count})
+			if err != nil {
+				t.Fatalf("ColorPalette failed: %v", err)
+			}
+			if !slices.Equal(result.Colors, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, result.Colors)
+			}
+		})
+	}
+
+	result, err := svc.ColorPalette(&domain.ColorPaletteRequest{Color: "red", Scheme: PaletteComplementary, Format: stringPtr(ColorFormatHSL)})
+	if err != nil {
+		t.Fatalf("ColorPalette failed: %v", err)
+	}
+	if result.Colors[1] != "hsl(180, 100%, 50%)" {
+		t.Errorf("Expected HSL output, got %v", result.Colors)
+	}
+}
+
+func TestSimulateColorBlindness(t *testing.T) {
+	svc := NewConverterService()
+
+	result, err := svc.SimulateColorBlindness(&domain.SimulateColorBlindnessRequest{Color: "red"})
+	if err != nil {
+		t.Fatalf("SimulateColorBlindness failed: %v", err)
+	}
+	if result.Achromatopsia != "#7f7f7f" {
+		t.Errorf("Expected red to have the luminance of #7f7f7f, got %s", result.Achromatopsia)
+	}
+	if result.Protanopia == result.Original || result.Protanopia == result.Protanomaly {
+		t.Errorf("Expected protanopia to differ from normal vision and protanomaly: %+v", result)
+	}
+
+	// Neutral colors are unaffected and severity 0 is normal vision
+	result, err = svc.SimulateColorBlindness(&domain.SimulateColorBlindnessRequest{Color: "white"})
+	if err != nil {
+		t.Fatalf("SimulateColorBlindness failed: %v", err)
+	}
+	for _, simulated := range []string{result.Protanopia, result.Deuteranopia, result.Tritanopia, result.Achromatopsia} {
+		if simulated != "#ffffff" {
+			t.Errorf("Expected white to stay white, got %+v", result)
+		}
+	}
+
+	severity := 0.0
+	result, err = svc.SimulateColorBlindness(&domain.SimulateColorBlindnessRequest{Color: "#336699", Severity: &severity})
+	if err != nil {
+		t.Fatalf("SimulateColorBlindness failed: %v", err)
+	}
+	if result.Protanomaly != "#336699" || result.Deuteranomaly != "#336699" || result.Tritanomaly != "#336699" {
+		t.Errorf("Expected severity 0 to leave the color unchanged, got %+v", result)
+	}
+}
+
+func TestConvertTime(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name     string
+		req      domain.ConvertTimeRequest
+		expected string
+		detected string
+		timezone string
+	}{
+		{"unix to iso8601", domain.ConvertTimeRequest{Value: "1700000000", From: stringPtr("unix"), To: "iso8601"}, "2023-11-14T22:13:20Z", "unix", "UTC"},
+		{"detect seconds", domain.ConvertTimeRequest{Value: "1700000000.5", To: "iso8601"}, "2023-11-14T22:13:20.5Z", "unix", "UTC"},
+		{"detect milliseconds", domain.ConvertTimeRequest{Value: "1700000000123", To: "unix"}, "1700000000.123", "unix_ms", "UTC"},
+		{"detect microseconds", domain.ConvertTimeRequest{Value: "1700000000123456", To: "unix_ms"}, "1700000000123", "unix_us", "UTC"},
+		{"detect nanoseconds", domain.ConvertTimeRequest{Value: "1700000000123456789", To: "iso8601"}, "2023-11-14T22:13:20.123456789Z", "unix_ns", "UTC"},
+		{"negative epoch", domain.ConvertTimeRequest{Value: "-1.5", To: "unix_ms"}, "-1500", "unix", "UTC"},
+		{"iso8601 to unix", domain.ConvertTimeRequest{Value: "2023-11-14T23:13:20+01:00", To: "unix"}, "1700000000", "iso8601", "UTC+01:00"},
+		{"rfc1123 input", domain.ConvertTimeRequest{Value: "Tue, 14 Nov 2023 22:13:20 GMT", To: "unix"}, "1700000000", "rfc1123", "GMT"},
+		{"rfc822 output", domain.ConvertTimeRequest{Value: "1700000000", To: "rfc822"}, "14 Nov 23 22:13 +0000", "unix", "UTC"},
+		{"iso week output", domain.ConvertTimeRequest{Value: "2023-11-14", To: "iso_week"}, "2023-W46-2", "iso8601", "UTC"},
+		{"iso week input", domain.ConvertTimeRequest{Value: "2021-W01", To: "iso8601"}, "2021-01-04T00:00:00Z", "iso_week", "UTC"},
+		{"to time zone", domain.ConvertTimeRequest{Value: "1700000000", To: "human", ToTZ: stringPtr("America/New_York")}, "Tue, 14 Nov 2023 17:13:20 EST", "unix", "America/New_York"},
+		{"from time zone", domain.ConvertTimeRequest{Value: "2024-07-01 09:00:00", To: "iso8601", FromTZ: stringPtr("Europe/Berlin"), ToTZ: stringPtr("Asia/Tokyo")}, "2024-07-01T16:00:00+09:00", "iso8601", "Asia/Tokyo"},
+		{"go layout", domain.ConvertTimeRequest{Value: "14/11/2023 22:13", FromLayout: stringPtr("02/01/2006 15:04"), To: "unix"}, "1699999980", "layout", "UTC"},
+		{"strftime", domain.ConvertTimeRequest{Value: "1700000000", To: "strftime", ToLayout: stringPtr("%A %d %B %Y, %H:%M:%S.%L")}, "Tuesday 14 November 2023, 22:13:20.000", "unix", "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.ConvertTime(&tt.req)
+			if err != nil {
+				t.Fatalf("ConvertTime failed: %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result.Result)
+			}
+			if result.Detected != tt.detected {
+				t.Errorf("Expected detected format %s, got %s", tt.detected, result.Detected)
+			}
+			if result.Timezone != tt.timezone {
+				t.Errorf("Expected time zone %s, got %s", tt.timezone, result.Timezone)
+			}
+		})
+	}
+}
+
+func TestConvertTimeErrors(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name string
+		req  domain.ConvertTimeRequest
+	}{
+		{"unrecognized", domain.ConvertTimeRequest{Value: "next tuesday", To: "unix"}},
+		{"wrong format", domain.ConvertTimeRequest{Value: "2023-11-14", From: stringPtr("rfc1123"), To: "unix"}},
+		{"week out of range", domain.ConvertTimeRequest{Value: "2021-W53", To: "unix"}},
+		{"unknown zone", domain.ConvertTimeRequest{Value: "0", To: "unix", ToTZ: stringPtr("Mars/Olympus")}},
+		{"local zone", domain.ConvertTimeRequest{Value: "0", To: "unix", FromTZ: stringPtr("Local")}},
+		{"missing layout", domain.ConvertTimeRequest{Value: "0", To: "layout"}},
+		{"nanoseconds overflow", domain.ConvertTimeRequest{Value: "2300-01-01", To: "unix_ns"}},
+		{"year out of range", domain.ConvertTimeRequest{Value: "999999999999", From: stringPtr("unix"), To: "iso8601"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.ConvertTime(&tt.req); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestStrftimeToLayout(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+		wantErr  bool
+	}{
+		{"%Y-%m-%dT%H:%M:%S%z", "2006-01-02T15:04:05-0700", false},
+		{"%F %T.%f", "2006-01-02 15:04:05.000000", false},
+		{"%e %b %y, %I:%M %p %Z", "_2 Jan 06, 03:04 PM MST", false},
+		{"100%% at %H", "", true},
+		{"%H Mon", "", true},
+		{"%Q", "", true},
+		{"%S%f", "", true},
+		{"%Y%", "", true},
+	}
+
+	for _, tt := range tests {
+		layout, err := strftimeToLayout(tt.format)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for %q, got %q", tt.format, layout)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("strftimeToLayout(%q) failed: %v", tt.format, err)
+		} else if layout != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, layout)
+		}
+	}
+}
+
+func TestFormatRelativeTime(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		t        time.Time
+		expected string
+	}{
+		{now.Add(300 * time.Millisecond), "just now"},
+		{now.Add(-3*time.Hour - 20*time.Minute), "3 hours ago"},
+		{now.Add(time.Minute), "in 1 minute"},
+		{now.AddDate(0, 0, -15), "2 weeks ago"},
+		{now.AddDate(0, 2, 1), "in 2 months"},
+		{now.AddDate(-500, 0, 0), "500 years ago"},
+	}
+
+	for _, tt := range tests {
+		if result := formatRelativeTime(tt.t, now); result != tt.expected {
+			t.Errorf("Expected %q for %s, got %q", tt.expected, tt.t, result)
+		}
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name        string
+		req         domain.CronParseRequest
+		description string
+		nextRuns    []string
+	}{
+		{
+			"five fields",
+			domain.CronParseRequest{Expression: "*/15 9-17 * * MON-FRI", Count: intPtr(3), Start: stringPtr("2024-03-08T16:50:00Z")},
+			"At every 15th minute past every hour from 9 through 17 on every day-of-week from Monday through Friday.",
+			[]string{"2024-03-08T17:00:00Z", "2024-03-08T17:15:00Z", "2024-03-08T17:30:00Z"},
+		},
+		{
+			"six fields",
+			domain.CronParseRequest{Expression: "30 0 12 1,15 * *", Count: intPtr(2), Start: stringPtr("2024-01-01T00:00:00Z")},
+			"At 12:00:30 on day-of-month 1 and 15.",
+			[]string{"2024-01-01T12:00:30Z", "2024-01-15T12:00:30Z"},
+		},
+		{
+			"macro in time zone",
+			domain.CronParseRequest{Expression: "@daily", Count: intPtr(2), Timezone: stringPtr("Asia/Kolkata"), Start: stringPtr("2024-01-01T00:00:00Z")},
+			"At 00:00.",
+			[]string{"2024-01-02T00:00:00+05:30", "2024-01-03T00:00:00+05:30"},
+		},
+		{
+			"every",
+			domain.CronParseRequest{Expression: "@every 1h30m", Count: intPtr(2), Start: stringPtr("1704067200")},
+			"Every 1h30m0s",
+			[]string{"2024-01-01T01:30:00Z", "2024-01-01T03:00:00Z"},
+		},
+		{
+			"skipped by spring forward",
+			domain.CronParseRequest{Expression: "30 2 * * *", Count: intPtr(3), Timezone: stringPtr("America/New_York"), Start: stringPtr("2024-03-09T12:00:00")},
+			"At 02:30.",
+			[]string{"2024-03-10T03:00:00-04:00", "2024-03-11T02:30:00-04:00", "2024-03-12T02:30:00-04:00"},
+		},
+		{
+			"repeated by fall back",
+			domain.CronParseRequest{Expression: "30 1 * * *", Count: intPtr(2), Timezone: stringPtr("America/New_York"), Start: stringPtr("2024-11-02T12:00:00")},
+			"At 01:30.",
+			[]string{"2024-11-03T01:30:00-04:00", "2024-11-04T01:30:00-05:00"},
+		},
+		{
+			"hourly across fall back",
+			domain.CronParseRequest{Expression: "0 * * * *", Count: intPtr(3), Timezone: stringPtr("America/New_York"), Start: stringPtr("2024-11-03T00:30:00")},
+			"At minute 0.",
+			[]string{"2024-11-03T01:00:00-04:00", "2024-11-03T01:00:00-05:00", "2024-11-03T02:00:00-05:00"},
+		},
+		{
+			"never runs",
+			domain.CronParseRequest{Expression: "0 0 30 2 *", Start: stringPtr("2024-01-01")},
+			"At 00:00 on day-of-month 30 in February.",
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.ParseCron(&tt.req)
+			if err != nil {
+				t.Fatalf("ParseCron failed: %v", err)
+			}
+			if result.Description != tt.description {
+				t.Errorf("Expected description %q, got %q", tt.description, result.Description)
+			}
+			if !slices.Equal(result.NextRuns, tt.nextRuns) {
+				t.Errorf("Expected next runs %v, got %v", tt.nextRuns, result.NextRuns)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	svc := NewConverterService()
+
+	for _, expression := range []string{"* * * *", "60 * * * *", "* * * * 8", "@fortnightly", "TZ=Local * * * * *"} {
+		if _, err := svc.ParseCron(&domain.CronParseRequest{Expression: expression}); err == nil {
+			t.Errorf("Expected error for %q, got nil", expression)
+		}
+	}
+	if _, err := svc.ParseCron(&domain.CronParseRequest{Expression: "@daily", Timezone: stringPtr("Local")}); err == nil {
+		t.Error("Expected error for the local time zone, got nil")
+	}
+}
+
+func TestDescribeCron(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{"* * * * *", "At every minute."},
+		{"0 */2 * * *", "At minute 0 past every 2nd hour."},
+		{"5 4 * * sun", "At 04:05 on Sunday."},
+		{"0 0 1 1 1", "At 00:00 on day-of-month 1 and on Monday in January."},
+		{"*/10 * * * * *", "At every 10th second."},
+		{"0 22 * * 1-5/2", "At 22:00 on every 2nd day-of-week from Monday through Friday."},
+		{"0,30 8-10,14 * JAN,JUL *", "At minute 0 and 30 past hour 8 through 10 and 14 in January and July."},
+		{"@monthly", "At 00:00 on day-of-month 1."},
+	}
+
+	for _, tt := range tests {
+		if result := describeCron(cronExpressionFields(tt.expression)); result != tt.expected {
+			t.Errorf("Expected %q for %q, got %q", tt.expected, tt.expression, result)
+		}
+	}
+}
+
+func TestCalculateTime(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name     string
+		req      domain.TimeCalcRequest
+		expected string
+	}{
+		{"add month clamps", domain.TimeCalcRequest{Operation: "add", Time: stringPtr("2024-01-31T10:00:00Z"), Duration: stringPtr("P1M")}, "2024-02-29T10:00:00Z"},
+		{"subtract go duration", domain.TimeCalcRequest{Operation: "subtract", Time: stringPtr("2024-01-01T00:00:00Z"), Duration: stringPtr("1h30m")}, "2023-12-31T22:30:00Z"},
+		{"day keeps wall clock", domain.TimeCalcRequest{Operation: "add", Time: stringPtr("2024-03-09T12:00:00"), Duration: stringPtr("P1D"), Timezone: stringPtr("America/New_York")}, "2024-03-10T12:00:00-04:00"},
+		{"hours are elapsed", domain.TimeCalcRequest{Operation: "add", Time: stringPtr("2024-03-09T12:00:00"), Duration: stringPtr("PT24H"), Timezone: stringPtr("America/New_York")}, "2024-03-10T13:00:00-04:00"},
+		{"epoch output", domain.TimeCalcRequest{Operation: "add", Time: stringPtr("0"), Duration: stringPtr("P1W"), Format: stringPtr("unix")}, "604800"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.CalculateTime(&tt.req)
+			if err != nil {
+				t.Fatalf("CalculateTime failed: %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result.Result)
+			}
+		})
+	}
+}
+
+func TestCalculateTimeDiff(t *testing.T) {
+	svc := NewConverterService()
+
+	// Friday March 1 to Monday March 11, 2024
+	result, err := svc.CalculateTime(&domain.TimeCalcRequest{
+		Operation: "diff",
+		Time:      stringPtr("2024-03-01"),
+		End:       stringPtr("2024-03-11"),
+		Holidays:  []string{"2024-03-04", "2024-03-04", "2024-03-09", "2024-03-11"},
+	})
+	if err != nil {
+		t.Fatalf("CalculateTime failed: %v", err)
+	}
+	if *result.CalendarDays != 10 {
+		t.Errorf("Expected 10 calendar days, got %d", *result.CalendarDays)
+	}
+	// Holidays on weekends, repeated or on the end date don't count
+	if *result.BusinessDays != 5 {
+		t.Errorf("Expected 5 business days, got %d", *result.BusinessDays)
+	}
+	if result.Duration.ISO8601 != "P10D" || result.Duration.Hours != 240 {
+		t.Errorf("Expected a duration of 10 days, got %+v", result.Duration)
+	}
+
+	result, err = svc.CalculateTime(&domain.TimeCalcRequest{Operation: "diff", Time: stringPtr("2024-03-11"), End: stringPtr("2024-03-01")})
+	if err != nil {
+		t.Fatalf("CalculateTime failed: %v", err)
+	}
+	if *result.CalendarDays != -10 || *result.BusinessDays != -6 || result.Duration.ISO8601 != "-P10D" {
+		t.Errorf("Expected a negative difference, got %d calendar and %d business days, %s", *result.CalendarDays, *result.BusinessDays, result.Duration.ISO8601)
+	}
+}
+
+func TestCalculateTimeConvert(t *testing.T) {
+	svc := NewConverterService()
+
+	result, err := svc.CalculateTime(&domain.TimeCalcRequest{Operation: "convert", Duration: stringPtr("P1DT2H30M")})
+	if err != nil {
+		t.Fatalf("CalculateTime failed: %v", err)
+	}
+	if result.Duration.Hours != 26.5 || result.Duration.Go != "26h30m0s" || result.Duration.ISO8601 != "P1DT2H30M" {
+		t.Errorf("Unexpected duration units: %+v", result.Duration)
+	}
+
+	result, err = svc.CalculateTime(&domain.TimeCalcRequest{Operation: "convert", Duration: stringPtr("-90.5s")})
+	if err != nil {
+		t.Fatalf("CalculateTime failed: %v", err)
+	}
+	if result.Duration.ISO8601 != "-PT1M30.5S" || result.Duration.Milliseconds != -90500 {
+		t.Errorf("Unexpected duration units: %+v", result.Duration)
+	}
+}
+
+func TestCalculateTimeErrors(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name string
+		req  domain.TimeCalcRequest
+	}{
+		{"calendar convert", domain.TimeCalcRequest{Operation: "convert", Duration: stringPtr("P1M")}},
+		{"empty duration", domain.TimeCalcRequest{Operation: "convert", Duration: stringPtr("P")}},
+		{"empty time part", domain.TimeCalcRequest{Operation: "convert", Duration: stringPtr("P1DT")}},
+		{"hours in date part", domain.TimeCalcRequest{Operation: "convert", Duration: stringPtr("P1H")}},
+		{"day unit", domain.TimeCalcRequest{Operation: "convert", Duration: stringPtr("1d")}},
+		{"missing duration", domain.TimeCalcRequest{Operation: "add"}},
+		{"invalid time", domain.TimeCalcRequest{Operation: "add", Time: stringPtr("tomorrow"), Duration: stringPtr("1h")}},
+		{"out of range", domain.TimeCalcRequest{Operation: "add", Time: stringPtr("9999-12-31"), Duration: stringPtr("P1D")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.CalculateTime(&tt.req); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestConvertData(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name     string
+		content  string
+		from     string
+		to       string
+		expected string
+	}{
+		{"json to yaml keeps order", `{"b":1,"a":[true,null]}`, "json", "yaml", "b: 1\na:\n    - true\n    - null\n"},
+		{"yaml documents to json", "a: 1\n---\na: 2\n", "yaml", "json", "[\n  {\n    \"a\": 1\n  },\n  {\n    \"a\": 2\n  }\n]"},
+		{"big integers stay integers", `{"n":12345678901234567890}`, "json", "yaml", "n: 12345678901234567890\n"},
+		{"toml to json", "title = \"x\"\n[owner]\nname = \"Ada\"\n", "toml", "json", "{\n  \"owner\": {\n    \"name\": \"Ada\"\n  },\n  \"title\": \"x\"\n}"},
+		{"xml attributes and lists", `<a id="1"><b>x</b><b>y</b></a>`, "xml", "json", "{\n  \"a\": {\n    \"@id\": \"1\",\n    \"b\": [\n      \"x\",\n      \"y\"\n    ]\n  }\n}"},
+		{"csv header inferred", "name,age\nAda,36\n", "csv", "json", "[\n  {\n    \"name\": \"Ada\",\n    \"age\": \"36\"\n  }\n]"},
+		{"csv without header", "1,2\n3,4\n", "csv", "json", "[\n  [\n    \"1\",\n    \"2\"\n  ],\n  [\n    \"3\",\n    \"4\"\n  ]\n]"},
+		{"nested objects flattened", `[{"id":1,"user":{"name":"Ada"}},{"id":2,"tags":["x"]}]`, "json", "tsv", "id\tuser.name\ttags.0\n1\tAda\t\n2\t\tx\n"},
+		{"ini sections", "debug = true\n[db]\nhost = localhost\n", "ini", "json", "{\n  \"debug\": \"true\",\n  \"db\": {\n    \"host\": \"localhost\"\n  }\n}"},
+		{"json to ini", `{"name":"app","db":{"port":5432,"opts":{"ssl":true}}}`, "json", "ini", "name = app\n\n[db]\nport = 5432\nopts.ssl = true\n"},
+		{"env keys", `{"db":{"host":"localhost","pass":"a b"},"log-level":"info"}`, "json", "env", "DB_HOST=localhost\nDB_PASS=\"a b\"\nLOG_LEVEL=info\n"},
+		{"env quoting", "export A=\"x\\ny\"\nB='$HOME' # literal\nC=plain # comment\n", "env", "json", "{\n  \"A\": \"x\\ny\",\n  \"B\": \"$HOME\",\n  \"C\": \"plain\"\n}"},
+		{"hcl blocks", "region = \"eu\"\nresource \"aws_s3_bucket\" \"logs\" {\n  acl = \"private\"\n}\n", "hcl", "json", "{\n  \"region\": \"eu\",\n  \"resource\": {\n    \"aws_s3_bucket\": {\n      \"logs\": {\n        \"acl\": \"private\"\n      }\n    }\n  }\n}"},
+		{"json to hcl", `{"name":"app","server":[{"port":80},{"port":443}]}`, "json", "hcl", "name = \"app\"\nserver {\n  port = 80\n}\nserver {\n  port = 443\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.ConvertYAML(&domain.ConvertYAMLRequest{Content: tt.content, From: stringPtr(tt.from), To: tt.to})
+			if err != nil {
+				t.Fatalf("ConvertYAML failed: %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result.Result)
+			}
+		})
+	}
+}
+
+func TestConvertDataDetect(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := map[string]string{
+		`{"a":1}`:    "json",
+		"a: 1\n":     "yaml",
+		"<a>1</a>":   "xml",
+		"  [1, 2]\n": "json",
+		"- 1\n- 2\n": "yaml",
+	}
+	for content, expected := range tests {
+		result, err := svc.ConvertYAML(&domain.ConvertYAMLRequest{Content: content, To: "json"})
+		if err != nil {
+			t.Fatalf("ConvertYAML(%q) failed: %v", content, err)
+		}
+		if result.From != expected {
+			t.Errorf("Expected %s for %q, got %s", expected, content, result.From)
+		}
+	}
+}
+
+func TestConvertDataErrors(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name    string
+		content string
+		from    string
+		to      string
+		line    int
+		column  int
+	}{
+		{"json", "{\n  \"a\": 1,\n  \"b\" 2\n}", "json", "yaml", 3, 7},
+		{"yaml", "a: 1\nb: c: d\n", "yaml", "json", 2, 0},
+		{"toml", "a = 1\nb = \n", "toml", "json", 2, 5},
+		{"xml", "<a>\n<b></a>", "xml", "json", 2, 8},
+		{"csv", "a,b\n1,\"2\n", "csv", "json", 2, 6},
+		{"ini", "[db]\nhost\n", "ini", "json", 2, 1},
+		{"env", "A=1\n  B=\"x\n", "env", "json", 2, 3},
+		{"hcl", "a = 1\nb = {\n", "hcl", "json", 3, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ConvertYAML(&domain.ConvertYAMLRequest{Content: tt.content, From: stringPtr(tt.from), To: tt.to})
+			var parseErr *DataParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a DataParseError, got %v", err)
+			}
+			if parseErr.Line != tt.line || (tt.column > 0 && parseErr.Column != tt.column) {
+				t.Errorf("Expected line %d, column %d, got %d, %d: %v", tt.line, tt.column, parseErr.Line, parseErr.Column, err)
+			}
+		})
+	}
+
+	// Values the target format cannot hold are rejected
+	unsupported := []struct{ content, to string }{
+		{`[1,2]`, "toml"},
+		{`{"a":null}`, "toml"},
+		{`{"a":{"b":1},"a_b":2}`, "env"},
+		{`{"1a":1}`, "hcl"},
+		{`[{"a":1},2]`, "csv"},
+	}
+	for _, tt := range unsupported {
+		if _, err := svc.ConvertYAML(&domain.ConvertYAMLRequest{Content: tt.content, From: stringPtr("json"), To: tt.to}); err == nil {
+			t.Errorf("Expected error converting %s to %s, got nil", tt.content, tt.to)
+		}
+	}
+}
+
+func TestConvertDataYAMLAliasBomb(t *testing.T) {
+	// Each level refers to the previous one 9 times, expanding to 9^9 nodes
+	var b strings.Builder
+	b.WriteString("a0: &a0 [x, x, x, x, x, x, x, x, x]\n")
+	for i := 1; i <= 9; i++ {
+		fmt.Fprintf(&b, "a%d: &a%d [", i, i)
+		for j := range 9 {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "*a%d", i-1)
+		}
+		b.WriteString("]\n")
+	}
+
+	start := time.Now()
+	_, err := NewConverterService().ConvertYAML(&domain.ConvertYAMLRequest{Content: b.String(), From: stringPtr("yaml"), To: "json"})
+	var parseErr *DataParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a DataParseError, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the document to be rejected quickly, took %s", elapsed)
+	}
+
+	// Aliases within the limit still expand
+	result, err := NewConverterService().ConvertYAML(&domain.ConvertYAMLRequest{Content: "a: &a [1, 2]\nb: *a\n", From: stringPtr("yaml"), To: "json"})
+	if err != nil {
+		t.Fatalf("ConvertYAML failed: %v", err)
+	}
+	if !strings.Contains(strings.Join(strings.Fields(result.Result), ""), `"b":[1,2]`) {
+		t.Errorf("Expected the alias to expand, got %s", result.Result)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name     string
+		req      domain.FormatJSONRequest
+		expected string
+	}{
+		{"keeps order and numbers", domain.FormatJSONRequest{JSON: `{"b":1.50,"a":12345678901234567890,"c":"\u00e9"}`}, "{\n  \"b\": 1.50,\n  \"a\": 12345678901234567890,\n  \"c\": \"\\u00e9\"\n}"},
+		{"minify", domain.FormatJSONRequest{JSON: "{\n  \"b\": [1, 2],\n  \"a\": {}\n}", Minify: boolPtr(true)}, `{"b":[1,2],"a":{}}`},
+		{"sort keys with tabs", domain.FormatJSONRequest{JSON: `{"b":1,"a":{"d":2,"c":[]}}`, SortKeys: boolPtr(true), UseTabs: boolPtr(true)}, "{\n\t\"a\": {\n\t\t\"c\": [],\n\t\t\"d\": 2\n\t},\n\t\"b\": 1\n}"},
+		{"zero indent", domain.FormatJSONRequest{JSON: `[1,2]`, Indent: intPtr(0)}, "[\n1,\n2\n]"},
+		{
+			"jsonc comments move with sorted keys",
+			domain.FormatJSONRequest{JSON: "// head\n{\n  // about b\n  \"b\": 1, // one\n  \"a\": [1, 2,],\n  // end\n}", Syntax: stringPtr("jsonc"), SortKeys: boolPtr(true)},
+			"// head\n{\n  \"a\": [\n    1,\n    2\n  ],\n  // about b\n  \"b\": 1 // one\n  // end\n}",
+		},
+		{"strip comments", domain.FormatJSONRequest{JSON: "[1, /* x */ 2] // y", Syntax: stringPtr("jsonc"), StripComments: boolPtr(true), Minify: boolPtr(true)}, "[1,2]"},
+		{
+			"json5 values",
+			domain.FormatJSONRequest{JSON: "{key: 'it\\'s', hex: 0x1F, half: .5, five: +5., $id: 'a\\\nb',}", Syntax: stringPtr("json5"), Minify: boolPtr(true)},
+			`{"key":"it's","hex":31,"half":0.5,"five":5,"$id":"ab"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.FormatJSON(&tt.req)
+			if err != nil {
+				t.Fatalf("FormatJSON failed: %v", err)
+			}
+			if result.Result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result.Result)
+			}
+		})
+	}
+}
+
+func TestFormatJSONErrors(t *testing.T) {
+	svc := NewConverterService()
+
+	tests := []struct {
+		name   string
+		json   string
+		syntax string
+		line   int
+		column int
+	}{
+		{"missing colon", "{\n  \"a\" 1\n}", "json", 2, 7},
+		{"trailing comma", `[1,]`, "json", 1, 4},
+		{"comment", "[1] // x", "json", 1, 5},
+		{"leading zero", `[01]`, "json", 1, 2},
+		{"invalid escape", `"\q"`, "json", 1, 2},
+		{"unterminated", "[1,\n2", "jsonc", 2, 2},
+		{"unterminated comment", "[1] /* x", "jsonc", 1, 5},
+		{"infinity", "[1, -Infinity]", "json5", 1, 5},
+		{"trailing data", `{} {}`, "json", 1, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.FormatJSON(&domain.FormatJSONRequest{JSON: tt.json, Syntax: stringPtr(tt.syntax)})
+			var parseErr *DataParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a DataParseError, got %v", err)
+			}
+			if parseErr.Line != tt.line || parseErr.Column != tt.column {
+				t.Errorf("Expected line %d, column %d, got %d, %d: %v", tt.line, tt.column, parseErr.Line, parseErr.Column, err)
+			}
+		})
+	}
+}
+
+const queryStore = `{"store": {
+  "book": [
+    {"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+    {"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+    {"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+    {"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+  ],
+  "bicycle": {"color": "red", "price": 399}
+}}`
+
+// queryResults joins the results of a query for comparison
+func queryResults(t *testing.T, document, query, language string) string {
+	t.Helper()
+	result, err := NewConverterService().QueryJSON(context.Background(), &domain.JSONQueryRequest{JSON: document, Query: query, Language: stringPtr(language)})
+	if err != nil {
+		t.Fatalf("QueryJSON(%q) failed: %v", query, err)
+	}
+	results := make([]string, len(result.Results))
+	for i, r := range result.Results {
+		results[i] = string(r)
+	}
+	return strings.Join(results, " ")
+}
+
+func TestQueryJSONPath(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"$.store.book[*].author", `"Nigel Rees" "Evelyn Waugh" "Herman Melville" "J. R. R. Tolkien"`},
+		{"$.store..price", `8.95 12.99 8.99 22.99 399`},
+		{"$..book[-1].title", `"The Lord of the Rings"`},
+		{"$..book[0, 2]['price', 'title']", `8.95 "Sayings of the Century" 8.99 "Moby Dick"`},
+		{"$..book[::-2].price", `22.99 12.99`},
+		{"$..book[?@.isbn].title", `"Moby Dick" "The Lord of the Rings"`},
+		{"$..book[?@.price < 10 && @.category == 'fiction'].title", `"Moby Dick"`},
+		{"$..book[?!(@.price < 10 || @.category == 'fiction')].title", ``},
+		{"$..book[?match(@.author, 'J.*')].author", `"J. R. R. Tolkien"`},
+		{"$..book[?search(@.title, 'of')].price", `8.95 12.99 22.99`},
+		{"$..book[?length(@.title) > 15].price", `8.95 22.99`},
+		{"$.store.book[?count(@.*) == 5].price", `8.99 22.99`},
+		{"$.store[?value(@..color) == 'red'].price", `399`},
+		{"$..book[?@.price == 8.95e0].price", `8.95`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := queryResults(t, queryStore, tt.query, "jsonpath"); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	// Numbers compare by value, keeping their literals
+	if got := queryResults(t, `[{"a":[1,2]},{"a":[1,2.0]},{"a":12345678901234567891}]`, "$[?@.a == $[0].a || @.a == 12345678901234567891]", "jsonpath"); got != `{"a":[1,2]} {"a":[1,2.0]} {"a":12345678901234567891}` {
+		t.Errorf("Unexpected results: %s", got)
+	}
+	// Dots in match() don't match line breaks
+	if got := queryResults(t, `["x\ny", "xzy"]`, "$[?match(@, 'x.y')]", "jsonpath"); got != `"xzy"` {
+		t.Errorf("Unexpected results: %s", got)
+	}
+}
+
+func TestQueryJSONPathPaths(t *testing.T) {
+	result, err := NewConverterService().QueryJSON(context.Background(), &domain.JSONQueryRequest{JSON: `{"a'b": [1, {"c\n": 2}]}`, Query: "$..*"})
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	expected := []string{`$['a\'b']`, `$['a\'b'][0]`, `$['a\'b'][1]`, `$['a\'b'][1]['c\n']`}
+	if !slices.Equal(result.Paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, result.Paths)
+	}
+	if result.Count != 4 || result.Language != "jsonpath" {
+		t.Errorf("Expected 4 jsonpath results, got %d %s", result.Count, result.Language)
+	}
+}
+
+func TestQueryJSONErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		language string
+		column   int
+	}{
+		{"store", "jsonpath", 1},
+		{"$.store ", "jsonpath", 8},
+		{"$[01]", "jsonpath", 3},
+		{"$['\\ud800']", "jsonpath", 4},
+		{"$[?@.a == $..b]", "jsonpath", 11},
+		{"$[?length(@.*) == 1]", "jsonpath", 11},
+		{"$[?count(@) < 'x' == 1]", "jsonpath", 19},
+		{"$[?match(@.a)]", "jsonpath", 4},
+		{"$[?true]", "jsonpath", 4},
+		{"$[?foo(@)]", "jsonpath", 4},
+		{".a |", "jq", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := NewConverterService().QueryJSON(context.Background(), &domain.JSONQueryRequest{JSON: queryStore, Query: tt.query, Language: stringPtr(tt.language)})
+			var parseErr *DataParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a DataParseError, got %v", err)
+			}
+			if parseErr.Format != tt.language || parseErr.Line != 1 || parseErr.Column != tt.column {
+				t.Errorf("Expected %s error at column %d, got %v", tt.language, tt.column, err)
+			}
+		})
+	}
+}
+
+func TestQueryJQ(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{".store.book[] | select(.price > 10) | .title", `"Sword of Honour" "The Lord of the Rings"`},
+		{"[.store.book[].price] | add", `53.92`},
+		{".store.bicycle | keys", `["color","price"]`},
+		{"1, halt, 2", `1`},
+		{"$ENV", `{}`},
+		{`[(1,2) + (10,20)]`, `[11,12,21,22]`},
+		{`"ab" * 3`, `"ababab"`},
+		{`{"a":{"b":1}} * {"a":{"c":2}}`, `{"a":{"b":1,"c":2}}`},
+		{`[.store.bicycle.price += (1,2) | .store.bicycle.price]`, `[400,401]`},
+		{`.store.bicycle.color *= 2 | .store.bicycle.color`, `"redred"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := queryResults(t, queryStore, tt.query, "jq"); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	if got := queryResults(t, `{"n":12345678901234567890}`, ".n", "jq"); got != "12345678901234567890" {
+		t.Errorf("Expected big integers to keep their precision, got %s", got)
+	}
+	if _, err := NewConverterService().QueryJSON(context.Background(), &domain.JSONQueryRequest{JSON: `{}`, Query: `error("boom")`, Language: stringPtr("jq")}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestQueryJSONLimits(t *testing.T) {
+	svc := NewConverterService()
+
+	result, err := svc.QueryJSON(context.Background(), &domain.JSONQueryRequest{JSON: `null`, Query: "range(20000)", Language: stringPtr("jq")})
+	if err != nil {
+		t.Fatalf("QueryJSON failed: %v", err)
+	}
+	if !result.Truncated || result.Count != maxJSONQueryResults {
+		t.Errorf("Expected %d truncated results, got %d, truncated %v", maxJSONQueryResults, result.Count, result.Truncated)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := svc.QueryJSON(ctx, &domain.JSONQueryRequest{JSON: `null`, Query: "def f: f; f", Language: stringPtr("jq")}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the query to stop with its context, got %v", err)
+	}
+
+	// Values built with + and * share one budget, so these fail before allocating gigabytes
+	for _, query := range []string{
+		`[range(50) | "a" * 268435455] | length`,
+		`"a" * 1e300`,
+		`reduce range(64) as $_ ("a"; . + .) | length`,
+		`reduce range(64) as $_ ([1]; . += .) | length`,
+		`def grow: . * 2 | grow; "ab" | grow`,
+	} {
+		if _, err := svc.QueryJSON(context.Background(), &domain.JSONQueryRequest{JSON: `null`, Query: query, Language: stringPtr("jq")}); !errors.Is(err, ErrJSONQueryTooLarge) {
+			t.Errorf("Expected ErrJSONQueryTooLarge for %s, got %v", query, err)
+		}
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	const schema = `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"age": {"type": "integer", "minimum": 0},
+			"email": {"$ref": "#/$defs/email"}
+		},
+		"required": ["name"],
+		"$defs": {"email": {"type": "string", "format": "email"}}
+	}`
+
+	tests := []struct {
+		name         string
+		json         string
+		schema       string
+		draft        string
+		assertFormat bool
+		expected     []domain.JSONSchemaError
+	}{
+		{"valid", `{"name":"Ann","age":30}`, schema, "2020-12", false, nil},
+		{"all errors", `{"age":-1,"email":5}`, schema, "2020-12", false, []domain.JSONSchemaError{
+			{InstancePath: "", SchemaPath: "/required", Message: "missing property 'name'"},
+			{InstancePath: "/age", SchemaPath: "/properties/age/minimum", Message: "minimum: got -1, want 0"},
+			{InstancePath: "/email", SchemaPath: "/$defs/email/type", Message: "got number, want string"},
+		}},
+		{"format annotation", `{"name":"Ann","email":"nope"}`, schema, "2020-12", false, nil},
+		{"format assertion", `{"name":"Ann","email":"nope"}`, schema, "2020-12", true, []domain.JSONSchemaError{
+			{InstancePath: "/email", SchemaPath: "/$defs/email/format", Message: "'nope' is not valid email: missing @"},
+		}},
+		{"draft-07 asserts formats", `{"name":"Ann","email":"nope"}`, schema, "draft-07", false, []domain.JSONSchemaError{
+			{InstancePath: "/email", SchemaPath: "/$defs/email/format", Message: "'nope' is not valid email: missing @"},
+		}},
+		{"$schema wins", `{"name":"Ann","email":"nope"}`, `{"$schema":"http://json-schema.org/draft-07/schema#","properties":{"email":{"format":"email"}}}`, "2020-12", false, []domain.JSONSchemaError{
+			{InstancePath: "/email", SchemaPath: "/properties/email/format", Message: "'nope' is not valid email: missing @"},
+		}},
+		{"escaped pointers", `{"a/b":"x"}`, `{"properties":{"a/b":{"type":"integer"}}}`, "2020-12", false, []domain.JSONSchemaError{
+			{InstancePath: "/a~1b", SchemaPath: "/properties/a~1b/type", Message: "got string, want integer"},
+		}},
+		{"draft-07 items", `[1,"x"]`, `{"items":{"type":"integer"}}`, "draft-07", false, []domain.JSONSchemaError{
+			{InstancePath: "/1", SchemaPath: "/items/type", Message: "got string, want integer"},
+		}},
+		{"2020-12 prefixItems", `[1,"x"]`, `{"prefixItems":[{"type":"integer"},{"type":"integer"}]}`, "2020-12", false, []domain.JSONSchemaError{
+			{InstancePath: "/1", SchemaPath: "/prefixItems/1/type", Message: "got string, want integer"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewConverterService().ValidateJSON(context.Background(), &domain.JSONValidateRequest{
+				JSON:         tt.json,
+				Schema:       tt.schema,
+				Draft:        stringPtr(tt.draft),
+				AssertFormat: boolPtr(tt.assertFormat),
+			})
+			if err != nil {
+				t.Fatalf("ValidateJSON failed: %v", err)
+			}
+			if result.Valid != (len(tt.expected) == 0) {
+				t.Errorf("Expected valid %v, got %v", len(tt.expected) == 0, result.Valid)
+			}
+			if !slices.Equal(result.Errors, tt.expected) {
+				t.Errorf("Expected errors %v, got %v", tt.expected, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateJSONErrors(t *testing.T) {
+	svc := NewConverterService()
+
+	_, err := svc.ValidateJSON(context.Background(), &domain.JSONValidateRequest{JSON: `{}`, Schema: "{\n  \"type\": }"})
+	var parseErr *DataParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 || !strings.HasPrefix(err.Error(), "schema: ") {
+		t.Errorf("Expected a schema parse error on line 2, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"invalid keyword", `{"type":"text"}`},
+		{"file reference", `{"$ref":"file:///etc/passwd"}`},
+		{"remote reference", `{"$ref":"https://example.com/schema.json"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.ValidateJSON(context.Background(), &domain.JSONValidateRequest{JSON: `{}`, Schema: tt.schema}); err == nil || !strings.HasPrefix(err.Error(), "invalid schema: ") {
+				t.Errorf("Expected an invalid schema error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateJSONStopsWithContext(t *testing.T) {
+	// Each level of nesting doubles the branches to try
+	schema := `{"$ref":"#/$defs/a","$defs":{"a":{"anyOf":[{"type":"integer"},{"items":{"$ref":"#/$defs/a"}},{"items":{"$ref":"#/$defs/a"}}]}}}`
+	document := strings.Repeat("[", 40) + `"x"` + strings.Repeat("]", 40)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, draft := range []string{"draft-07", "2020-12"} {
+		_, err := NewConverterService().ValidateJSON(ctx, &domain.JSONValidateRequest{JSON: document, Schema: schema, Draft: stringPtr(draft)})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected %s validation to stop with its context, got %v", draft, err)
+		}
+	}
+
+	if _, err := NewConverterService().InferJSONSchema(ctx, &domain.JSONSchemaInferRequest{Samples: []string{`{}`}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected inference to stop with its context, got %v", err)
+	}
+}
+
+func TestInferJSONSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  []string
+		draft    string
+		formats  bool
+		expected string
+	}{
+		{"scalar", []string{`42`}, "2020-12", true, `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"integer"}`},
+		{"merged numbers", []string{`1`, `2.5`}, "2020-12", true, `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"number"}`},
+		{"mixed types", []string{`"a"`, `null`, `true`}, "draft-07", true, `{"$schema":"http://json-schema.org/draft-07/schema#","type":["null","boolean","string"]}`},
+		{"required keys", []string{`{"id":1,"email":"a@example.com"}`, `{"id":2,"name":"B"}`}, "2020-12", true,
+			`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"id":{"type":"integer"},"email":{"type":"string","format":"email"},"name":{"type":"string"}},"required":["id"]}`},
+		{"array items", []string{`[{"at":"2024-01-02T03:04:05Z"},{"at":"2024-05-06T07:08:09+02:00","tags":[]}]`}, "2020-12", true,
+			`{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"type":"object","properties":{"at":{"type":"string","format":"date-time"},"tags":{"type":"array"}},"required":["at"]}}`},
+		{"mixed formats", []string{`"2024-01-02"`, `"10.0.0.1"`}, "2020-12", true, `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"string"}`},
+		{"formats off", []string{`"123e4567-e89b-12d3-a456-426614174000"`}, "2020-12", false, `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"string"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewConverterService().InferJSONSchema(context.Background(), &domain.JSONSchemaInferRequest{Samples: tt.samples, Draft: stringPtr(tt.draft), Formats: boolPtr(tt.formats)})
+			if err != nil {
+				t.Fatalf("InferJSONSchema failed: %v", err)
+			}
+			minified, err := NewConverterService().FormatJSON(&domain.FormatJSONRequest{JSON: string(result.Schema), Minify: boolPtr(true)})
+			if err != nil {
+				t.Fatalf("FormatJSON failed: %v", err)
+			}
+			if minified.Result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, minified.Result)
+			}
+		})
+	}
+}
+
+func TestInferJSONSchemaValidatesSamples(t *testing.T) {
+	samples := []string{
+		`{"id":1,"tags":["a"],"owner":{"name":"Ann","site":"https://example.com"}}`,
+		`{"id":2.5,"tags":[],"owner":null,"extra":[1,"x",{"k":true}]}`,
+	}
+	svc := NewConverterService()
+
+	for _, draft := range []string{"draft-07", "2020-12"} {
+		inferred, err := svc.InferJSONSchema(context.Background(), &domain.JSONSchemaInferRequest{Samples: samples, Draft: stringPtr(draft)})
+		if err != nil {
+			t.Fatalf("InferJSONSchema failed: %v", err)
+		}
+		for _, sample := range samples {
+			result, err := svc.ValidateJSON(context.Background(), &domain.JSONValidateRequest{JSON: sample, Schema: string(inferred.Schema), AssertFormat: boolPtr(true)})
+			if err != nil {
+				t.Fatalf("ValidateJSON failed: %v", err)
+			}
+			if !result.Valid {
+				t.Errorf("Expected %s to satisfy the inferred %s schema, got %v", sample, draft, result.Errors)
+			}
+		}
+	}
+
+	_, err := svc.InferJSONSchema(context.Background(), &domain.JSONSchemaInferRequest{Samples: []string{`{}`, `{"a":}`}})
+	var parseErr *DataParseError
+	if !errors.As(err, &parseErr) || !strings.HasPrefix(err.Error(), "sample 2: ") {
+		t.Errorf("Expected a parse error for sample 2, got %v", err)
+	}
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/robfig/cron/v3"
+)
+
+// defaultCronRuns is the number of next runs listed when none is requested
+const defaultCronRuns = 5
+
+// cronSearchYears is how far ahead the cron parser searches for a run
+const cronSearchYears = 5
+
+// cronParser accepts 5 fields, 6 fields with seconds first, and macros
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// cronMacros are the fields each macro stands for, with seconds first
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// cronField describes a schedule field for the human-readable description
+type cronField struct {
+	unit     string
+	min, max int
+	names    []string // value names indexed from min, used in place of numbers
+}
+
+var (
+	cronSecond     = cronField{unit: "second", min: 0, max: 59}
+	cronMinute     = cronField{unit: "minute", min: 0, max: 59}
+	cronHour       = cronField{unit: "hour", min: 0, max: 23}
+	cronDayOfMonth = cronField{unit: "day-of-month", min: 1, max: 31}
+	cronMonth      = cronField{unit: "month", min: 1, max: 12, names: []string{
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	}}
+	cronDayOfWeek = cronField{unit: "day-of-week", min: 0, max: 6, names: []string{
+		"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday",
+	}}
+)
+
+// ParseCron validates a cron expression, describes it and lists its next
+// runs in the requested time zone
+func (s *ConverterService) ParseCron(req *domain.CronParseRequest) (*domain.CronParseResponse, error) {
+	expression := strings.Join(strings.Fields(req.Expression), " ")
+	if strings.HasPrefix(expression, "TZ=") || strings.HasPrefix(expression, "CRON_TZ=") {
+		return nil, fmt.Errorf("set the time zone with the timezone field instead of a TZ prefix")
+	}
+
+	loc, err := loadTimeZone(req.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	schedule, err := cronParser.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+
+	start := time.Now()
+	if req.Start != nil {
+		if start, _, err = parseTimeValue(*req.Start, TimeFormatAuto, "", loc); err != nil {
+			return nil, fmt.Errorf("invalid start: %w", err)
+		}
+	}
+	start = start.In(loc)
+
+	count := defaultCronRuns
+	if req.Count != nil {
+		count = *req.Count
+	}
+
+	response := &domain.CronParseResponse{
+		Expression: expression,
+		Timezone:   loc.String(),
+		NextRuns:   []string{},
+	}
+
+	var next func(time.Time) time.Time
+	switch sched := schedule.(type) {
+	case cron.ConstantDelaySchedule:
+		response.Interval = sched.Delay.String()
+		response.Description = "Every " + sched.Delay.String()
+		next = sched.Next
+	case *cron.SpecSchedule:
+		fields := cronExpressionFields(expression)
+		response.Fields = &domain.CronFields{
+			Second:     fields[0],
+			Minute:     fields[1],
+			Hour:       fields[2],
+			DayOfMonth: fields[3],
+			Month:      fields[4],
+			DayOfWeek:  fields[5],
+		}
+		response.Description = describeCron(fields)
+
+		sched.Location = loc
+		next = newWallClockSchedule(sched, fields).Next
+	default:
+		return nil, fmt.Errorf("unsupported schedule type %T", schedule)
+	}
+
+	// Schedules that never fire, such as February 30, return the zero time
+	for t := start; len(response.NextRuns) < count; {
+		if t = next(t); t.IsZero() || t.After(maxSupportedTime) {
+			break
+		}
+		response.NextRuns = append(response.NextRuns, t.Format(time.RFC3339))
+	}
+
+	return response, nil
+}
+
+// cronExpressionFields returns the six fields of a parsed expression, with
+// macros expanded and a zero seconds field added to 5-field expressions
+func cronExpressionFields(expression string) []string {
+	if macro, ok := cronMacros[expression]; ok {
+		expression = macro
+	}
+	fields := strings.Fields(expression)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	return fields
+}
+
+// describeCron writes a human-readable description of six cron fields in
+// the style of crontab.guru, e.g. "At minute 0 past every 2nd hour on Monday."
+func describeCron(fields []string) string {
+	second, minute, hour := fields[0], fields[1], fields[2]
+	dayOfMonth, month, dayOfWeek := fields[3], fields[4], fields[5]
+
+	var sb strings.Builder
+	sb.WriteString("At ")
+
+	s, sOK := strconv.Atoi(second)
+	m, mOK := strconv.Atoi(minute)
+	h, hOK := strconv.Atoi(hour)
+	if sOK == nil && mOK == nil && hOK == nil {
+		fmt.Fprintf(&sb, "%02d:%02d", h, m)
+		if s != 0 {
+			fmt.Fprintf(&sb, ":%02d", s)
+		}
+	} else {
+		var clauses []string
+		if second != "0" {
+			clauses = append(clauses, describeCronField(cronSecond, second))
+		}
+		// Every minute goes without saying when seconds are described
+		if !isCronWildcard(minute) || second == "0" {
+			clauses = append(clauses, describeCronField(cronMinute, minute))
+		}
+		if !isCronWildcard(hour) {
+			clauses = append(clauses, describeCronField(cronHour, hour))
+		}
+		sb.WriteString(strings.Join(clauses, " past "))
+	}
+
+	// Cron runs on either day when both days are restricted
+	if !isCronWildcard(dayOfMonth) {
+		sb.WriteString(" on " + describeCronField(cronDayOfMonth, dayOfMonth))
+	}
+	if !isCronWildcard(dayOfWeek) {
+		if !isCronWildcard(dayOfMonth) {
+			sb.WriteString(" and")
+		}
+		sb.WriteString(" on " + describeCronField(cronDayOfWeek, dayOfWeek))
+	}
+	if !isCronWildcard(month) {
+		sb.WriteString(" in " + describeCronField(cronMonth, month))
+	}
+
+	sb.WriteString(".")
+	return sb.String()
+}
+
+// isCronWildcard reports whether a field matches every value
+func isCronWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// describeCronField describes one field, e.g. "every 15th minute" or
+// "Monday, Wednesday and Friday"
+func describeCronField(f cronField, field string) string {
+	parts := strings.Split(field, ",")
+	if len(parts) == 1 {
+		lo, hi, step, wildcard := f.parseRange(field)
+		switch {
+		case wildcard && step <= 1:
+			return "every " + f.unit
+		case wildcard:
+			return fmt.Sprintf("every %s %s", ordinal(step), f.unit)
+		case lo == hi:
+			if f.names != nil {
+				return f.value(lo)
+			}
+			return f.unit + " " + f.value(lo)
+		case step <= 1:
+			return fmt.Sprintf("every %s from %s through %s", f.unit, f.value(lo), f.value(hi))
+		default:
+			return fmt.Sprintf("every %s %s from %s through %s", ordinal(step), f.unit, f.value(lo), f.value(hi))
+		}
+	}
+
+	items := make([]string, len(parts))
+	for i, part := range parts {
+		lo, hi, step, _ := f.parseRange(part)
+		switch {
+		case lo == hi:
+			items[i] = f.value(lo)
+		case step <= 1:
+			items[i] = f.value(lo) + " through " + f.value(hi)
+		default:
+			items[i] = fmt.Sprintf("every %s from %s through %s", ordinal(step), f.value(lo), f.value(hi))
+		}
+	}
+	if f.names != nil {
+		return joinWithAnd(items)
+	}
+	return f.unit + " " + joinWithAnd(items)
+}
+
+// parseRange reads a field item validated by the cron parser: *, a value,
+// a range, or either with a step
+func (f cronField) parseRange(item string) (lo, hi, step int, wildcard bool) {
+	item, stepText, hasStep := strings.Cut(item, "/")
+	step = 1
+	if hasStep {
+		step, _ = strconv.Atoi(stepText)
+	}
+
+	if isCronWildcard(item) {
+		return f.min, f.max, step, true
+	}
+	loText, hiText, isRange := strings.Cut(item, "-")
+	lo = f.parseValue(loText)
+	switch {
+	case isRange:
+		hi = f.parseValue(hiText)
+	case hasStep:
+		// A start with a step runs to the end of the field
+		hi = f.max
+	default:
+		hi = lo
+	}
+	return lo, hi, step, false
+}
+
+// parseValue reads a number or a three-letter month or weekday name
+func (f cronField) parseValue(text string) int {
+	if n, err := strconv.Atoi(text); err == nil {
+		return n
+	}
+	for i, name := range f.names {
+		if strings.EqualFold(name[:3], text) {
+			return f.min + i
+		}
+	}
+	return f.min
+}
+
+// value writes a field value, by name where the field has names
+func (f cronField) value(n int) string {
+	if f.names != nil && n >= f.min && n-f.min < len(f.names) {
+		return f.names[n-f.min]
+	}
+	return strconv.Itoa(n)
+}
+
+// ordinal writes n as an English ordinal such as 2nd or 11th
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// joinWithAnd joins items as "a, b and c"
+func joinWithAnd(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// wallClockSchedule follows the Vixie cron rules for daylight saving time
+// changes. Jobs at a fixed time of day, with no wildcard in the second,
+// minute or hour field, run once at the change when their time is skipped
+// and only once when their time is repeated. Other jobs run at every real
+// occurrence of their times.
+type wallClockSchedule struct {
+	spec      *cron.SpecSchedule
+	fixedTime bool
+}
+
+// newWallClockSchedule wraps a parsed schedule with its six fields
+func newWallClockSchedule(spec *cron.SpecSchedule, fields []string) *wallClockSchedule {
+	fixedTime := true
+	for _, field := range fields[:3] {
+		if strings.HasPrefix(field, "*") || strings.HasPrefix(field, "?") {
+			fixedTime = false
+		}
+	}
+	return &wallClockSchedule{spec: spec, fixedTime: fixedTime}
+}
+
+// Next returns the first run after t, or the zero time if there is none
+// within the cron parser's search
+func (s *wallClockSchedule) Next(t time.Time) time.Time {
+	for {
+		next := s.spec.Next(t)
+		if !s.fixedTime {
+			return next
+		}
+
+		// Skipped runs happen at the first change to summer time before next
+		if change, ok := s.skippedRun(t, next); ok {
+			return change
+		}
+		if next.IsZero() || !isRepeatedWallClock(next) {
+			return next
+		}
+		t = next
+	}
+}
+
+// skippedRun finds a forward change after t and before next whose skipped
+// wall clock times include a run of the schedule
+func (s *wallClockSchedule) skippedRun(t, next time.Time) (time.Time, bool) {
+	if next.IsZero() {
+		next = t.AddDate(cronSearchYears, 0, 0)
+	}
+	for change := t; ; {
+		_, end := change.ZoneBounds()
+		if end.IsZero() || !end.Before(next) {
+			return time.Time{}, false
+		}
+		change = end
+
+		_, before := change.Add(-time.Nanosecond).Zone()
+		_, after := change.Zone()
+		if after <= before {
+			continue
+		}
+
+		// Search the skipped times as wall clock times of the old offset
+		spec := *s.spec
+		spec.Location = time.FixedZone("", before)
+		skipped := time.Duration(after-before) * time.Second
+		if run := spec.Next(change.Add(-time.Second)); !run.IsZero() && run.Before(change.Add(skipped)) {
+			return change, true
+		}
+	}
+}
+
+// isRepeatedWallClock reports whether the wall clock time of t already
+// occurred under an earlier offset, after a change back from summer time
+func isRepeatedWallClock(t time.Time) bool {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return false
+	}
+	_, before := start.Add(-time.Nanosecond).Zone()
+	_, after := t.Zone()
+	return before > after && t.Sub(start) < time.Duration(before-after)*time.Second
+}
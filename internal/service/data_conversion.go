@@ -0,0 +1,750 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Structured data formats
+const (
+	DataFormatJSON = "json"
+	DataFormatYAML = "yaml"
+	DataFormatTOML = "toml"
+	DataFormatXML  = "xml"
+	DataFormatCSV  = "csv"
+	DataFormatTSV  = "tsv"
+	DataFormatINI  = "ini"
+	DataFormatEnv  = "env"
+	DataFormatHCL  = "hcl"
+
+	// JSON dialects read by the JSON formatter. JSONC adds comments and
+	// trailing commas, and JSON5 also single-quoted strings, unquoted keys
+	// and hexadecimal, signed and dotted numbers.
+	DataFormatJSONC = "jsonc"
+	DataFormatJSON5 = "json5"
+)
+
+// dataFormatNames are the display names of the formats in errors
+var dataFormatNames = map[string]string{
+	DataFormatJSON:    "JSON",
+	DataFormatYAML:    "YAML",
+	DataFormatTOML:    "TOML",
+	DataFormatXML:     "XML",
+	DataFormatCSV:     "CSV",
+	DataFormatTSV:     "TSV",
+	DataFormatINI:     "INI",
+	DataFormatEnv:     ".env",
+	DataFormatHCL:     "HCL",
+	DataFormatJSONC:   "JSONC",
+	DataFormatJSON5:   "JSON5",
+	JSONQueryJSONPath: "JSONPath",
+	JSONQueryJQ:       "jq",
+}
+
+// DataParseError reports where structured data failed to parse. Line and
+// Column are 1-based and zero when unknown.
+type DataParseError struct {
+	Format  string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *DataParseError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("invalid %s at line %d, column %d: %s", dataFormatNames[e.Format], e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("invalid %s at line %d: %s", dataFormatNames[e.Format], e.Line, e.Message)
+	default:
+		return fmt.Sprintf("invalid %s: %s", dataFormatNames[e.Format], e.Message)
+	}
+}
+
+// offsetPosition converts a byte offset in content to a 1-based line and column
+func offsetPosition(content string, offset int64) (line, column int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	before := content[:offset]
+	line = strings.Count(before, "\n") + 1
+	column = len([]rune(before[strings.LastIndexByte(before, '\n')+1:])) + 1
+	return line, column
+}
+
+// orderedMap is an object that keeps the order of its keys. Decoded data is
+// built from orderedMap, []any, string, bool, nil and the number types
+// int64, uint64, float64 and json.Number.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]any)}
+}
+
+// set adds or replaces a key, keeping the position of an existing key
+func (m *orderedMap) set(key string, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// get returns the value of a key
+func (m *orderedMap) get(key string) (any, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// toPlainValue converts ordered data to plain maps and number literals to
+// int64 or float64, for encoders that sort keys anyway
+func toPlainValue(value any) any {
+	switch v := value.(type) {
+	case *orderedMap:
+		m := make(map[string]any, len(v.keys))
+		for _, key := range v.keys {
+			m[key] = toPlainValue(v.values[key])
+		}
+		return m
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = toPlainValue(item)
+		}
+		return items
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return value
+	}
+}
+
+// formatScalar writes a scalar as text for formats without types
+func formatScalar(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		// Nested values are written as compact JSON
+		var buf bytes.Buffer
+		if err := writeJSON(&buf, value, "", 0); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
+
+// flatten writes nested objects and arrays as keys joined by sep, such as
+// a.b and a.0, and calls set for each scalar
+func flatten(prefix, sep string, value any, set func(key string, value any)) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + sep + key
+	}
+
+	switch v := value.(type) {
+	case *orderedMap:
+		if len(v.keys) == 0 && prefix != "" {
+			set(prefix, "")
+		}
+		for _, key := range v.keys {
+			flatten(join(key), sep, v.values[key], set)
+		}
+	case []any:
+		if len(v) == 0 && prefix != "" {
+			set(prefix, "")
+		}
+		for i, item := range v {
+			flatten(join(strconv.Itoa(i)), sep, item, set)
+		}
+	default:
+		set(prefix, value)
+	}
+}
+
+// convertData reads content in one format and writes it in another. Without
+// a source format, JSON and XML are recognized and anything else read as
+// YAML. It returns the result and the format the content was read as.
+func convertData(content, from, to string, csvHeader *bool) (string, string, error) {
+	if from == "" {
+		from = detectDataFormat(content)
+	}
+
+	var value any
+	var documents bool
+	var err error
+	switch from {
+	case DataFormatJSON:
+		value, err = decodeJSON(content)
+	case DataFormatYAML:
+		value, documents, err = decodeYAML(content)
+	case DataFormatTOML:
+		value, err = decodeTOML(content)
+	case DataFormatXML:
+		value, err = decodeXML(content)
+	case DataFormatCSV, DataFormatTSV:
+		value, err = decodeCSV(content, from, csvHeader)
+	case DataFormatINI:
+		value, err = decodeINI(content)
+	case DataFormatEnv:
+		value, err = decodeEnv(content)
+	case DataFormatHCL:
+		value, err = decodeHCL(content)
+	default:
+		return "", "", fmt.Errorf("unsupported source format: %s", from)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	var result string
+	switch to {
+	case DataFormatJSON:
+		var buf bytes.Buffer
+		err = writeJSON(&buf, value, "  ", 0)
+		result = buf.String()
+	case DataFormatYAML:
+		result, err = encodeYAML(value, documents)
+	case DataFormatTOML:
+		result, err = encodeTOML(value)
+	case DataFormatXML:
+		result, err = encodeXML(value)
+	case DataFormatCSV, DataFormatTSV:
+		result, err = encodeCSV(value, to)
+	case DataFormatINI:
+		result, err = encodeINI(value)
+	case DataFormatEnv:
+		result, err = encodeEnv(value)
+	case DataFormatHCL:
+		result, err = encodeHCL(value)
+	default:
+		return "", "", fmt.Errorf("unsupported conversion target: %s", to)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to convert to %s: %w", dataFormatNames[to], err)
+	}
+
+	return result, from, nil
+}
+
+// detectDataFormat recognizes JSON and XML, defaulting to YAML
+func detectDataFormat(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "<") {
+		return DataFormatXML
+	}
+	if json.Valid([]byte(trimmed)) {
+		return DataFormatJSON
+	}
+	return DataFormatYAML
+}
+
+// decodeJSON reads a single JSON value, keeping key order and number literals
+func decodeJSON(content string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+
+	jsonError := func(err error) error {
+		var syntaxErr *json.SyntaxError
+		offset := dec.InputOffset()
+		if errors.As(err, &syntaxErr) && syntaxErr.Offset > 0 {
+			// The offset is just past the offending character
+			offset = syntaxErr.Offset - 1
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			offset = int64(len(content))
+			err = errors.New("unexpected end of input")
+		}
+		line, column := offsetPosition(content, offset)
+		return &DataParseError{Format: DataFormatJSON, Line: line, Column: column, Message: strings.TrimPrefix(err.Error(), "json: ")}
+	}
+
+	var decode func() (any, error)
+	decode = func() (any, error) {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, jsonError(err)
+		}
+
+		switch t := token.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				m := newOrderedMap()
+				for dec.More() {
+					keyToken, err := dec.Token()
+					if err != nil {
+						return nil, jsonError(err)
+					}
+					key, _ := keyToken.(string)
+					value, err := decode()
+					if err != nil {
+						return nil, err
+					}
+					m.set(key, value)
+				}
+				if _, err := dec.Token(); err != nil {
+					return nil, jsonError(err)
+				}
+				return m, nil
+			case '[':
+				items := []any{}
+				for dec.More() {
+					value, err := decode()
+					if err != nil {
+						return nil, err
+					}
+					items = append(items, value)
+				}
+				if _, err := dec.Token(); err != nil {
+					return nil, jsonError(err)
+				}
+				return items, nil
+			}
+			return nil, jsonError(fmt.Errorf("unexpected %q", t))
+		default:
+			return t, nil
+		}
+	}
+
+	value, err := decode()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		line, column := offsetPosition(content, dec.InputOffset())
+		return nil, &DataParseError{Format: DataFormatJSON, Line: line, Column: column, Message: "unexpected data after the top-level value"}
+	}
+	return value, nil
+}
+
+// writeJSON writes a value as JSON, indented by indent per level or compact
+// when indent is empty. HTML characters are not escaped.
+func writeJSON(buf *bytes.Buffer, value any, indent string, level int) error {
+	newline := func(level int) {
+		if indent != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(strings.Repeat(indent, level))
+		}
+	}
+
+	switch v := value.(type) {
+	case *orderedMap:
+		if len(v.keys) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(level + 1)
+			writeJSONString(buf, key)
+			buf.WriteByte(':')
+			if indent != "" {
+				buf.WriteByte(' ')
+			}
+			if err := writeJSON(buf, v.values[key], indent, level+1); err != nil {
+				return err
+			}
+		}
+		newline(level)
+		buf.WriteByte('}')
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(level + 1)
+			if err := writeJSON(buf, item, indent, level+1); err != nil {
+				return err
+			}
+		}
+		newline(level)
+		buf.WriteByte(']')
+	case string:
+		writeJSONString(buf, v)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("JSON cannot represent %v", v)
+		}
+		b, _ := json.Marshal(v)
+		buf.Write(b)
+	case nil, bool, int64, uint64, json.Number:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	default:
+		return fmt.Errorf("unsupported value of type %T", value)
+	}
+	return nil
+}
+
+// writeJSONString writes a JSON string without escaping HTML characters
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1) // Encode adds a newline
+}
+
+// yamlLinePattern finds the line number in yaml.v3 error messages
+var yamlLinePattern = regexp.MustCompile(`line (\d+): `)
+
+// maxYAMLNodes caps the nodes produced from a YAML stream once aliases are
+// expanded. Nested aliases grow exponentially, and a 1 MiB document without
+// them stays well below the cap.
+const maxYAMLNodes = 1 << 20
+
+// decodeYAML reads one or more YAML documents, keeping key order. Several
+// documents are returned as an array and reported as documents.
+func decodeYAML(content string) (any, bool, error) {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+
+	var documents []any
+	nodes := 0
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			parseErr := &DataParseError{Format: DataFormatYAML, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+			if m := yamlLinePattern.FindStringSubmatch(parseErr.Message); m != nil {
+				parseErr.Line, _ = strconv.Atoi(m[1])
+				parseErr.Message = strings.Replace(parseErr.Message, m[0], "", 1)
+			}
+			return nil, false, parseErr
+		}
+		value, err := yamlNodeValue(&node, &nodes)
+		if err != nil {
+			return nil, false, err
+		}
+		documents = append(documents, value)
+	}
+
+	switch len(documents) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return documents[0], false, nil
+	default:
+		return documents, true, nil
+	}
+}
+
+// yamlNodeValue converts a YAML node, resolving aliases and merge keys. nodes
+// counts the nodes converted so far, across documents, against maxYAMLNodes.
+func yamlNodeValue(node *yaml.Node, nodes *int) (any, error) {
+	nodeError := func(message string) error {
+		return &DataParseError{Format: DataFormatYAML, Line: node.Line, Column: node.Column, Message: message}
+	}
+
+	// Security: Aliases are expanded here rather than by yaml.v3, which
+	// skips its own check for excessive aliasing when decoding into nodes
+	*nodes++
+	if *nodes > maxYAMLNodes {
+		return nil, nodeError(fmt.Sprintf("document expands to more than %d nodes, check for nested aliases", maxYAMLNodes))
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(node.Content[0], nodes)
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias, nodes)
+	case yaml.SequenceNode:
+		items := make([]any, len(node.Content))
+		for i, child := range node.Content {
+			value, err := yamlNodeValue(child, nodes)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = value
+		}
+		return items, nil
+	case yaml.MappingNode:
+		m := newOrderedMap()
+		var merged []*orderedMap
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			value, err := yamlNodeValue(valueNode, nodes)
+			if err != nil {
+				return nil, err
+			}
+			if keyNode.Tag == "!!merge" {
+				// Merged mappings fill in keys the mapping doesn't set itself
+				sources := []any{value}
+				if list, ok := value.([]any); ok {
+					sources = list
+				}
+				for _, source := range sources {
+					sourceMap, ok := source.(*orderedMap)
+					if !ok {
+						return nil, nodeError("merge key requires a mapping or a list of mappings")
+					}
+					merged = append(merged, sourceMap)
+				}
+				continue
+			}
+			key, err := yamlNodeValue(keyNode, nodes)
+			if err != nil {
+				return nil, err
+			}
+			keyText, err := formatScalar(key)
+			if err != nil {
+				return nil, err
+			}
+			m.set(keyText, value)
+		}
+		for _, source := range merged {
+			for _, key := range source.keys {
+				if _, ok := m.get(key); !ok {
+					m.set(key, source.values[key])
+				}
+			}
+		}
+		return m, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!bool":
+			var b bool
+			if err := node.Decode(&b); err != nil {
+				return nil, nodeError(err.Error())
+			}
+			return b, nil
+		case "!!int":
+			var n int64
+			if err := node.Decode(&n); err == nil {
+				return n, nil
+			}
+			var u uint64
+			if err := node.Decode(&u); err == nil {
+				return u, nil
+			}
+			return nil, nodeError(fmt.Sprintf("integer %s is out of range", node.Value))
+		case "!!float":
+			var f float64
+			if err := node.Decode(&f); err != nil {
+				return nil, nodeError(err.Error())
+			}
+			return f, nil
+		default:
+			// Strings, timestamps and binary keep their text
+			return node.Value, nil
+		}
+	}
+	return nil, nodeError("unsupported YAML node")
+}
+
+// encodeYAML writes a value as YAML, or each array item as a document when
+// the value was read from several documents
+func encodeYAML(value any, documents bool) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+
+	items := []any{value}
+	if documents {
+		items = value.([]any)
+	}
+	for _, item := range items {
+		node, err := yamlNode(item)
+		if err != nil {
+			return "", err
+		}
+		if err := enc.Encode(node); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// yamlNode builds a YAML node, keeping key order
+func yamlNode(value any) (*yaml.Node, error) {
+	scalar := func(tag, text string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: text}
+	}
+
+	switch v := value.(type) {
+	case *orderedMap:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range v.keys {
+			child, err := yamlNode(v.values[key])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, scalar("!!str", key), child)
+		}
+		return node, nil
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			child, err := yamlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case nil:
+		return scalar("!!null", "null"), nil
+	case string:
+		return scalar("!!str", v), nil
+	case bool:
+		return scalar("!!bool", strconv.FormatBool(v)), nil
+	case int64, uint64:
+		text, _ := formatScalar(v)
+		return scalar("!!int", text), nil
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return scalar("!!float", ".nan"), nil
+		case math.IsInf(v, 1):
+			return scalar("!!float", ".inf"), nil
+		case math.IsInf(v, -1):
+			return scalar("!!float", "-.inf"), nil
+		}
+		return scalar("!!float", strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return scalar("!!float", v.String()), nil
+		}
+		return scalar("!!int", v.String()), nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// decodeTOML reads a TOML document. TOML tables don't keep key order, so
+// keys are sorted.
+func decodeTOML(content string) (any, error) {
+	var data map[string]any
+	if err := toml.Unmarshal([]byte(content), &data); err != nil {
+		parseErr := &DataParseError{Format: DataFormatTOML, Message: strings.TrimPrefix(err.Error(), "toml: ")}
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			parseErr.Line, parseErr.Column = decodeErr.Position()
+		}
+		return nil, parseErr
+	}
+	return fromTOMLValue(data), nil
+}
+
+// fromTOMLValue converts decoded TOML, writing dates and times as text
+func fromTOMLValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := newOrderedMap()
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			m.set(key, fromTOMLValue(v[key]))
+		}
+		return m
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = fromTOMLValue(item)
+		}
+		return items
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		// Local dates, times and date-times
+		return v.String()
+	default:
+		return value
+	}
+}
+
+// encodeTOML writes an object as a TOML document, with keys sorted
+func encodeTOML(value any) (string, error) {
+	if _, ok := value.(*orderedMap); !ok {
+		return "", fmt.Errorf("TOML requires an object at the top level")
+	}
+	if path, ok := findNull(value, ""); ok {
+		return "", fmt.Errorf("TOML cannot represent null at %s", path)
+	}
+
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.SetIndentTables(true)
+	if err := enc.Encode(toPlainValue(value)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// findNull returns the path of the first null in a value
+func findNull(value any, path string) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		if path == "" {
+			path = "the top level"
+		}
+		return path, true
+	case *orderedMap:
+		for _, key := range v.keys {
+			if found, ok := findNull(v.values[key], strings.TrimPrefix(path+"."+key, ".")); ok {
+				return found, true
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if found, ok := findNull(item, fmt.Sprintf("%s[%d]", path, i)); ok {
+				return found, true
+			}
+		}
+	}
+	return "", false
+}
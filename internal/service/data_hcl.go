@@ -0,0 +1,263 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// HCL mapping: blocks become objects keyed by their type and then each
+// label, as in Terraform's JSON syntax, and repeated blocks become arrays.
+// Attributes must be literal values; variables and function calls are
+// rejected.
+
+// decodeHCL reads an HCL configuration into an object, in source order
+func decodeHCL(content string) (any, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(content), "input.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, hclError(diags)
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, &DataParseError{Format: DataFormatHCL, Message: "unsupported HCL body"}
+	}
+	return hclBodyValue(body)
+}
+
+// hclError reports the first error diagnostic with its position
+func hclError(diags hcl.Diagnostics) error {
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		parseErr := &DataParseError{Format: DataFormatHCL, Message: diag.Summary}
+		if diag.Detail != "" {
+			parseErr.Message += ": " + diag.Detail
+		}
+		if diag.Subject != nil {
+			parseErr.Line, parseErr.Column = diag.Subject.Start.Line, diag.Subject.Start.Column
+		}
+		return parseErr
+	}
+	return &DataParseError{Format: DataFormatHCL, Message: diags.Error()}
+}
+
+// hclBodyValue converts the attributes and blocks of a body in source order
+func hclBodyValue(body *hclsyntax.Body) (*orderedMap, error) {
+	type item struct {
+		start int
+		attr  *hclsyntax.Attribute
+		block *hclsyntax.Block
+	}
+	items := make([]item, 0, len(body.Attributes)+len(body.Blocks))
+	for _, attr := range body.Attributes {
+		items = append(items, item{start: attr.SrcRange.Start.Byte, attr: attr})
+	}
+	for _, block := range body.Blocks {
+		items = append(items, item{start: block.TypeRange.Start.Byte, block: block})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].start < items[j].start })
+
+	m := newOrderedMap()
+	for _, it := range items {
+		if it.attr != nil {
+			value, diags := it.attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				return nil, hclError(diags)
+			}
+			converted, err := fromCtyValue(value)
+			if err != nil {
+				return nil, &DataParseError{Format: DataFormatHCL, Line: it.attr.SrcRange.Start.Line, Column: it.attr.SrcRange.Start.Column, Message: err.Error()}
+			}
+			m.set(it.attr.Name, converted)
+			continue
+		}
+
+		value, err := hclBodyValue(it.block.Body)
+		if err != nil {
+			return nil, err
+		}
+		// Nest the block body under its type and all but the last label
+		path := append([]string{it.block.Type}, it.block.Labels...)
+		parent := m
+		for _, key := range path[:len(path)-1] {
+			child, ok := parent.get(key)
+			childMap, isMap := child.(*orderedMap)
+			if !ok || !isMap {
+				childMap = newOrderedMap()
+				parent.set(key, childMap)
+			}
+			parent = childMap
+		}
+		key := path[len(path)-1]
+		switch existing, ok := parent.get(key); {
+		case !ok:
+			parent.set(key, value)
+		case isObjectList(existing):
+			parent.set(key, append(existing.([]any), value))
+		default:
+			parent.set(key, []any{existing, value})
+		}
+	}
+	return m, nil
+}
+
+// isObjectList reports whether a value is an array of objects, as repeated blocks are
+func isObjectList(value any) bool {
+	items, ok := value.([]any)
+	if !ok || len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if _, isObject := item.(*orderedMap); !isObject {
+			return false
+		}
+	}
+	return true
+}
+
+// fromCtyValue converts an evaluated HCL value
+func fromCtyValue(value cty.Value) (any, error) {
+	if !value.IsKnown() {
+		return nil, fmt.Errorf("value is not known")
+	}
+	if value.IsNull() {
+		return nil, nil
+	}
+
+	t := value.Type()
+	switch {
+	case t == cty.String:
+		return value.AsString(), nil
+	case t == cty.Bool:
+		return value.True(), nil
+	case t == cty.Number:
+		return json.Number(value.AsBigFloat().Text('f', -1)), nil
+	case t.IsListType() || t.IsSetType() || t.IsTupleType():
+		items := make([]any, 0, value.LengthInt())
+		for it := value.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			item, err := fromCtyValue(element)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case t.IsMapType() || t.IsObjectType():
+		m := newOrderedMap()
+		for it := value.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			item, err := fromCtyValue(element)
+			if err != nil {
+				return nil, err
+			}
+			m.set(key.AsString(), item)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unsupported HCL value of type %s", t.FriendlyName())
+}
+
+// encodeHCL writes an object as an HCL configuration. Objects become blocks,
+// arrays of objects repeated blocks and everything else attributes.
+func encodeHCL(value any) (string, error) {
+	root, ok := value.(*orderedMap)
+	if !ok {
+		return "", fmt.Errorf("HCL requires an object at the top level")
+	}
+
+	file := hclwrite.NewEmptyFile()
+	if err := writeHCLBody(file.Body(), root); err != nil {
+		return "", err
+	}
+	return string(hclwrite.Format(file.Bytes())), nil
+}
+
+// writeHCLBody writes the keys of an object as blocks and attributes
+func writeHCLBody(body *hclwrite.Body, m *orderedMap) error {
+	for _, key := range m.keys {
+		if !hclsyntax.ValidIdentifier(key) {
+			return fmt.Errorf("%q is not a valid HCL identifier", key)
+		}
+
+		value := m.values[key]
+		var blocks []any
+		switch v := value.(type) {
+		case *orderedMap:
+			blocks = []any{v}
+		case []any:
+			if isObjectList(v) {
+				blocks = v
+			}
+		}
+
+		if blocks != nil {
+			for _, block := range blocks {
+				if err := writeHCLBody(body.AppendNewBlock(key, nil).Body(), block.(*orderedMap)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		tokens, err := hclTokens(value)
+		if err != nil {
+			return err
+		}
+		body.SetAttributeRaw(key, tokens)
+	}
+	return nil
+}
+
+// hclTokens writes a value as an HCL expression, keeping object key order
+func hclTokens(value any) (hclwrite.Tokens, error) {
+	switch v := value.(type) {
+	case *orderedMap:
+		attrs := make([]hclwrite.ObjectAttrTokens, len(v.keys))
+		for i, key := range v.keys {
+			valueTokens, err := hclTokens(v.values[key])
+			if err != nil {
+				return nil, err
+			}
+			nameTokens := hclwrite.TokensForValue(cty.StringVal(key))
+			if hclsyntax.ValidIdentifier(key) {
+				nameTokens = hclwrite.TokensForIdentifier(key)
+			}
+			attrs[i] = hclwrite.ObjectAttrTokens{Name: nameTokens, Value: valueTokens}
+		}
+		return hclwrite.TokensForObject(attrs), nil
+	case []any:
+		items := make([]hclwrite.Tokens, len(v))
+		for i, item := range v {
+			tokens, err := hclTokens(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = tokens
+		}
+		return hclwrite.TokensForTuple(items), nil
+	case nil:
+		return hclwrite.TokensForValue(cty.NullVal(cty.DynamicPseudoType)), nil
+	case string:
+		return hclwrite.TokensForValue(cty.StringVal(v)), nil
+	case bool:
+		return hclwrite.TokensForValue(cty.BoolVal(v)), nil
+	default:
+		text, err := formatScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		f, _, err := big.ParseFloat(text, 10, 512, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("HCL cannot represent the number %s", text)
+		}
+		return hclwrite.TokensForValue(cty.NumberVal(f)), nil
+	}
+}
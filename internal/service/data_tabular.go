@@ -0,0 +1,421 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Key separators of flattened nested values
+const (
+	flatKeySeparator = "."
+	envKeySeparator  = "_"
+)
+
+var (
+	numberPattern  = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+	envKeyPattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	envSafePattern = regexp.MustCompile(`^[A-Za-z0-9_./:@+,-]*$`)
+)
+
+// decodeCSV reads CSV or TSV rows, as objects keyed by the header row or as
+// arrays of cells without one. Values stay strings.
+func decodeCSV(content, format string, header *bool) (any, error) {
+	r := csv.NewReader(strings.NewReader(content))
+	if format == DataFormatTSV {
+		r.Comma = '\t'
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		parseErr := &DataParseError{Format: format, Message: err.Error()}
+		var csvErr *csv.ParseError
+		if errors.As(err, &csvErr) {
+			parseErr.Line, parseErr.Column, parseErr.Message = csvErr.Line, csvErr.Column, csvErr.Err.Error()
+		}
+		return nil, parseErr
+	}
+
+	hasHeader := inferCSVHeader(records)
+	if header != nil {
+		hasHeader = *header
+	}
+
+	rows := make([]any, 0, len(records))
+	if !hasHeader || len(records) == 0 {
+		for _, record := range records {
+			row := make([]any, len(record))
+			for i, cell := range record {
+				row[i] = cell
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	for _, record := range records[1:] {
+		row := newOrderedMap()
+		for i, name := range records[0] {
+			row.set(name, record[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// inferCSVHeader treats the first row as a header when it has more rows
+// after it and its cells are unique, non-empty and not numbers or booleans
+func inferCSVHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return false
+	}
+	seen := make(map[string]bool, len(records[0]))
+	for _, cell := range records[0] {
+		cell = strings.TrimSpace(cell)
+		if cell == "" || seen[cell] || numberPattern.MatchString(cell) || cell == "true" || cell == "false" {
+			return false
+		}
+		seen[cell] = true
+	}
+	return true
+}
+
+// encodeCSV writes an array of objects as rows under a header of their
+// flattened keys, or an array of arrays as rows without a header
+func encodeCSV(value any, format string) (string, error) {
+	var rows []any
+	switch v := value.(type) {
+	case []any:
+		rows = v
+	case *orderedMap:
+		rows = []any{v}
+	default:
+		return "", fmt.Errorf("%s requires an array or an object", dataFormatNames[format])
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if format == DataFormatTSV {
+		w.Comma = '\t'
+	}
+
+	objects := 0
+	for _, row := range rows {
+		if _, ok := row.(*orderedMap); ok {
+			objects++
+		}
+	}
+
+	switch objects {
+	case len(rows):
+		// Columns are the union of the flattened keys in order of appearance
+		header := newOrderedMap()
+		flatRows := make([]*orderedMap, len(rows))
+		for i, row := range rows {
+			flatRows[i] = newOrderedMap()
+			flatten("", flatKeySeparator, row, func(key string, value any) {
+				flatRows[i].set(key, value)
+				header.set(key, nil)
+			})
+		}
+		if len(header.keys) > 0 {
+			_ = w.Write(header.keys)
+		}
+		for _, row := range flatRows {
+			record := make([]string, len(header.keys))
+			for i, key := range header.keys {
+				var err error
+				if record[i], err = formatScalar(row.values[key]); err != nil {
+					return "", err
+				}
+			}
+			_ = w.Write(record)
+		}
+	case 0:
+		for _, row := range rows {
+			cells, ok := row.([]any)
+			if !ok {
+				cells = []any{row}
+			}
+			record := make([]string, len(cells))
+			for i, cell := range cells {
+				var err error
+				if record[i], err = formatScalar(cell); err != nil {
+					return "", err
+				}
+			}
+			_ = w.Write(record)
+		}
+	default:
+		return "", fmt.Errorf("%s rows must be all objects or all arrays and values", dataFormatNames[format])
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// decodeINI reads INI keys, before any section at the top level and in
+// sections as objects. Values stay strings; double-quoted values are unescaped.
+func decodeINI(content string) (any, error) {
+	root := newOrderedMap()
+	current := root
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(nil, max(len(content)+1, bufio.MaxScanTokenSize))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		column := len(text) - len(strings.TrimLeft(text, " \t")) + 1
+		iniError := func(message string) error {
+			return &DataParseError{Format: DataFormatINI, Line: line, Column: column, Message: message}
+		}
+
+		switch {
+		case trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#':
+			continue
+		case trimmed[0] == '[':
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, iniError("section header is missing ]")
+			}
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if name == "" {
+				return nil, iniError("empty section name")
+			}
+			// Repeated sections are merged
+			existing, ok := root.get(name)
+			if section, isSection := existing.(*orderedMap); ok && isSection {
+				current = section
+			} else {
+				current = newOrderedMap()
+				root.set(name, current)
+			}
+		default:
+			i := strings.IndexAny(trimmed, "=:")
+			if i <= 0 {
+				return nil, iniError("expected key = value")
+			}
+			value, err := unquoteValue(strings.TrimSpace(trimmed[i+1:]))
+			if err != nil {
+				return nil, iniError(err.Error())
+			}
+			current.set(strings.TrimSpace(trimmed[:i]), value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &DataParseError{Format: DataFormatINI, Message: err.Error()}
+	}
+	return root, nil
+}
+
+// unquoteValue strips matching quotes, unescaping double-quoted values
+func unquoteValue(value string) (string, error) {
+	if len(value) >= 2 {
+		switch {
+		case value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return "", fmt.Errorf("invalid quoted value %s", value)
+			}
+			return unquoted, nil
+		case value[0] == '\'' && value[len(value)-1] == '\'':
+			return value[1 : len(value)-1], nil
+		}
+	}
+	return value, nil
+}
+
+// encodeINI writes an object as INI: scalars at the top, then each object
+// as a section with nested values flattened to dotted keys
+func encodeINI(value any) (string, error) {
+	root, ok := value.(*orderedMap)
+	if !ok {
+		return "", fmt.Errorf("INI requires an object at the top level")
+	}
+
+	var buf bytes.Buffer
+	writeKeys := func(prefix string, value any) error {
+		var err error
+		flatten(prefix, flatKeySeparator, value, func(key string, value any) {
+			if err != nil {
+				return
+			}
+			var text string
+			if text, err = formatScalar(value); err != nil {
+				return
+			}
+			buf.WriteString(key + " =")
+			if text != "" {
+				buf.WriteString(" " + quoteINIValue(text))
+			}
+			buf.WriteByte('\n')
+		})
+		return err
+	}
+
+	var sections []string
+	for _, key := range root.keys {
+		if _, isSection := root.values[key].(*orderedMap); isSection {
+			sections = append(sections, key)
+			continue
+		}
+		if err := writeKeys(key, root.values[key]); err != nil {
+			return "", err
+		}
+	}
+
+	for _, name := range sections {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("[" + name + "]\n")
+		if err := writeKeys("", root.values[name]); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// quoteINIValue quotes values that would not read back unchanged
+func quoteINIValue(value string) string {
+	if value != strings.TrimSpace(value) || strings.ContainsAny(value, "\"';#\n\r") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// decodeEnv reads KEY=VALUE lines of a .env file. Lines may start with
+// export, double-quoted values are unescaped, single-quoted values are
+// literal and unquoted values end at a # comment.
+func decodeEnv(content string) (any, error) {
+	root := newOrderedMap()
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(nil, max(len(content)+1, bufio.MaxScanTokenSize))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		column := len(text) - len(strings.TrimLeft(text, " \t")) + 1
+		envError := func(message string) error {
+			return &DataParseError{Format: DataFormatEnv, Line: line, Column: column, Message: message}
+		}
+
+		trimmed = strings.TrimPrefix(trimmed, "export ")
+		key, rawValue, found := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if !found {
+			return nil, envError("expected KEY=VALUE")
+		}
+		if !envKeyPattern.MatchString(key) {
+			return nil, envError(fmt.Sprintf("invalid key %q", key))
+		}
+
+		rawValue = strings.TrimSpace(rawValue)
+		var value string
+		switch {
+		case strings.HasPrefix(rawValue, `"`) || strings.HasPrefix(rawValue, "'"):
+			end := closingQuote(rawValue)
+			if end < 0 {
+				return nil, envError("unterminated quoted value")
+			}
+			if rest := strings.TrimSpace(rawValue[end+1:]); rest != "" && rest[0] != '#' {
+				return nil, envError("unexpected text after quoted value")
+			}
+			var err error
+			if value, err = unquoteValue(rawValue[:end+1]); err != nil {
+				return nil, envError(err.Error())
+			}
+		default:
+			value = rawValue
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		root.set(key, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &DataParseError{Format: DataFormatEnv, Message: err.Error()}
+	}
+	return root, nil
+}
+
+// closingQuote returns the index of the quote closing a quoted value, skipping
+// escaped double quotes, or -1
+func closingQuote(value string) int {
+	quote := value[0]
+	for i := 1; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && quote == '"':
+			i++
+		case value[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// encodeEnv writes an object as .env lines. Nested keys are joined with
+// underscores and all keys are upper-cased, so {"db": {"host": ...}} becomes DB_HOST.
+func encodeEnv(value any) (string, error) {
+	root, ok := value.(*orderedMap)
+	if !ok {
+		return "", fmt.Errorf(".env requires an object at the top level")
+	}
+
+	var buf bytes.Buffer
+	seen := make(map[string]string)
+	var err error
+	flatten("", envKeySeparator, root, func(key string, value any) {
+		if err != nil {
+			return
+		}
+		name := envKeyName(key)
+		if original, ok := seen[name]; ok {
+			err = fmt.Errorf("keys %q and %q both become %s", original, key, name)
+			return
+		}
+		seen[name] = key
+
+		var text string
+		if text, err = formatScalar(value); err != nil {
+			return
+		}
+		if !envSafePattern.MatchString(text) {
+			text = strconv.Quote(text)
+		}
+		buf.WriteString(name + "=" + text + "\n")
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// envKeyName upper-cases a key and replaces characters not allowed in
+// environment variable names with underscores
+func envKeyName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// XML mapping: attributes become keys prefixed with @, text next to
+// attributes or child elements becomes #text, and repeated elements become
+// arrays. The root element is the only key of the top-level object.
+const (
+	xmlAttributePrefix = "@"
+	xmlTextKey         = "#text"
+	xmlDefaultRoot     = "root"
+	xmlDefaultItem     = "item"
+)
+
+// xmlNamePattern matches the XML names this converter writes
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.:-]*$`)
+
+// decodeXML reads an XML document into an object keyed by the root element
+func decodeXML(content string) (any, error) {
+	dec := xml.NewDecoder(strings.NewReader(content))
+
+	xmlError := func(err error) error {
+		line, column := dec.InputPos()
+		message := err.Error()
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			message = syntaxErr.Msg
+		}
+		if errors.Is(err, io.EOF) {
+			message = "unexpected end of input"
+		}
+		return &DataParseError{Format: DataFormatXML, Line: line, Column: column, Message: message}
+	}
+
+	var decodeElement func(start xml.StartElement) (any, error)
+	decodeElement = func(start xml.StartElement) (any, error) {
+		m := newOrderedMap()
+		for _, attr := range start.Attr {
+			m.set(xmlAttributePrefix+xmlName(attr.Name), attr.Value)
+		}
+
+		var text strings.Builder
+		for {
+			token, err := dec.Token()
+			if err != nil {
+				return nil, xmlError(err)
+			}
+
+			switch t := token.(type) {
+			case xml.StartElement:
+				child, err := decodeElement(t)
+				if err != nil {
+					return nil, err
+				}
+				name := xmlName(t.Name)
+				existing, ok := m.get(name)
+				if !ok {
+					m.set(name, child)
+				} else if list, isList := existing.([]any); isList {
+					m.set(name, append(list, child))
+				} else {
+					m.set(name, []any{existing, child})
+				}
+			case xml.CharData:
+				text.Write(t)
+			case xml.EndElement:
+				trimmed := strings.TrimSpace(text.String())
+				if len(m.keys) == 0 {
+					if trimmed == "" {
+						return nil, nil
+					}
+					return trimmed, nil
+				}
+				if trimmed != "" {
+					m.set(xmlTextKey, trimmed)
+				}
+				return m, nil
+			}
+		}
+	}
+
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, &DataParseError{Format: DataFormatXML, Message: "no root element"}
+			}
+			return nil, xmlError(err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := decodeElement(start)
+			if err != nil {
+				return nil, err
+			}
+			root := newOrderedMap()
+			root.set(xmlName(start.Name), value)
+			return root, nil
+		}
+	}
+}
+
+// xmlName writes an element or attribute name. Namespace prefixes are
+// dropped, except on namespace declarations.
+func xmlName(name xml.Name) string {
+	if name.Space == "xmlns" {
+		return "xmlns:" + name.Local
+	}
+	return name.Local
+}
+
+// encodeXML writes a value as an indented XML document. An object with a
+// single key is written as that root element, anything else inside <root>.
+func encodeXML(value any) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	root, rootValue := xmlDefaultRoot, value
+	if m, ok := value.(*orderedMap); ok && len(m.keys) == 1 {
+		if _, isArray := m.values[m.keys[0]].([]any); !isArray {
+			root, rootValue = m.keys[0], m.values[m.keys[0]]
+		}
+	}
+	if _, isArray := rootValue.([]any); isArray {
+		// Top-level arrays are written as repeated items
+		wrapped := newOrderedMap()
+		wrapped.set(xmlDefaultItem, rootValue)
+		rootValue = wrapped
+	}
+
+	if err := writeXMLElement(&buf, root, rootValue, 0); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeXMLElement writes a value as an element, or as repeated elements for arrays
+func writeXMLElement(buf *bytes.Buffer, name string, value any, level int) error {
+	if !xmlNamePattern.MatchString(name) {
+		return fmt.Errorf("%q is not a valid XML element name", name)
+	}
+	indent := strings.Repeat("  ", level)
+
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			if err := writeXMLElement(buf, name, item, level); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *orderedMap:
+		buf.WriteString(indent + "<" + name)
+		var children []string
+		var text *string
+		for _, key := range v.keys {
+			switch {
+			case strings.HasPrefix(key, xmlAttributePrefix):
+				attrName := strings.TrimPrefix(key, xmlAttributePrefix)
+				if !xmlNamePattern.MatchString(attrName) {
+					return fmt.Errorf("%q is not a valid XML attribute name", attrName)
+				}
+				attrValue, err := formatScalar(v.values[key])
+				if err != nil {
+					return err
+				}
+				buf.WriteString(" " + attrName + `="`)
+				_ = xml.EscapeText(buf, []byte(attrValue))
+				buf.WriteString(`"`)
+			case key == xmlTextKey:
+				s, err := formatScalar(v.values[key])
+				if err != nil {
+					return err
+				}
+				text = &s
+			default:
+				children = append(children, key)
+			}
+		}
+
+		switch {
+		case len(children) == 0 && text == nil:
+			buf.WriteString("/>\n")
+			return nil
+		case len(children) == 0:
+			buf.WriteString(">")
+			_ = xml.EscapeText(buf, []byte(*text))
+			buf.WriteString("</" + name + ">\n")
+			return nil
+		}
+
+		buf.WriteString(">\n")
+		if text != nil {
+			buf.WriteString(indent + "  ")
+			_ = xml.EscapeText(buf, []byte(*text))
+			buf.WriteString("\n")
+		}
+		for _, key := range children {
+			if err := writeXMLElement(buf, key, v.values[key], level+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</" + name + ">\n")
+		return nil
+	case nil:
+		buf.WriteString(indent + "<" + name + "/>\n")
+		return nil
+	default:
+		text, err := formatScalar(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(indent + "<" + name + ">")
+		_ = xml.EscapeText(buf, []byte(text))
+		buf.WriteString("</" + name + ">\n")
+		return nil
+	}
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"bytes"
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"math/big"
+	"mime/quotedprintable"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// textCodec converts between bytes and their text encoding
+type textCodec struct {
+	encode func(data []byte) (string, error)
+	decode func(text string) ([]byte, error)
+}
+
+// textCodecs maps encoding names to codecs. Operations are the name followed
+// by "-encode" or "-decode".
+var textCodecs = map[string]textCodec{
+	"base64":           stdCodec(base64.StdEncoding),
+	"base64url":        stdCodec(base64.URLEncoding),
+	"base64-raw":       stdCodec(base64.RawStdEncoding),
+	"base64url-raw":    stdCodec(base64.RawURLEncoding),
+	"base32":           stdCodec(base32.StdEncoding),
+	"base32-raw":       stdCodec(base32.StdEncoding.WithPadding(base32.NoPadding)),
+	"base58":           {encode: encodeBase58, decode: decodeBase58},
+	"base85":           {encode: encodeBase85, decode: decodeBase85},
+	"ascii85":          {encode: encodeASCII85, decode: decodeASCII85},
+	"hex":              {encode: infallible(hex.EncodeToString), decode: decodeHex},
+	"url":              {encode: infallible(func(b []byte) string { return url.QueryEscape(string(b)) }), decode: decodeURL},
+	"quoted-printable": {encode: encodeQuotedPrintable, decode: decodeQuotedPrintable},
+	"html":             {encode: infallible(func(b []byte) string { return html.EscapeString(string(b)) }), decode: func(s string) ([]byte, error) { return []byte(html.UnescapeString(s)), nil }},
+	"unicode":          {encode: encodeUnicodeEscapes, decode: decodeUnicodeEscapes},
+	"punycode":         {encode: encodePunycode, decode: decodePunycode},
+	"rot13":            {encode: infallible(func(b []byte) string { return string(rot13(b)) }), decode: func(s string) ([]byte, error) { return rot13([]byte(s)), nil }},
+}
+
+// stdEncoding is implemented by base64 and base32 encodings
+type stdEncoding interface {
+	EncodeToString(src []byte) string
+	DecodeString(s string) ([]byte, error)
+}
+
+// stdCodec adapts a standard library base64 or base32 encoding
+func stdCodec(enc stdEncoding) textCodec {
+	return textCodec{encode: infallible(enc.EncodeToString), decode: enc.DecodeString}
+}
+
+// infallible adapts an encoder that cannot fail
+func infallible(encode func([]byte) string) func([]byte) (string, error) {
+	return func(data []byte) (string, error) { return encode(data), nil }
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(s)
+}
+
+func decodeURL(s string) ([]byte, error) {
+	decoded, err := url.QueryUnescape(s)
+	return []byte(decoded), err
+}
+
+// base58Alphabet is the Bitcoin base58 alphabet
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// maxBase58Size limits base58 input in bytes. Converting the whole input as
+// one number takes quadratic time, and base58 is meant for short values such
+// as keys and hashes.
+const maxBase58Size = 8 << 10
+
+// encodeBase58 encodes data as a big-endian number, keeping leading zero bytes as '1'
+func encodeBase58(data []byte) (string, error) {
+	if len(data) > maxBase58Size {
+		return "", fmt.Errorf("base58 input exceeds %d bytes", maxBase58Size)
+	}
+
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for range zeros {
+		out = append(out, '1')
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out), nil
+}
+
+func decodeBase58(s string) ([]byte, error) {
+	if len(s) > maxBase58Size {
+		return nil, fmt.Errorf("base58 input exceeds %d bytes", maxBase58Size)
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base58Alphabet, s[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("illegal base58 character %q at offset %d", s[i], i)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// base85Alphabet is the RFC 1924 alphabet, also used by git and Python's b85encode
+const base85Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+// encodeBase85 encodes each 4-byte group as 5 characters. A final partial
+// group of n bytes is zero padded and truncated to n+1 characters.
+func encodeBase85(data []byte) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(data); i += 4 {
+		var chunk [4]byte
+		n := copy(chunk[:], data[i:])
+		v := binary.BigEndian.Uint32(chunk[:])
+
+		var group [5]byte
+		for j := 4; j >= 0; j-- {
+			group[j] = base85Alphabet[v%85]
+			v /= 85
+		}
+		out.Write(group[:n+1])
+	}
+	return out.String(), nil
+}
+
+// decodeBase85 reverses encodeBase85, padding a final partial group with the
+// highest digit so truncated bytes round down correctly
+func decodeBase85(s string) ([]byte, error) {
+	if len(s)%5 == 1 {
+		return nil, fmt.Errorf("invalid base85 length %d", len(s))
+	}
+
+	out := make([]byte, 0, len(s)/5*4+4)
+	for i := 0; i < len(s); i += 5 {
+		group := []byte(s[i:min(i+5, len(s))])
+		n := len(group) - 1
+		for len(group) < 5 {
+			group = append(group, base85Alphabet[84])
+		}
+
+		var v uint64
+		for j, c := range group {
+			digit := strings.IndexByte(base85Alphabet, c)
+			if digit < 0 {
+				return nil, fmt.Errorf("illegal base85 character %q at offset %d", c, i+j)
+			}
+			v = v*85 + uint64(digit)
+		}
+		if v > 0xFFFFFFFF {
+			return nil, fmt.Errorf("base85 group at offset %d overflows", i)
+		}
+
+		var chunk [4]byte
+		binary.BigEndian.PutUint32(chunk[:], uint32(v))
+		out = append(out, chunk[:n]...)
+	}
+	return out, nil
+}
+
+// encodeASCII85 encodes with the Adobe/btoa alphabet, without <~ ~> delimiters
+func encodeASCII85(data []byte) (string, error) {
+	out := make([]byte, ascii85.MaxEncodedLen(len(data)))
+	return string(out[:ascii85.Encode(out, data)]), nil
+}
+
+// decodeASCII85 accepts input with or without <~ ~> delimiters
+func decodeASCII85(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "<~")
+	s = strings.TrimSuffix(s, "~>")
+
+	out := make([]byte, 4*len(s))
+	n, _, err := ascii85.Decode(out, []byte(s), true)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+func encodeQuotedPrintable(data []byte) (string, error) {
+	var buf bytes.Buffer
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func decodeQuotedPrintable(s string) ([]byte, error) {
+	return io.ReadAll(quotedprintable.NewReader(strings.NewReader(s)))
+}
+
+// encodeUnicodeEscapes escapes every non-printable-ASCII character as \uXXXX,
+// using surrogate pairs above U+FFFF. Backslashes are doubled.
+func encodeUnicodeEscapes(data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("input is not valid UTF-8")
+	}
+
+	var out strings.Builder
+	for _, r := range string(data) {
+		switch {
+		case r == '\\':
+			out.WriteString(`\\`)
+		case r >= 0x20 && r < 0x7F:
+			out.WriteRune(r)
+		case r > 0xFFFF:
+			hi, lo := utf16.EncodeRune(r)
+			fmt.Fprintf(&out, `\u%04x\u%04x`, hi, lo)
+		default:
+			fmt.Fprintf(&out, `\u%04x`, r)
+		}
+	}
+	return out.String(), nil
+}
+
+// decodeUnicodeEscapes reverses encodeUnicodeEscapes, joining surrogate pairs
+func decodeUnicodeEscapes(s string) ([]byte, error) {
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			out.WriteByte(s[i])
+			i++
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\\' {
+			out.WriteByte('\\')
+			i += 2
+			continue
+		}
+
+		r, err := parseUnicodeEscape(s, i)
+		if err != nil {
+			return nil, err
+		}
+		i += 6
+
+		if utf16.IsSurrogate(r) {
+			lo, err := parseUnicodeEscape(s, i)
+			if err != nil {
+				return nil, fmt.Errorf("unpaired surrogate at offset %d", i-6)
+			}
+			if r = utf16.DecodeRune(r, lo); r == utf8.RuneError {
+				return nil, fmt.Errorf("invalid surrogate pair at offset %d", i-6)
+			}
+			i += 6
+		}
+		out.WriteRune(r)
+	}
+	return []byte(out.String()), nil
+}
+
+// parseUnicodeEscape parses the \uXXXX escape starting at offset i
+func parseUnicodeEscape(s string, i int) (rune, error) {
+	if i+6 > len(s) || s[i] != '\\' || s[i+1] != 'u' {
+		return 0, fmt.Errorf(`invalid escape at offset %d: expected \uXXXX`, i)
+	}
+	v, err := strconv.ParseUint(s[i+2:i+6], 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex digits %q at offset %d", s[i+2:i+6], i+2)
+	}
+	return rune(v), nil
+}
+
+// encodePunycode converts an internationalized domain name to its ASCII form
+func encodePunycode(data []byte) (string, error) {
+	return idna.Lookup.ToASCII(string(data))
+}
+
+// decodePunycode converts an ASCII (xn--) domain name to its Unicode form
+func decodePunycode(s string) ([]byte, error) {
+	decoded, err := idna.Lookup.ToUnicode(s)
+	return []byte(decoded), err
+}
+
+// rot13 rotates ASCII letters by 13 places, which is its own inverse
+func rot13(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, c := range data {
+		switch {
+		case c >= 'a' && c <= 'z':
+			c = 'a' + (c-'a'+13)%26
+		case c >= 'A' && c <= 'Z':
+			c = 'A' + (c-'A'+13)%26
+		}
+		out[i] = c
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sweepJob is a named cleanup function run on every sweep
+type sweepJob struct {
+	name  string
+	sweep func(ctx context.Context) error
+}
+
+// ExpirySweeper periodically deletes expired short URLs, pastes and QR codes
+type ExpirySweeper struct {
+	interval time.Duration
+	logger   *zap.Logger
+	jobs     []sweepJob
+}
+
+// NewExpirySweeper creates a sweeper that runs its jobs every interval
+func NewExpirySweeper(interval time.Duration, logger *zap.Logger) *ExpirySweeper {
+	return &ExpirySweeper{
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Register adds a cleanup job to the sweeper
+func (s *ExpirySweeper) Register(name string, sweep func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, sweepJob{name: name, sweep: sweep})
+}
+
+// Run sweeps immediately and then on every tick until ctx is cancelled. An
+// interval of zero or less disables sweeping.
+func (s *ExpirySweeper) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		s.logger.Info("expiry sweeper disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep runs every registered job once. A failing job is logged and does not
+// stop the others.
+func (s *ExpirySweeper) Sweep(ctx context.Context) {
+	for _, job := range s.jobs {
+		if err := job.sweep(ctx); err != nil {
+			s.logger.Error("expiry sweep failed", zap.String("job", job.name), zap.Error(err))
+			continue
+		}
+		s.logger.Debug("expiry sweep completed", zap.String("job", job.name))
+	}
+}
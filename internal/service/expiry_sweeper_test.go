@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestExpirySweeper_Run(t *testing.T) {
+	sweeps := make(chan struct{}, 10)
+	newSweeper := func(interval time.Duration) *ExpirySweeper {
+		sweeper := NewExpirySweeper(interval, zap.NewNop())
+		sweeper.Register("test", func(context.Context) error {
+			sweeps <- struct{}{}
+			return nil
+		})
+		return sweeper
+	}
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		done := make(chan struct{})
+		go func() {
+			newSweeper(interval).Run(context.Background())
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected Run to return for interval %s", interval)
+		}
+		if len(sweeps) != 0 {
+			t.Errorf("Expected no sweeps for interval %s, got %d", interval, len(sweeps))
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		newSweeper(time.Hour).Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-sweeps:
+	case <-time.After(time.Second):
+		t.Fatal("Expected an immediate sweep")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Run to stop when its context is cancelled")
+	}
+}
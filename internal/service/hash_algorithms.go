@@ -0,0 +1,112 @@
+package service
+
+import (
+	"crypto/md5"  // #nosec G501 - MD5 is provided as a user-requested feature, not for security
+	"crypto/sha1" // #nosec G505 - SHA1 is provided as a user-requested feature, not for security
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"slices"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/zeebo/blake3"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+)
+
+// maxDigestAlgorithms limits how many digests are computed in one pass
+const maxDigestAlgorithms = 20
+
+var (
+	crc32cTable    = crc32.MakeTable(crc32.Castagnoli)
+	crc64ISOTable  = crc64.MakeTable(crc64.ISO)
+	crc64ECMATable = crc64.MakeTable(crc64.ECMA)
+)
+
+// digestConstructors maps streaming digest algorithm names to their constructors
+var digestConstructors = map[string]func() hash.Hash{
+	"md5":         md5.New,  // #nosec G401 - MD5 is provided as a user-requested feature, not for security
+	"sha1":        sha1.New, // #nosec G401 - SHA1 is provided as a user-requested feature, not for security
+	"sha224":      sha256.New224,
+	"sha256":      sha256.New,
+	"sha384":      sha512.New384,
+	"sha512":      sha512.New,
+	"sha3-224":    func() hash.Hash { return sha3.New224() },
+	"sha3-256":    func() hash.Hash { return sha3.New256() },
+	"sha3-384":    func() hash.Hash { return sha3.New384() },
+	"sha3-512":    func() hash.Hash { return sha3.New512() },
+	"blake2b-256": func() hash.Hash { return mustUnkeyed(blake2b.New256(nil)) },
+	"blake2b-384": func() hash.Hash { return mustUnkeyed(blake2b.New384(nil)) },
+	"blake2b-512": func() hash.Hash { return mustUnkeyed(blake2b.New512(nil)) },
+	"blake2s-256": func() hash.Hash { return mustUnkeyed(blake2s.New256(nil)) },
+	"blake3":      func() hash.Hash { return blake3.New() },
+	"crc32":       func() hash.Hash { return crc32.NewIEEE() },
+	"crc32c":      func() hash.Hash { return crc32.New(crc32cTable) },
+	"crc64-iso":   func() hash.Hash { return crc64.New(crc64ISOTable) },
+	"crc64-ecma":  func() hash.Hash { return crc64.New(crc64ECMATable) },
+	"xxhash64":    func() hash.Hash { return xxhash.New() },
+}
+
+// mustUnkeyed unwraps a BLAKE2 constructor, which only fails for oversized keys
+func mustUnkeyed(h hash.Hash, err error) hash.Hash {
+	if err != nil {
+		panic(fmt.Sprintf("unkeyed BLAKE2 constructor failed: %v", err))
+	}
+	return h
+}
+
+// digestReader computes every requested digest over r in a single pass and
+// returns them in request order, without duplicates, along with the number of
+// bytes read
+func digestReader(r io.Reader, algorithms []string) ([]domain.HashDigest, int64, error) {
+	unique := make([]string, 0, len(algorithms))
+	for _, name := range algorithms {
+		if !slices.Contains(unique, name) {
+			unique = append(unique, name)
+		}
+	}
+	algorithms = unique
+
+	if len(algorithms) == 0 {
+		return nil, 0, fmt.Errorf("at least one algorithm is required")
+	}
+	if len(algorithms) > maxDigestAlgorithms {
+		return nil, 0, fmt.Errorf("at most %d algorithms can be computed at once", maxDigestAlgorithms)
+	}
+
+	hashes := make([]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, len(algorithms))
+	for i, name := range algorithms {
+		newHash, ok := digestConstructors[name]
+		if !ok {
+			return nil, 0, fmt.Errorf("unsupported algorithm: %s", name)
+		}
+		hashes[i] = newHash()
+		writers[i] = hashes[i]
+	}
+
+	n, err := io.Copy(io.MultiWriter(writers...), r)
+	if err != nil {
+		return nil, n, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	digests := make([]domain.HashDigest, len(algorithms))
+	for i, h := range hashes {
+		sum := h.Sum(nil)
+		digests[i] = domain.HashDigest{
+			Algorithm: algorithms[i],
+			Hex:       hex.EncodeToString(sum),
+			Base64:    base64.StdEncoding.EncodeToString(sum),
+		}
+	}
+
+	return digests, n, nil
+}
@@ -1,19 +1,15 @@
 package service
 
 import (
-	"crypto/md5" // #nosec G501 - MD5 is provided as a user-requested feature, not for security
-	"crypto/rand"
-	"crypto/sha1" // #nosec G505 - SHA1 is provided as a user-requested feature, not for security
-	"crypto/sha256"
-	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"math/big"
-	"net/url"
+	"io"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/codewithwan/gopilot/internal/domain"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // HashService handles hashing and encoding operations
@@ -24,86 +20,200 @@ func NewHashService() *HashService {
 	return &HashService{}
 }
 
-// Hash hashes text using the specified algorithm
+// ErrPasswordHashWithDigests is returned when a password hash algorithm is combined with other algorithms
+var ErrPasswordHashWithDigests = errors.New("password hash algorithms cannot be combined with other algorithms")
+
+// ErrUnsupportedPasswordOption is returned when a password option is set that
+// the requested mode cannot honor, such as character classes for passphrases,
+// whose words are drawn whole
+var ErrUnsupportedPasswordOption = errors.New("unsupported password option")
+
+// Hash hashes text using the specified algorithm. Any additional algorithms
+// are computed in the same pass and returned as digests.
 func (s *HashService) Hash(req *domain.HashRequest) (*domain.HashResponse, error) {
-	var hash string
 	text := req.Text
 	if req.Salt != nil {
 		text += *req.Salt
 	}
 
-	switch req.Algorithm {
-	case "md5":
-		h := md5.Sum([]byte(text)) // #nosec G401 - MD5 is provided as a user-requested feature, not for security
-		hash = hex.EncodeToString(h[:])
-	case "sha1":
-		h := sha1.Sum([]byte(text)) // #nosec G401 - SHA1 is provided as a user-requested feature, not for security
-		hash = hex.EncodeToString(h[:])
-	case "sha256":
-		h := sha256.Sum256([]byte(text))
-		hash = hex.EncodeToString(h[:])
-	case "sha512":
-		h := sha512.Sum512([]byte(text))
-		hash = hex.EncodeToString(h[:])
-	case "bcrypt":
-		h, err := bcrypt.GenerateFromPassword([]byte(text), bcrypt.DefaultCost)
+	if isPasswordHashAlgorithm(req.Algorithm) {
+		if len(req.Algorithms) > 0 {
+			return nil, ErrPasswordHashWithDigests
+		}
+		h, err := generatePasswordHash(req.Algorithm, text, req.Params)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate bcrypt hash: %w", err)
+			return nil, err
 		}
-		hash = string(h)
-	default:
-		return nil, fmt.Errorf("unsupported algorithm: %s", req.Algorithm)
+		return &domain.HashResponse{
+			Hash:      h,
+			Algorithm: req.Algorithm,
+		}, nil
+	}
+
+	algorithms := req.Algorithms
+	if req.Algorithm != "" {
+		algorithms = append([]string{req.Algorithm}, req.Algorithms...)
+	}
+
+	digests, _, err := digestReader(strings.NewReader(text), algorithms)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.HashResponse{}
+	if req.Algorithm != "" {
+		response.Hash = digests[0].Hex
+		response.Base64 = digests[0].Base64
+		response.Algorithm = req.Algorithm
+	}
+	if len(req.Algorithms) > 0 {
+		response.Digests = digests
 	}
 
-	return &domain.HashResponse{
-		Hash:      hash,
-		Algorithm: req.Algorithm,
+	return response, nil
+}
+
+// VerifyHash checks text against a bcrypt, argon2, scrypt or PBKDF2 hash,
+// detecting the algorithm from the hash format
+func (s *HashService) VerifyHash(req *domain.VerifyHashRequest) (*domain.VerifyHashResponse, error) {
+	valid, algorithm, err := verifyPasswordHash(req.Hash, req.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.VerifyHashResponse{
+		Valid:     valid,
+		Algorithm: algorithm,
 	}, nil
 }
 
-// Encode encodes/decodes text using the specified operation
+// AnalyzePassword estimates the strength of a password
+func (s *HashService) AnalyzePassword(req *domain.AnalyzePasswordRequest) *domain.PasswordAnalysis {
+	return analyzePassword(req.Password, req.UserInputs)
+}
+
+// HashFile streams r through every requested digest algorithm in a single
+// pass without buffering it. SHA-256 is used when no algorithm is given.
+func (s *HashService) HashFile(r io.Reader, filename string, algorithms []string) (*domain.HashFileResponse, error) {
+	if len(algorithms) == 0 {
+		algorithms = []string{"sha256"}
+	}
+
+	digests, size, err := digestReader(r, algorithms)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.HashFileResponse{
+		Filename: filename,
+		Size:     size,
+		Digests:  digests,
+	}, nil
+}
+
+// Encode encodes/decodes text using the specified operation. Results that
+// are not valid UTF-8 are returned hex or base64 encoded and flagged binary.
 func (s *HashService) Encode(req *domain.EncodeRequest) (*domain.EncodeResponse, error) {
-	var result string
-	var err error
+	output, err := s.EncodeRaw(req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.EncodeResponse{Operation: req.Operation}
+	response.Result, response.Encoding = encodeOutput(output, req.BinaryEncoding)
+	response.Binary = response.Encoding != ""
+
+	return response, nil
+}
+
+// encodeOutput returns output as text, or hex or base64 encoded when it is
+// not valid UTF-8. The returned encoding is empty for text output.
+func encodeOutput(output []byte, binaryEncoding *string) (string, string) {
+	if utf8.Valid(output) {
+		return string(output), ""
+	}
 
-	switch req.Operation {
-	case "base64-encode":
-		result = base64.StdEncoding.EncodeToString([]byte(req.Text))
-	case "base64-decode":
-		var decoded []byte
-		decoded, err = base64.StdEncoding.DecodeString(req.Text)
+	encoding := "base64"
+	if binaryEncoding != nil {
+		encoding = *binaryEncoding
+	}
+	if encoding == "hex" {
+		return hex.EncodeToString(output), encoding
+	}
+	return base64.StdEncoding.EncodeToString(output), encoding
+}
+
+// EncodeRaw runs an encode/decode operation and returns the raw output bytes.
+// The input text is first decoded from the request's input encoding.
+func (s *HashService) EncodeRaw(req *domain.EncodeRequest) ([]byte, error) {
+	input, err := decodeInput(req.Text, req.InputEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	name, decode := strings.CutSuffix(req.Operation, "-decode")
+	if !decode {
+		var ok bool
+		if name, ok = strings.CutSuffix(req.Operation, "-encode"); !ok {
+			return nil, fmt.Errorf("unsupported operation: %s", req.Operation)
+		}
+	}
+
+	codec, ok := textCodecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported operation: %s", req.Operation)
+	}
+
+	if decode {
+		decoded, err := codec.decode(string(input))
 		if err != nil {
-			return nil, fmt.Errorf("failed to decode base64: %w", err)
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
 		}
-		result = string(decoded)
-	case "url-encode":
-		result = url.QueryEscape(req.Text)
-	case "url-decode":
-		result, err = url.QueryUnescape(req.Text)
+		return decoded, nil
+	}
+
+	encoded, err := codec.encode(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return []byte(encoded), nil
+}
+
+// decodeInput converts request text to bytes. Binary input is sent as hex or
+// base64; text is used as is.
+func decodeInput(text string, encoding *string) ([]byte, error) {
+	if encoding == nil {
+		return []byte(text), nil
+	}
+
+	switch *encoding {
+	case "text":
+		return []byte(text), nil
+	case "hex":
+		data, err := hex.DecodeString(strings.TrimSpace(text))
 		if err != nil {
-			return nil, fmt.Errorf("failed to decode URL: %w", err)
+			return nil, fmt.Errorf("failed to decode hex input: %w", err)
 		}
-	case "hex-encode":
-		result = hex.EncodeToString([]byte(req.Text))
-	case "hex-decode":
-		var decoded []byte
-		decoded, err = hex.DecodeString(req.Text)
+		return data, nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
 		if err != nil {
-			return nil, fmt.Errorf("failed to decode hex: %w", err)
+			return nil, fmt.Errorf("failed to decode base64 input: %w", err)
 		}
-		result = string(decoded)
+		return data, nil
 	default:
-		return nil, fmt.Errorf("unsupported operation: %s", req.Operation)
+		return nil, fmt.Errorf("unsupported input encoding: %s", *encoding)
 	}
-
-	return &domain.EncodeResponse{
-		Result:    result,
-		Operation: req.Operation,
-	}, nil
 }
 
-// GeneratePassword generates a random password
+// GeneratePassword generates a random password, passphrase or pronounceable
+// password and reports its entropy
 func (s *HashService) GeneratePassword(req *domain.GeneratePasswordRequest) (*domain.GeneratePasswordResponse, error) {
+	mode := PasswordModeRandom
+	if req.Mode != nil {
+		mode = *req.Mode
+	}
+
 	length := 16
 	if req.Length != nil {
 		length = *req.Length
@@ -113,55 +223,113 @@ func (s *HashService) GeneratePassword(req *domain.GeneratePasswordRequest) (*do
 		}
 	}
 
-	includeUpper := true
-	if req.IncludeUpper != nil {
-		includeUpper = *req.IncludeUpper
-	}
-
-	includeLower := true
-	if req.IncludeLower != nil {
-		includeLower = *req.IncludeLower
-	}
+	// Pronounceable passwords are lowercase letters unless asked otherwise
+	defaultClasses := mode == PasswordModeRandom
+	includeUpper := boolOr(req.IncludeUpper, defaultClasses)
+	includeLower := boolOr(req.IncludeLower, true)
+	includeNumbers := boolOr(req.IncludeNumbers, defaultClasses)
+	includeSymbols := boolOr(req.IncludeSymbols, false)
 
-	includeNumbers := true
-	if req.IncludeNumbers != nil {
-		includeNumbers = *req.IncludeNumbers
-	}
+	var password string
+	var entropy float64
+	var err error
+	response := &domain.GeneratePasswordResponse{Mode: mode}
 
-	includeSymbols := false
-	if req.IncludeSymbols != nil {
-		includeSymbols = *req.IncludeSymbols
+	if err := checkPasswordOptions(mode, req); err != nil {
+		return nil, err
 	}
 
-	charset := ""
-	if includeLower {
-		charset += "abcdefghijklmnopqrstuvwxyz"
-	}
-	if includeUpper {
-		charset += "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	}
-	if includeNumbers {
-		charset += "0123456789"
+	switch mode {
+	case PasswordModePassphrase:
+		words := 6
+		if req.Words != nil {
+			words = *req.Words
+		}
+		separator := "-"
+		if req.Separator != nil {
+			separator = *req.Separator
+		}
+		password, entropy, err = generatePassphrase(words, separator, boolOr(req.Capitalize, false), includeNumbers)
+		response.Words = words
+	case PasswordModePronounceable:
+		password, entropy, err = generatePronounceable(length, includeUpper, includeNumbers, includeSymbols, boolOr(req.ExcludeAmbiguous, false))
+	case PasswordModeRandom:
+		var classes []string
+		for _, class := range []struct {
+			include bool
+			chars   string
+		}{
+			{includeLower, lowerChars},
+			{includeUpper, upperChars},
+			{includeNumbers, digitChars},
+			{includeSymbols, symbolChars},
+		} {
+			if !class.include {
+				continue
+			}
+			chars := class.chars
+			if boolOr(req.ExcludeAmbiguous, false) {
+				chars = withoutChars(chars, ambiguousChars)
+			}
+			classes = append(classes, chars)
+		}
+		password, entropy, err = generateRandomPassword(classes, length, boolOr(req.RequireEachClass, false))
+	default:
+		return nil, fmt.Errorf("unsupported mode: %s", mode)
 	}
-	if includeSymbols {
-		charset += "!@#$%^&*()_+-=[]{}|;:,.<>?"
+	if err != nil {
+		return nil, err
 	}
 
-	if charset == "" {
-		return nil, fmt.Errorf("at least one character type must be included")
-	}
+	response.Password = password
+	response.Length = len([]rune(password))
+	response.Entropy = round2(entropy)
+	return response, nil
+}
 
-	password := make([]byte, length)
-	for i := range password {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate random number: %w", err)
+// checkPasswordOptions rejects options that mode would otherwise ignore
+func checkPasswordOptions(mode string, req *domain.GeneratePasswordRequest) error {
+	var unsupported []string
+	switch mode {
+	case PasswordModePassphrase:
+		if boolOr(req.IncludeUpper, false) {
+			unsupported = append(unsupported, "include_upper")
+		}
+		if !boolOr(req.IncludeLower, true) {
+			unsupported = append(unsupported, "include_lower")
+		}
+		if boolOr(req.IncludeSymbols, false) {
+			unsupported = append(unsupported, "include_symbols")
+		}
+		if boolOr(req.RequireEachClass, false) {
+			unsupported = append(unsupported, "require_each_class")
+		}
+		if boolOr(req.ExcludeAmbiguous, false) {
+			unsupported = append(unsupported, "exclude_ambiguous")
+		}
+	case PasswordModePronounceable:
+		if !boolOr(req.IncludeLower, true) {
+			unsupported = append(unsupported, "include_lower")
+		}
+		if boolOr(req.RequireEachClass, false) {
+			unsupported = append(unsupported, "require_each_class")
 		}
-		password[i] = charset[num.Int64()]
 	}
 
-	return &domain.GeneratePasswordResponse{
-		Password: string(password),
-		Length:   length,
-	}, nil
+	switch len(unsupported) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%w: %s is not supported in %s mode", ErrUnsupportedPasswordOption, unsupported[0], mode)
+	default:
+		return fmt.Errorf("%w: %s are not supported in %s mode", ErrUnsupportedPasswordOption, strings.Join(unsupported, ", "), mode)
+	}
+}
+
+// boolOr returns *p, or def when p is nil
+func boolOr(p *bool, def bool) bool {
+	if p == nil {
+		return def
+	}
+	return *p
 }
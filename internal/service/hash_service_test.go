@@ -0,0 +1,478 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+func TestHash_KnownDigests(t *testing.T) {
+	// Digests of "abc"
+	want := map[string]string{
+		"md5":         "900150983cd24fb0d6963f7d28e17f72",
+		"sha224":      "23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7",
+		"sha384":      "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7",
+		"sha3-256":    "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+		"blake2b-256": "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+		"blake2s-256": "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982",
+		"blake3":      "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85",
+		"crc32":       "352441c2",
+		"crc32c":      "364b3fb7",
+		"xxhash64":    "44bc2cf5ad770999",
+	}
+
+	algorithms := make([]string, 0, len(want))
+	for algorithm := range want {
+		algorithms = append(algorithms, algorithm)
+	}
+
+	svc := NewHashService()
+	result, err := svc.Hash(&domain.HashRequest{Text: "abc", Algorithm: "sha256", Algorithms: algorithms})
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	if result.Hash != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("Unexpected sha256 hash %s", result.Hash)
+	}
+	if result.Base64 != "ungWv48Bz+pBQUDeXa4iI7ADYaOWF3qctBD/YfIAFa0=" {
+		t.Errorf("Unexpected sha256 base64 %s", result.Base64)
+	}
+	if len(result.Digests) != len(algorithms)+1 {
+		t.Fatalf("Expected %d digests, got %d", len(algorithms)+1, len(result.Digests))
+	}
+	for _, digest := range result.Digests[1:] {
+		if digest.Hex != want[digest.Algorithm] {
+			t.Errorf("%s: expected %s, got %s", digest.Algorithm, want[digest.Algorithm], digest.Hex)
+		}
+	}
+}
+
+func TestHash_BcryptWithDigests(t *testing.T) {
+	svc := NewHashService()
+	_, err := svc.Hash(&domain.HashRequest{Text: "abc", Algorithm: "bcrypt", Algorithms: []string{"sha256"}})
+	if !errors.Is(err, ErrPasswordHashWithDigests) {
+		t.Errorf("Expected ErrPasswordHashWithDigests, got %v", err)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	svc := NewHashService()
+
+	result, err := svc.HashFile(strings.NewReader(strings.Repeat("a", 1<<20)), "a.bin", nil)
+	if err != nil {
+		t.Fatalf("Failed to hash file: %v", err)
+	}
+	if result.Size != 1<<20 || len(result.Digests) != 1 || result.Digests[0].Algorithm != "sha256" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	if _, err := svc.HashFile(strings.NewReader("a"), "a.bin", []string{"sha256", "whirlpool"}); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+}
+
+func TestHash_PasswordHashRoundTrip(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		params    *domain.HashParams
+	}{
+		{algorithm: "bcrypt", params: &domain.HashParams{Cost: intPtr(4)}},
+		{algorithm: "argon2id", params: &domain.HashParams{Memory: intPtr(1024), Iterations: intPtr(1)}},
+		{algorithm: "argon2i", params: &domain.HashParams{Memory: intPtr(1024), Iterations: intPtr(1), Parallelism: intPtr(2)}},
+		{algorithm: "scrypt", params: &domain.HashParams{LogN: intPtr(10)}},
+		{algorithm: "pbkdf2-sha256", params: &domain.HashParams{Iterations: intPtr(1000)}},
+		{algorithm: "pbkdf2-sha512", params: &domain.HashParams{Iterations: intPtr(1000)}},
+	}
+
+	svc := NewHashService()
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			result, err := svc.Hash(&domain.HashRequest{Text: "hunter2", Algorithm: tt.algorithm, Params: tt.params})
+			if err != nil {
+				t.Fatalf("Failed to hash: %v", err)
+			}
+
+			for text, want := range map[string]bool{"hunter2": true, "hunter3": false} {
+				verified, err := svc.VerifyHash(&domain.VerifyHashRequest{Text: text, Hash: result.Hash})
+				if err != nil {
+					t.Fatalf("Failed to verify %s: %v", result.Hash, err)
+				}
+				if verified.Valid != want || verified.Algorithm != tt.algorithm {
+					t.Errorf("Verify %q against %s: got %+v", text, result.Hash, verified)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyHash_ExternalFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		hash     string
+		wantAlgo string
+	}{
+		{
+			name:     "argon2 reference",
+			text:     "password",
+			hash:     "$argon2i$v=19$m=65536,t=2,p=4$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG",
+			wantAlgo: "argon2i",
+		},
+		{
+			name:     "django pbkdf2",
+			text:     "hunter2",
+			hash:     "pbkdf2_sha256$1000$seasalt$aZOLUDnbVq4qfmIhIFCkAqvDNHspRzj9l43SgVe7GOM=",
+			wantAlgo: "pbkdf2-sha256",
+		},
+		{
+			name:     "passlib pbkdf2",
+			text:     "hunter2",
+			hash:     "$pbkdf2-sha512$1000$MDEyMzQ1Njc4OWFiY2RlZg$Xp/4UtI3VYuUskUJvg/ElBho/1QUob2t4wOqDH2dRs5/P6kzj5.E8oi97sPcw7P4ZvydZ2rKx3aQeetuxYWOOg",
+			wantAlgo: "pbkdf2-sha512",
+		},
+		{
+			name:     "scrypt",
+			text:     "hunter2",
+			hash:     "$scrypt$ln=10,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$xhygCB++/lnqkJuXyqpuqIwyXp1fZuC+q3d168khIUA",
+			wantAlgo: "scrypt",
+		},
+	}
+
+	svc := NewHashService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.VerifyHash(&domain.VerifyHashRequest{Text: tt.text, Hash: tt.hash})
+			if err != nil {
+				t.Fatalf("Failed to verify: %v", err)
+			}
+			if !result.Valid || result.Algorithm != tt.wantAlgo {
+				t.Errorf("Expected valid %s, got %+v", tt.wantAlgo, result)
+			}
+		})
+	}
+}
+
+func TestVerifyHash_CostGuard(t *testing.T) {
+	hashes := []string{
+		"$argon2id$v=19$m=4194304,t=3,p=1$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG",
+		"$scrypt$ln=30,r=8,p=1$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG",
+		"pbkdf2_sha256$100000000$salt$aZOLUDnbVq4qfmIhIFCkAqvDNHspRzj9l43SgVe7GOM=",
+		"$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+	}
+
+	svc := NewHashService()
+	for _, h := range hashes {
+		if _, err := svc.VerifyHash(&domain.VerifyHashRequest{Text: "x", Hash: h}); !errors.Is(err, ErrHashCostTooHigh) {
+			t.Errorf("Expected ErrHashCostTooHigh for %s, got %v", h, err)
+		}
+	}
+
+	if _, err := svc.VerifyHash(&domain.VerifyHashRequest{Text: "x", Hash: "5f4dcc3b5aa765d61d8327deb882cf99"}); !errors.Is(err, ErrUnrecognizedHash) {
+		t.Errorf("Expected ErrUnrecognizedHash, got %v", err)
+	}
+}
+
+func TestAnalyzePassword(t *testing.T) {
+	tests := []struct {
+		password    string
+		userInputs  []string
+		maxScore    int
+		minScore    int
+		wantPattern string
+	}{
+		{password: "password", maxScore: 0, wantPattern: "dictionary"},
+		{password: "zxcvfrewq", maxScore: 1, wantPattern: "spatial"},
+		{password: "12/25/1987", maxScore: 1, wantPattern: "date"},
+		{password: "alice2024", userInputs: []string{"alice"}, maxScore: 1, wantPattern: "dictionary"},
+		{password: "correct horse battery staple", minScore: 3, maxScore: 4},
+		{password: "Xk9#mQ2$vL7!", minScore: 3, maxScore: 4},
+	}
+
+	svc := NewHashService()
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			result := svc.AnalyzePassword(&domain.AnalyzePasswordRequest{Password: tt.password, UserInputs: tt.userInputs})
+			if result.Score < tt.minScore || result.Score > tt.maxScore {
+				t.Errorf("Expected score between %d and %d, got %d", tt.minScore, tt.maxScore, result.Score)
+			}
+			if tt.wantPattern != "" {
+				found := false
+				for _, m := range result.Matches {
+					found = found || m.Pattern == tt.wantPattern
+				}
+				if !found {
+					t.Errorf("Expected a %s match, got %+v", tt.wantPattern, result.Matches)
+				}
+				if result.Feedback.Warning == "" {
+					t.Error("Expected a warning for a weak password")
+				}
+			}
+		})
+	}
+
+	if err := checkPasswordStrength("alice123", 2, "alice"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("Expected ErrWeakPassword, got %v", err)
+	}
+	if err := checkPasswordStrength("correct horse battery staple", 2); err != nil {
+		t.Errorf("Expected strong password to pass, got %v", err)
+	}
+}
+
+func TestGeneratePassword_Modes(t *testing.T) {
+	svc := NewHashService()
+	boolPtr := func(b bool) *bool { return &b }
+
+	t.Run("passphrase", func(t *testing.T) {
+		result, err := svc.GeneratePassword(&domain.GeneratePasswordRequest{
+			Mode:           stringPtr("passphrase"),
+			Words:          intPtr(5),
+			Separator:      stringPtr("."),
+			Capitalize:     boolPtr(true),
+			IncludeNumbers: boolPtr(true),
+		})
+		if err != nil {
+			t.Fatalf("Failed to generate passphrase: %v", err)
+		}
+		words := strings.Split(result.Password, ".")
+		if len(words) != 5 || result.Words != 5 {
+			t.Fatalf("Expected 5 words, got %q", result.Password)
+		}
+		digits := 0
+		for _, word := range words {
+			if word[0] < 'A' || word[0] > 'Z' {
+				t.Errorf("Expected capitalized word, got %q", word)
+			}
+			digits += strings.IndexAny(word, digitChars) + 1
+		}
+		if digits == 0 {
+			t.Errorf("Expected a digit in %q", result.Password)
+		}
+		// 5 words of log2(7776) bits, plus the digit and its position
+		want := 5*math.Log2(7776) + math.Log2(10) + math.Log2(5)
+		if math.Abs(result.Entropy-want) > 0.01 {
+			t.Errorf("Expected entropy %.2f, got %v", want, result.Entropy)
+		}
+	})
+
+	t.Run("pronounceable", func(t *testing.T) {
+		result, err := svc.GeneratePassword(&domain.GeneratePasswordRequest{Mode: stringPtr("pronounceable"), Length: intPtr(12)})
+		if err != nil {
+			t.Fatalf("Failed to generate password: %v", err)
+		}
+		if result.Length != 12 {
+			t.Fatalf("Expected length 12, got %q", result.Password)
+		}
+		for i := 1; i < len(result.Password); i++ {
+			if strings.ContainsRune(vowelChars, rune(result.Password[i])) == strings.ContainsRune(vowelChars, rune(result.Password[i-1])) {
+				t.Fatalf("Expected alternating consonants and vowels, got %q", result.Password)
+			}
+		}
+	})
+
+	t.Run("pronounceable without ambiguous", func(t *testing.T) {
+		for range 50 {
+			result, err := svc.GeneratePassword(&domain.GeneratePasswordRequest{
+				Mode:             stringPtr("pronounceable"),
+				Length:           intPtr(10),
+				IncludeUpper:     boolPtr(true),
+				IncludeNumbers:   boolPtr(true),
+				IncludeSymbols:   boolPtr(true),
+				ExcludeAmbiguous: boolPtr(true),
+			})
+			if err != nil {
+				t.Fatalf("Failed to generate password: %v", err)
+			}
+			if strings.ContainsAny(result.Password, ambiguousChars) {
+				t.Fatalf("Expected no ambiguous characters in %q", result.Password)
+			}
+			for _, class := range []string{lowerChars, upperChars, digitChars, symbolChars} {
+				if !strings.ContainsAny(result.Password, class) {
+					t.Fatalf("Expected every character type in %q", result.Password)
+				}
+			}
+		}
+
+		// A bit for the starting letter kind, 4 consonants and 4 vowels without
+		// l and o, then a digit without 0 and 1 and a symbol without |
+		result, err := svc.GeneratePassword(&domain.GeneratePasswordRequest{Mode: stringPtr("pronounceable"), Length: intPtr(10), IncludeNumbers: boolPtr(true), IncludeSymbols: boolPtr(true), ExcludeAmbiguous: boolPtr(true)})
+		if err != nil {
+			t.Fatalf("Failed to generate password: %v", err)
+		}
+		consonants, vowels := float64(len(consonantChars)-1), float64(len(vowelChars)-1)
+		want := 1 + 4*math.Log2(consonants) + 4*math.Log2(vowels) + math.Log2(8) + math.Log2(float64(len(symbolChars)-1))
+		if math.Abs(result.Entropy-want) > 0.01 {
+			t.Errorf("Expected entropy %.2f, got %v", want, result.Entropy)
+		}
+	})
+
+	t.Run("unsupported options", func(t *testing.T) {
+		for _, req := range []domain.GeneratePasswordRequest{
+			{Mode: stringPtr("passphrase"), ExcludeAmbiguous: boolPtr(true)},
+			{Mode: stringPtr("passphrase"), RequireEachClass: boolPtr(true)},
+			{Mode: stringPtr("passphrase"), IncludeUpper: boolPtr(true)},
+			{Mode: stringPtr("passphrase"), IncludeSymbols: boolPtr(true)},
+			{Mode: stringPtr("pronounceable"), IncludeLower: boolPtr(false)},
+			{Mode: stringPtr("pronounceable"), RequireEachClass: boolPtr(true)},
+		} {
+			if _, err := svc.GeneratePassword(&req); !errors.Is(err, ErrUnsupportedPasswordOption) {
+				t.Errorf("Expected ErrUnsupportedPasswordOption for %+v, got %v", req, err)
+			}
+		}
+
+		// Options matching what the mode does anyway are accepted
+		for _, req := range []domain.GeneratePasswordRequest{
+			{Mode: stringPtr("passphrase"), IncludeUpper: boolPtr(false), IncludeLower: boolPtr(true), IncludeSymbols: boolPtr(false), Capitalize: boolPtr(true)},
+			{Mode: stringPtr("pronounceable"), IncludeLower: boolPtr(true), RequireEachClass: boolPtr(false)},
+		} {
+			if _, err := svc.GeneratePassword(&req); err != nil {
+				t.Errorf("Failed to generate password for %+v: %v", req, err)
+			}
+		}
+	})
+
+	t.Run("require each class without ambiguous", func(t *testing.T) {
+		for range 50 {
+			result, err := svc.GeneratePassword(&domain.GeneratePasswordRequest{
+				Length:           intPtr(8),
+				IncludeSymbols:   boolPtr(true),
+				RequireEachClass: boolPtr(true),
+				ExcludeAmbiguous: boolPtr(true),
+			})
+			if err != nil {
+				t.Fatalf("Failed to generate password: %v", err)
+			}
+			for _, class := range []string{lowerChars, upperChars, digitChars, symbolChars} {
+				if !strings.ContainsAny(result.Password, withoutChars(class, ambiguousChars)) {
+					t.Fatalf("Expected every character type in %q", result.Password)
+				}
+			}
+			if strings.ContainsAny(result.Password, ambiguousChars) {
+				t.Fatalf("Expected no ambiguous characters in %q", result.Password)
+			}
+		}
+	})
+}
+
+func TestRequireEachClassEntropy(t *testing.T) {
+	// Two classes of one character each: only "ab" and "ba" qualify
+	if got := requireEachClassEntropy([]string{"a", "b"}, 2); got != 1 {
+		t.Errorf("Expected 1 bit, got %v", got)
+	}
+	// All lowercase and digit strings minus those with only one of the classes
+	want := math.Log2(math.Pow(36, 8) - math.Pow(26, 8) - math.Pow(10, 8))
+	if got := requireEachClassEntropy([]string{lowerChars, digitChars}, 8); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected %v bits, got %v", want, got)
+	}
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		encoded string
+	}{
+		{name: "base64url", text: "subjects?_d", encoded: "c3ViamVjdHM_X2Q="},
+		{name: "base64url-raw", text: "subjects?_d", encoded: "c3ViamVjdHM_X2Q"},
+		{name: "base64-raw", text: "hi", encoded: "aGk"},
+		{name: "base32", text: "hello", encoded: "NBSWY3DP"},
+		{name: "base32-raw", text: "hi", encoded: "NBUQ"},
+		{name: "base58", text: "\x00\x00hello world", encoded: "11StV1DL6CwTryKyV"},
+		{name: "base85", text: "hello world!", encoded: "Xk~0{Zy<MXa%^NF"},
+		{name: "base85", text: "hi", encoded: "XlV"},
+		{name: "ascii85", text: "hello world!", encoded: "BOu!rD]j7BEbo80"},
+		{name: "quoted-printable", text: "café = ok", encoded: "caf=C3=A9 =3D ok"},
+		{name: "html", text: `<a href="x">&</a>`, encoded: "&lt;a href=&#34;x&#34;&gt;&amp;&lt;/a&gt;"},
+		{name: "unicode", text: `é\😀`, encoded: `\u00e9\\\ud83d\ude00`},
+		{name: "punycode", text: "münchen.de", encoded: "xn--mnchen-3ya.de"},
+		{name: "rot13", text: "Hello, World!", encoded: "Uryyb, Jbeyq!"},
+	}
+
+	svc := NewHashService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := svc.Encode(&domain.EncodeRequest{Text: tt.text, Operation: tt.name + "-encode"})
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			if encoded.Result != tt.encoded {
+				t.Errorf("Expected %q, got %q", tt.encoded, encoded.Result)
+			}
+
+			decoded, err := svc.Encode(&domain.EncodeRequest{Text: tt.encoded, Operation: tt.name + "-decode"})
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			if decoded.Result != tt.text {
+				t.Errorf("Expected %q, got %q", tt.text, decoded.Result)
+			}
+		})
+	}
+}
+
+func TestEncode_MalformedInput(t *testing.T) {
+	tests := []struct{ operation, text string }{
+		{"base58-decode", "0OIl"},
+		{"base85-decode", "Xk~0{Z"},
+		{"base85-decode", "\"\"\"\"\""},
+		{"ascii85-decode", "<~BOu!rD]j7BEbo8v~>"},
+		{"base32-decode", "NBSWY3D"},
+		{"unicode-decode", `\u12`},
+		{"unicode-decode", `\ud83d`},
+		{"punycode-decode", "xn--a-ecp.xn--"},
+		{"base58-encode", strings.Repeat("a", maxBase58Size+1)},
+		{"base58-decode", strings.Repeat("2", maxBase58Size+1)},
+	}
+
+	svc := NewHashService()
+	for _, tt := range tests {
+		if _, err := svc.Encode(&domain.EncodeRequest{Text: tt.text, Operation: tt.operation}); err == nil {
+			t.Errorf("%s %q: expected error", tt.operation, tt.text)
+		}
+	}
+}
+
+func TestEncode_Binary(t *testing.T) {
+	svc := NewHashService()
+
+	// Decoding binary data returns it encoded rather than as invalid UTF-8
+	result, err := svc.Encode(&domain.EncodeRequest{Text: "/9j/4A==", Operation: "base64-decode"})
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !result.Binary || result.Encoding != "base64" || result.Result != "/9j/4A==" {
+		t.Errorf("Unexpected binary result: %+v", result)
+	}
+
+	result, err = svc.Encode(&domain.EncodeRequest{Text: "/9j/4A==", Operation: "base64-decode", BinaryEncoding: stringPtr("hex")})
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !result.Binary || result.Encoding != "hex" || result.Result != "ffd8ffe0" {
+		t.Errorf("Unexpected binary result: %+v", result)
+	}
+
+	// Text results are not flagged
+	result, err = svc.Encode(&domain.EncodeRequest{Text: "aGk=", Operation: "base64-decode"})
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if result.Binary || result.Result != "hi" {
+		t.Errorf("Unexpected text result: %+v", result)
+	}
+
+	// Binary input given as hex
+	result, err = svc.Encode(&domain.EncodeRequest{Text: "ffd8ffe0", Operation: "base58-encode", InputEncoding: stringPtr("hex")})
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	raw, err := svc.EncodeRaw(&domain.EncodeRequest{Text: result.Result, Operation: "base58-decode"})
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if string(raw) != "\xff\xd8\xff\xe0" {
+		t.Errorf("Unexpected raw output %x", raw)
+	}
+}
@@ -0,0 +1,571 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxJSONDepth limits nesting like encoding/json does
+const maxJSONDepth = 10000
+
+var (
+	jsonNumberPattern  = regexp.MustCompile(`^-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][+-]?\d+)?`)
+	json5NumberPattern = regexp.MustCompile(`^[+-]?(?:0[xX][0-9a-fA-F]+|(?:0|[1-9]\d*)(?:\.\d*)?(?:[eE][+-]?\d+)?|\.\d+(?:[eE][+-]?\d+)?|Infinity|NaN)`)
+)
+
+// jsonNode is a parsed JSON value. Scalars keep their literal, normalized to
+// standard JSON; objects and arrays keep their members in source order.
+type jsonNode struct {
+	kind     byte // '{', '[' or 0 for scalars
+	literal  string
+	members  []*jsonMember
+	dangling []string // comments before the closing bracket
+}
+
+// jsonMember is an object member or array item with the comments around it
+type jsonMember struct {
+	key      string // JSON string literal of an object key
+	name     string // decoded object key, for sorting
+	value    *jsonNode
+	comments []string // comments on the lines before
+	trailing []string // comments on the same line after
+}
+
+// jsonScanner parses JSON, JSONC or JSON5 source
+type jsonScanner struct {
+	src    string
+	pos    int
+	syntax string
+	depth  int
+}
+
+// parseJSONDocument parses a document into its top-level value, with the
+// comments before and after it, and any comments on the lines after it
+func parseJSONDocument(content, syntax string) (*jsonMember, []string, error) {
+	s := &jsonScanner{src: content, syntax: syntax}
+	if strings.HasPrefix(content, "\uFEFF") {
+		s.pos = len("\uFEFF")
+	}
+
+	trailing, leading, err := s.skip()
+	if err != nil {
+		return nil, nil, err
+	}
+	root := &jsonMember{comments: append(trailing, leading...)}
+	if root.value, err = s.parseValue(); err != nil {
+		return nil, nil, err
+	}
+
+	trailing, footer, err := s.skip()
+	if err != nil {
+		return nil, nil, err
+	}
+	root.trailing = trailing
+	if s.pos < len(s.src) {
+		return nil, nil, s.errorAt(s.pos, "unexpected data after the top-level value")
+	}
+	return root, footer, nil
+}
+
+// errorAt reports a parse error at a byte offset
+func (s *jsonScanner) errorAt(offset int, format string, args ...any) error {
+	line, column := offsetPosition(s.src, int64(offset))
+	return &DataParseError{Format: s.syntax, Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+// expected reports what was expected at the current position and what was found
+func (s *jsonScanner) expected(what string) error {
+	if s.pos >= len(s.src) {
+		return s.errorAt(s.pos, "expected %s, found end of input", what)
+	}
+	r, _ := utf8.DecodeRuneInString(s.src[s.pos:])
+	return s.errorAt(s.pos, "expected %s, found %q", what, r)
+}
+
+// peek reports whether the next byte is c
+func (s *jsonScanner) peek(c byte) bool {
+	return s.pos < len(s.src) && s.src[s.pos] == c
+}
+
+// skip skips whitespace and comments, returning the comments on the same
+// line as the previous token and those on the lines after it
+func (s *jsonScanner) skip() (trailing, leading []string, err error) {
+	newline := false
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '\n':
+			newline = true
+			s.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			s.pos++
+		case c == '/' && s.pos+1 < len(s.src) && (s.src[s.pos+1] == '/' || s.src[s.pos+1] == '*'):
+			if s.syntax == DataFormatJSON {
+				return nil, nil, s.errorAt(s.pos, "comments are not allowed in JSON, use the jsonc or json5 syntax")
+			}
+			start := s.pos
+			if s.src[s.pos+1] == '/' {
+				if end := strings.IndexByte(s.src[s.pos:], '\n'); end >= 0 {
+					s.pos += end
+				} else {
+					s.pos = len(s.src)
+				}
+			} else {
+				end := strings.Index(s.src[s.pos+2:], "*/")
+				if end < 0 {
+					return nil, nil, s.errorAt(start, "unterminated comment")
+				}
+				s.pos += end + 4
+			}
+			comment := strings.TrimRight(s.src[start:s.pos], " \t\r")
+			if newline {
+				leading = append(leading, comment)
+			} else {
+				trailing = append(trailing, comment)
+			}
+		case s.syntax == DataFormatJSON5 && c >= utf8.RuneSelf:
+			// JSON5 allows any Unicode space
+			r, size := utf8.DecodeRuneInString(s.src[s.pos:])
+			if !unicode.IsSpace(r) && r != '\uFEFF' {
+				return trailing, leading, nil
+			}
+			if r == '\u2028' || r == '\u2029' {
+				newline = true
+			}
+			s.pos += size
+		case s.syntax == DataFormatJSON5 && (c == '\v' || c == '\f'):
+			s.pos++
+		default:
+			return trailing, leading, nil
+		}
+	}
+	return trailing, leading, nil
+}
+
+// parseValue parses any value
+func (s *jsonScanner) parseValue() (*jsonNode, error) {
+	if s.pos >= len(s.src) {
+		return nil, s.expected("a value")
+	}
+
+	switch c := s.src[s.pos]; {
+	case c == '{' || c == '[':
+		return s.parseContainer()
+	case c == '"' || (c == '\'' && s.syntax == DataFormatJSON5):
+		literal, _, err := s.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonNode{literal: literal}, nil
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9') || strings.HasPrefix(s.src[s.pos:], "Infinity") || strings.HasPrefix(s.src[s.pos:], "NaN"):
+		literal, err := s.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonNode{literal: literal}, nil
+	}
+
+	for _, literal := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(s.src[s.pos:], literal) {
+			s.pos += len(literal)
+			return &jsonNode{literal: literal}, nil
+		}
+	}
+	return nil, s.expected("a value")
+}
+
+// parseContainer parses an object or array, attaching comments on the lines
+// before a member to it and comments after it on the same line as trailing
+func (s *jsonScanner) parseContainer() (*jsonNode, error) {
+	start := s.pos
+	s.depth++
+	if s.depth > maxJSONDepth {
+		return nil, s.errorAt(start, "nesting is deeper than %d levels", maxJSONDepth)
+	}
+	defer func() { s.depth-- }()
+
+	node := &jsonNode{kind: s.src[s.pos]}
+	closing := byte(']')
+	if node.kind == '{' {
+		closing = '}'
+	}
+	s.pos++
+
+	var pending []string
+	comma := false
+	for {
+		trailing, leading, err := s.skip()
+		if err != nil {
+			return nil, err
+		}
+		pending = append(append(pending, trailing...), leading...)
+		if s.peek(closing) {
+			if comma && s.syntax == DataFormatJSON {
+				return nil, s.errorAt(s.pos, "trailing comma before '%c' is not allowed in JSON", closing)
+			}
+			s.pos++
+			node.dangling = pending
+			return node, nil
+		}
+
+		member := &jsonMember{comments: pending}
+		pending = nil
+		if node.kind == '{' {
+			if err := s.parseKey(member); err != nil {
+				return nil, err
+			}
+			// Comments around the colon move before the member
+			trailing, leading, err := s.skip()
+			if err != nil {
+				return nil, err
+			}
+			if !s.peek(':') {
+				return nil, s.expected("':' after object key")
+			}
+			s.pos++
+			member.comments = append(append(member.comments, trailing...), leading...)
+			if trailing, leading, err = s.skip(); err != nil {
+				return nil, err
+			}
+			member.comments = append(append(member.comments, trailing...), leading...)
+		}
+		if member.value, err = s.parseValue(); err != nil {
+			return nil, err
+		}
+		node.members = append(node.members, member)
+
+		if member.trailing, pending, err = s.skip(); err != nil {
+			return nil, err
+		}
+		comma = s.peek(',')
+		if comma {
+			s.pos++
+			trailing, leading, err := s.skip()
+			if err != nil {
+				return nil, err
+			}
+			if len(pending) == 0 {
+				member.trailing = append(member.trailing, trailing...)
+			} else {
+				pending = append(pending, trailing...)
+			}
+			pending = append(pending, leading...)
+			continue
+		}
+		if !s.peek(closing) {
+			return nil, s.expected(fmt.Sprintf("',' or '%c'", closing))
+		}
+	}
+}
+
+// parseKey parses an object key, which JSON5 allows to be an identifier
+func (s *jsonScanner) parseKey(member *jsonMember) error {
+	if s.peek('"') || (s.peek('\'') && s.syntax == DataFormatJSON5) {
+		var err error
+		member.key, member.name, err = s.parseString()
+		return err
+	}
+
+	if s.syntax == DataFormatJSON5 {
+		end := s.pos
+		for end < len(s.src) {
+			r, size := utf8.DecodeRuneInString(s.src[end:])
+			if r != '$' && r != '_' && !unicode.IsLetter(r) && (end == s.pos || !unicode.In(r, unicode.Digit, unicode.Mn, unicode.Mc, unicode.Pc)) {
+				break
+			}
+			end += size
+		}
+		if end > s.pos {
+			member.name = s.src[s.pos:end]
+			var buf bytes.Buffer
+			writeJSONString(&buf, member.name)
+			member.key = buf.String()
+			s.pos = end
+			return nil
+		}
+	}
+	return s.expected("a string key")
+}
+
+// parseString parses a string, returning its JSON literal and its value. JSON
+// strings keep their literal; JSON5 strings with single quotes or escapes JSON
+// lacks are written again.
+func (s *jsonScanner) parseString() (literal, value string, err error) {
+	start := s.pos
+	quote := s.src[s.pos]
+	json5 := s.syntax == DataFormatJSON5
+	rewrite := quote != '"'
+	s.pos++
+
+	var sb strings.Builder
+	for {
+		if s.pos >= len(s.src) {
+			return "", "", s.errorAt(start, "unterminated string")
+		}
+		c := s.src[s.pos]
+		switch {
+		case c == quote:
+			s.pos++
+			if !rewrite {
+				return s.src[start:s.pos], sb.String(), nil
+			}
+			var buf bytes.Buffer
+			writeJSONString(&buf, sb.String())
+			return buf.String(), sb.String(), nil
+		case c == '\n' || c == '\r':
+			return "", "", s.errorAt(start, "unterminated string")
+		case c < 0x20:
+			if !json5 {
+				return "", "", s.errorAt(s.pos, "control character %U in string", rune(c))
+			}
+			rewrite = true
+			sb.WriteByte(c)
+			s.pos++
+		case c == '\\':
+			escaped, err := s.parseEscape(&sb)
+			if err != nil {
+				return "", "", err
+			}
+			rewrite = rewrite || escaped
+		default:
+			_, size := utf8.DecodeRuneInString(s.src[s.pos:])
+			sb.WriteString(s.src[s.pos : s.pos+size])
+			s.pos += size
+		}
+	}
+}
+
+// parseEscape decodes an escape sequence, reporting whether it is a JSON5
+// escape that JSON lacks
+func (s *jsonScanner) parseEscape(sb *strings.Builder) (bool, error) {
+	start := s.pos
+	s.pos++
+	if s.pos >= len(s.src) {
+		return false, s.errorAt(start, "unterminated string")
+	}
+
+	c := s.src[s.pos]
+	s.pos++
+	if i := strings.IndexByte(`"\/bfnrt`, c); i >= 0 {
+		sb.WriteByte("\"\\/\b\f\n\r\t"[i])
+		return false, nil
+	}
+	if c == 'u' {
+		r, ok := s.hexRune(4)
+		if !ok {
+			return false, s.errorAt(start, "invalid unicode escape")
+		}
+		// Join surrogate pairs written as two escapes
+		if utf16.IsSurrogate(r) && strings.HasPrefix(s.src[s.pos:], `\u`) {
+			s.pos += 2
+			low, ok := s.hexRune(4)
+			if !ok {
+				return false, s.errorAt(s.pos-2, "invalid unicode escape")
+			}
+			if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+				r = pair
+			} else {
+				sb.WriteRune(unicode.ReplacementChar)
+				r = low
+			}
+		}
+		sb.WriteRune(r)
+		return false, nil
+	}
+
+	if s.syntax != DataFormatJSON5 {
+		return false, s.errorAt(start, "invalid escape sequence \\%c", c)
+	}
+	switch {
+	case c == '\'':
+		sb.WriteByte('\'')
+	case c == 'v':
+		sb.WriteByte('\v')
+	case c == '0' && (s.pos >= len(s.src) || s.src[s.pos] < '0' || s.src[s.pos] > '9'):
+		sb.WriteByte(0)
+	case c >= '0' && c <= '9':
+		return false, s.errorAt(start, "invalid escape sequence \\%c", c)
+	case c == 'x':
+		r, ok := s.hexRune(2)
+		if !ok {
+			return false, s.errorAt(start, "invalid hexadecimal escape")
+		}
+		sb.WriteRune(r)
+	case c == '\n':
+		// An escaped line break continues the string
+	case c == '\r':
+		if s.peek('\n') {
+			s.pos++
+		}
+	default:
+		s.pos--
+		r, size := utf8.DecodeRuneInString(s.src[s.pos:])
+		s.pos += size
+		if r != '\u2028' && r != '\u2029' {
+			sb.WriteRune(r)
+		}
+	}
+	return true, nil
+}
+
+// hexRune reads a character code of n hexadecimal digits
+func (s *jsonScanner) hexRune(n int) (rune, bool) {
+	if s.pos+n > len(s.src) {
+		return 0, false
+	}
+	code, err := strconv.ParseUint(s.src[s.pos:s.pos+n], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	s.pos += n
+	return rune(code), true
+}
+
+// parseNumber parses a number, keeping JSON literals as written and writing
+// JSON5 numbers in standard JSON
+func (s *jsonScanner) parseNumber() (string, error) {
+	start := s.pos
+	pattern := jsonNumberPattern
+	if s.syntax == DataFormatJSON5 {
+		pattern = json5NumberPattern
+	}
+	literal := pattern.FindString(s.src[s.pos:])
+	end := s.pos + len(literal)
+	if literal == "" || (end < len(s.src) && strings.IndexByte(".0123456789eExX", s.src[end]) >= 0) {
+		return "", s.errorAt(start, "invalid number")
+	}
+	s.pos = end
+	if s.syntax != DataFormatJSON5 {
+		return literal, nil
+	}
+
+	sign := ""
+	if literal[0] == '-' {
+		sign = "-"
+	}
+	body := strings.TrimLeft(literal, "+-")
+	switch {
+	case body == "Infinity" || body == "NaN":
+		return "", s.errorAt(start, "%s cannot be represented in JSON", literal)
+	case strings.HasPrefix(body, "0x") || strings.HasPrefix(body, "0X"):
+		n, _ := new(big.Int).SetString(body[2:], 16)
+		return sign + n.String(), nil
+	}
+	if strings.HasPrefix(body, ".") {
+		body = "0" + body
+	}
+	if i := strings.IndexByte(body, '.'); i >= 0 && (i == len(body)-1 || body[i+1] < '0' || body[i+1] > '9') {
+		body = body[:i] + body[i+1:]
+	}
+	return sign + body, nil
+}
+
+// sortJSONMembers sorts object keys recursively, keeping comments with their members
+func sortJSONMembers(node *jsonNode) {
+	if node.kind == '{' {
+		slices.SortStableFunc(node.members, func(a, b *jsonMember) int { return strings.Compare(a.name, b.name) })
+	}
+	for _, member := range node.members {
+		sortJSONMembers(member.value)
+	}
+}
+
+// jsonWriter writes parsed JSON, one member per line indented by indent
+// unless compact. Comments are only written on separate lines, so compact
+// output drops them.
+type jsonWriter struct {
+	buf      bytes.Buffer
+	indent   string
+	compact  bool
+	comments bool
+}
+
+// writeDocument writes the top-level value with its comments
+func (w *jsonWriter) writeDocument(root *jsonMember, footer []string) string {
+	comments := w.comments && !w.compact
+	if comments {
+		for _, comment := range root.comments {
+			w.buf.WriteString(comment + "\n")
+		}
+	}
+	w.writeNode(root.value, 0)
+	if comments {
+		for _, comment := range root.trailing {
+			w.buf.WriteString(" " + comment)
+		}
+		for _, comment := range footer {
+			w.buf.WriteString("\n" + comment)
+		}
+	}
+	return w.buf.String()
+}
+
+func (w *jsonWriter) newline(level int) {
+	w.buf.WriteByte('\n')
+	w.buf.WriteString(strings.Repeat(w.indent, level))
+}
+
+func (w *jsonWriter) writeNode(node *jsonNode, level int) {
+	if node.kind == 0 {
+		w.buf.WriteString(node.literal)
+		return
+	}
+
+	closing := byte(']')
+	if node.kind == '{' {
+		closing = '}'
+	}
+	comments := w.comments && !w.compact
+	if len(node.members) == 0 && (!comments || len(node.dangling) == 0) {
+		w.buf.WriteByte(node.kind)
+		w.buf.WriteByte(closing)
+		return
+	}
+
+	w.buf.WriteByte(node.kind)
+	for i, member := range node.members {
+		if !w.compact {
+			if comments {
+				for _, comment := range member.comments {
+					w.newline(level + 1)
+					w.buf.WriteString(comment)
+				}
+			}
+			w.newline(level + 1)
+		}
+		if node.kind == '{' {
+			w.buf.WriteString(member.key)
+			w.buf.WriteByte(':')
+			if !w.compact {
+				w.buf.WriteByte(' ')
+			}
+		}
+		w.writeNode(member.value, level+1)
+		if i < len(node.members)-1 {
+			w.buf.WriteByte(',')
+		}
+		if comments {
+			for _, comment := range member.trailing {
+				w.buf.WriteString(" " + comment)
+			}
+		}
+	}
+	if !w.compact {
+		if comments {
+			for _, comment := range node.dangling {
+				w.newline(level + 1)
+				w.buf.WriteString(comment)
+			}
+		}
+		w.newline(level)
+	}
+	w.buf.WriteByte(closing)
+}
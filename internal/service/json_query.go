@@ -0,0 +1,332 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/itchyny/gojq"
+)
+
+// JSON query languages
+const (
+	JSONQueryJSONPath = "jsonpath"
+	JSONQueryJQ       = "jq"
+)
+
+// Limits of a JSON query
+const (
+	jsonQueryTimeout       = 2 * time.Second
+	maxJSONQueryResults    = 10000
+	maxJSONQueryOutputSize = 4 << 20  // in bytes
+	maxJQArithmeticSize    = 64 << 20 // in bytes built by jq's + and * operators over one query
+)
+
+var (
+	// ErrJSONQueryTimeout is returned when a query runs longer than jsonQueryTimeout
+	ErrJSONQueryTimeout = errors.New("query timed out")
+	// ErrJSONQueryTooLarge is returned when a jq filter builds more than
+	// maxJQArithmeticSize bytes of strings, arrays and objects with + and *
+	ErrJSONQueryTooLarge = errors.New("query builds values that are too large")
+)
+
+// QueryJSON evaluates a JSONPath (RFC 9535) or jq query against a JSON
+// document. Results past the result count or output size limits are dropped
+// and the response is marked truncated.
+func (s *ConverterService) QueryJSON(ctx context.Context, req *domain.JSONQueryRequest) (*domain.JSONQueryResponse, error) {
+	language := JSONQueryJSONPath
+	if req.Language != nil {
+		language = *req.Language
+	}
+
+	document, err := decodeJSON(req.JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jsonQueryTimeout)
+	defer cancel()
+
+	response := &domain.JSONQueryResponse{Language: language, Results: []json.RawMessage{}}
+	size := 0
+	// add keeps a result, reporting false once the limits are reached
+	add := func(result []byte) bool {
+		if len(response.Results) >= maxJSONQueryResults || size+len(result) > maxJSONQueryOutputSize {
+			response.Truncated = true
+			return false
+		}
+		size += len(result)
+		response.Results = append(response.Results, result)
+		return true
+	}
+
+	switch language {
+	case JSONQueryJSONPath:
+		response.Paths = []string{}
+		err = runJSONPath(ctx, req.Query, document, func(path string, value any) bool {
+			var buf bytes.Buffer
+			if err := writeJSON(&buf, value, "", 0); err != nil || !add(buf.Bytes()) {
+				return false
+			}
+			response.Paths = append(response.Paths, path)
+			return true
+		})
+	case JSONQueryJQ:
+		err = runJQ(ctx, req.Query, document, add)
+	default:
+		return nil, fmt.Errorf("unsupported query language: %s", language)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s", ErrJSONQueryTimeout, jsonQueryTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Count = len(response.Results)
+	return response, nil
+}
+
+// runJSONPath passes each node a JSONPath query selects to emit, with its
+// normalized path, until emit returns false
+func runJSONPath(ctx context.Context, query string, document any, emit func(path string, value any) bool) error {
+	q, err := parseJSONPath(query)
+	if err != nil {
+		return err
+	}
+
+	ev := &jsonPathEvaluator{ctx: ctx, root: document}
+	nodes := ev.query(q, document)
+	if ev.err != nil {
+		return ev.err
+	}
+	for _, node := range nodes {
+		if !emit(node.path(), node.value) {
+			break
+		}
+	}
+	return nil
+}
+
+// runJQ passes each output of a jq filter to emit, until emit returns false.
+// Environment variables are not visible to the filter.
+func runJQ(ctx context.Context, query string, document any, emit func([]byte) bool) error {
+	parsed, err := gojq.Parse(query)
+	if err != nil {
+		var parseErr *gojq.ParseError
+		if errors.As(err, &parseErr) {
+			line, column := offsetPosition(query, int64(parseErr.Offset-len(parseErr.Token)))
+			return &DataParseError{Format: JSONQueryJQ, Line: line, Column: column, Message: err.Error()}
+		}
+		return &DataParseError{Format: JSONQueryJQ, Message: err.Error()}
+	}
+	budget := &jqBudget{remaining: maxJQArithmeticSize}
+	guardJQArithmetic(parsed)
+	code, err := gojq.Compile(parsed,
+		gojq.WithFunction(jqGuardedAdd, 2, 2, budget.add),
+		gojq.WithFunction(jqGuardedMultiply, 2, 2, budget.multiply),
+	)
+	if err != nil {
+		return &DataParseError{Format: JSONQueryJQ, Message: err.Error()}
+	}
+
+	iter := code.RunWithContext(ctx, toMapValue(document))
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, isErr := value.(error); isErr {
+			// halt stops without an error
+			var haltErr *gojq.HaltError
+			if errors.As(err, &haltErr) && haltErr.Value() == nil {
+				return nil
+			}
+			return fmt.Errorf("jq query failed: %w", err)
+		}
+		result, err := gojq.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode jq result: %w", err)
+		}
+		if !emit(result) {
+			return nil
+		}
+	}
+}
+
+// Names of the functions guardJQArithmetic routes + and * through. They
+// cannot be written in a filter, so a filter cannot redefine them.
+const (
+	jqGuardedAdd      = "gopilot.add"
+	jqGuardedMultiply = "gopilot.multiply"
+)
+
+// guardJQArithmetic rewrites every l + r and l * r in a parsed jq filter to
+// gopilot.add(l; r) | .[0] + .[1], and likewise for *, so the size of each
+// result is charged to a jqBudget before it is built. Update assignments such
+// as l += r are expanded the way gojq compiles them, to
+// r as $x | l |= . + $x, first.
+func guardJQArithmetic(query *gojq.Query) {
+	walkJQQueries(reflect.ValueOf(query), func(q *gojq.Query) {
+		switch q.Op {
+		case gojq.OpAdd:
+			*q = *guardedJQOperation(jqGuardedAdd, q.Left, q.Op, q.Right)
+		case gojq.OpMul:
+			*q = *guardedJQOperation(jqGuardedMultiply, q.Left, q.Op, q.Right)
+		case gojq.OpUpdateAdd, gojq.OpUpdateMul:
+			name, op := jqGuardedAdd, gojq.OpAdd
+			if q.Op == gojq.OpUpdateMul {
+				name, op = jqGuardedMultiply, gojq.OpMul
+			}
+			const rhs = "$gopilot.rhs"
+			identity := &gojq.Query{Term: &gojq.Term{Type: gojq.TermTypeIdentity}}
+			variable := &gojq.Query{Term: &gojq.Term{Type: gojq.TermTypeFunc, Func: &gojq.Func{Name: rhs}}}
+			*q = gojq.Query{Term: &gojq.Term{
+				Type:  gojq.TermTypeQuery,
+				Query: q.Right,
+				SuffixList: []*gojq.Suffix{{Bind: &gojq.Bind{
+					Patterns: []*gojq.Pattern{{Name: rhs}},
+					Body:     &gojq.Query{Left: q.Left, Op: gojq.OpModify, Right: guardedJQOperation(name, identity, op, variable)},
+				}}},
+			}}
+		}
+	})
+}
+
+// guardedJQOperation builds name(l; r) | .[0] op .[1]. Operands are evaluated
+// in the same order as for l op r.
+func guardedJQOperation(name string, l *gojq.Query, op gojq.Operator, r *gojq.Query) *gojq.Query {
+	index := func(i string) *gojq.Query {
+		return &gojq.Query{Term: &gojq.Term{
+			Type:  gojq.TermTypeIndex,
+			Index: &gojq.Index{Start: &gojq.Query{Term: &gojq.Term{Type: gojq.TermTypeNumber, Number: i}}},
+		}}
+	}
+	return &gojq.Query{
+		Left: &gojq.Query{Term: &gojq.Term{Type: gojq.TermTypeFunc, Func: &gojq.Func{Name: name, Args: []*gojq.Query{l, r}}}},
+		Op:   gojq.OpPipe,
+		Right: &gojq.Query{
+			Left:  index("0"),
+			Op:    op,
+			Right: index("1"),
+		},
+	}
+}
+
+// walkJQQueries calls fn on every query node of a jq AST, children first
+func walkJQQueries(v reflect.Value, fn func(*gojq.Query)) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		walkJQQueries(v.Elem(), fn)
+		if q, ok := v.Interface().(*gojq.Query); ok {
+			fn(q)
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				walkJQQueries(v.Field(i), fn)
+			}
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			walkJQQueries(v.Index(i), fn)
+		}
+	}
+}
+
+// jqBudget tracks how many bytes the + and * operators of a jq filter may
+// still build. Arrays and objects are charged 16 bytes per element.
+type jqBudget struct {
+	remaining int
+}
+
+// add charges for concatenating strings, arrays or objects and returns the
+// operands for the real operator
+func (b *jqBudget) add(_ any, args []any) any {
+	return b.charge(args, jqValueSize(args[0])+jqValueSize(args[1]))
+}
+
+// multiply charges for repeating a string or merging objects and returns the
+// operands for the real operator
+func (b *jqBudget) multiply(_ any, args []any) any {
+	size := jqValueSize(args[0]) + jqValueSize(args[1])
+	if s, ok := args[0].(string); ok {
+		size = jqRepeatSize(s, args[1])
+	} else if s, ok := args[1].(string); ok {
+		size = jqRepeatSize(s, args[0])
+	}
+	return b.charge(args, size)
+}
+
+func (b *jqBudget) charge(args []any, size int) any {
+	if size > b.remaining {
+		return fmt.Errorf("%w: + and * may build at most %d MiB", ErrJSONQueryTooLarge, maxJQArithmeticSize>>20)
+	}
+	b.remaining -= size
+	return args
+}
+
+// jqValueSize estimates the bytes needed to hold a copy of a string, array
+// or object, without its nested values
+func jqValueSize(v any) int {
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []any:
+		return len(v) * 16
+	case map[string]any:
+		return len(v) * 16
+	default:
+		return 0
+	}
+}
+
+// jqRepeatSize returns the length of s repeated n times, saturating instead
+// of overflowing. Non-numeric counts are left to the operator to reject.
+func jqRepeatSize(s string, n any) int {
+	var count float64
+	switch n := n.(type) {
+	case int:
+		count = float64(n)
+	case float64:
+		count = n
+	case *big.Int:
+		count, _ = new(big.Float).SetInt(n).Float64()
+	default:
+		return 0
+	}
+	if !(count > 0) {
+		return 0
+	}
+	return int(min(count*float64(len(s)), maxJQArithmeticSize+1))
+}
+
+// toMapValue converts decoded JSON to the plain maps and slices gojq and the
+// schema validator work on. Number literals are kept so no precision is lost.
+func toMapValue(value any) any {
+	switch v := value.(type) {
+	case *orderedMap:
+		m := make(map[string]any, len(v.keys))
+		for _, key := range v.keys {
+			m[key] = toMapValue(v.values[key])
+		}
+		return m
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = toMapValue(item)
+		}
+		return items
+	default:
+		return v
+	}
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// JSON Schema drafts
+const (
+	JSONSchemaDraft7    = "draft-07"
+	JSONSchemaDraft2020 = "2020-12"
+)
+
+// jsonSchemaURL is where the submitted schema is registered. A URN keeps
+// server paths out of error messages and gives relative $refs nowhere to go.
+const jsonSchemaURL = "urn:gopilot:schema"
+
+// ErrJSONSchemaTimeout is returned when validation or inference runs longer
+// than jsonQueryTimeout
+var ErrJSONSchemaTimeout = errors.New("JSON Schema processing timed out")
+
+var (
+	jsonSchemaDrafts = map[string]*jsonschema.Draft{
+		JSONSchemaDraft7:    jsonschema.Draft7,
+		JSONSchemaDraft2020: jsonschema.Draft2020,
+	}
+	jsonSchemaMetaschemas = map[string]string{
+		JSONSchemaDraft7:    "http://json-schema.org/draft-07/schema#",
+		JSONSchemaDraft2020: "https://json-schema.org/draft/2020-12/schema",
+	}
+	jsonSchemaPrinter  = message.NewPrinter(language.English)
+	jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+	uuidPattern        = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// noSchemaLoader refuses every $ref outside the submitted schema, so schemas
+// cannot make the server read files or fetch URLs
+type noSchemaLoader struct{}
+
+func (noSchemaLoader) Load(url string) (any, error) {
+	return nil, errors.New("only references within the schema are supported")
+}
+
+// deadlineVocabulary has no keywords. Its extension is compiled into every
+// subschema, so validation checks ctx each time it enters one.
+func deadlineVocabulary(ctx context.Context) *jsonschema.Vocabulary {
+	return &jsonschema.Vocabulary{
+		URL:    "urn:gopilot:vocab:deadline",
+		Schema: emptyJSONSchema(),
+		Compile: func(*jsonschema.CompilerContext, map[string]any) (jsonschema.SchemaExt, error) {
+			return deadlineCheck{ctx}, nil
+		},
+	}
+}
+
+// emptyJSONSchema is the metaschema of the deadline vocabulary
+var emptyJSONSchema = sync.OnceValue(func() *jsonschema.Schema {
+	compiler := jsonschema.NewCompiler()
+	_ = compiler.AddResource("urn:gopilot:vocab:deadline:schema", map[string]any{})
+	return compiler.MustCompile("urn:gopilot:vocab:deadline:schema")
+})
+
+// deadlineCheck stops validation by panicking once its context is done; the
+// validator offers no other way out
+type deadlineCheck struct {
+	ctx context.Context
+}
+
+// validationStopped carries the context error out of the validator
+type validationStopped struct {
+	err error
+}
+
+func (d deadlineCheck) Validate(*jsonschema.ValidatorContext, any) {
+	if err := d.ctx.Err(); err != nil {
+		panic(validationStopped{err})
+	}
+}
+
+// validateSchema validates value, returning the context error when a
+// deadlineCheck stopped it
+func validateSchema(schema *jsonschema.Schema, value any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stopped, ok := r.(validationStopped)
+			if !ok {
+				panic(r)
+			}
+			err = stopped.err
+		}
+	}()
+	return schema.Validate(value)
+}
+
+// jsonSchemaError reports a timed out context as ErrJSONSchemaTimeout
+func jsonSchemaError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", ErrJSONSchemaTimeout, jsonQueryTimeout)
+	}
+	return err
+}
+
+// ValidateJSON validates a JSON document against a JSON Schema. The draft is
+// taken from $schema when present. Every failing keyword is reported, not
+// just the first one. Validation stops after jsonQueryTimeout.
+func (s *ConverterService) ValidateJSON(ctx context.Context, req *domain.JSONValidateRequest) (*domain.JSONValidateResponse, error) {
+	draft := JSONSchemaDraft2020
+	if req.Draft != nil {
+		draft = *req.Draft
+	}
+
+	document, err := decodeJSON(req.JSON)
+	if err != nil {
+		return nil, err
+	}
+	schemaDocument, err := decodeJSON(req.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jsonQueryTimeout)
+	defer cancel()
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonSchemaDrafts[draft])
+	compiler.UseLoader(noSchemaLoader{})
+	// Custom vocabularies only apply to 2019-09 and later drafts when asserted
+	compiler.RegisterVocabulary(deadlineVocabulary(ctx))
+	compiler.AssertVocabs()
+	if req.AssertFormat != nil && *req.AssertFormat {
+		compiler.AssertFormat()
+	}
+	if err := compiler.AddResource(jsonSchemaURL, toMapValue(schemaDocument)); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	schema, err := compiler.Compile(jsonSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	response := &domain.JSONValidateResponse{Valid: true, Errors: []domain.JSONSchemaError{}}
+	err = validateSchema(schema, toMapValue(document))
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		response.Valid = false
+		response.Errors = schemaErrors(validationErr, response.Errors)
+		// The validator walks properties in map order, so sort for stable output
+		slices.SortStableFunc(response.Errors, func(a, b domain.JSONSchemaError) int {
+			return cmp.Or(strings.Compare(a.InstancePath, b.InstancePath), strings.Compare(a.SchemaPath, b.SchemaPath))
+		})
+	} else if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, jsonSchemaError(ctxErr)
+	} else if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	return response, nil
+}
+
+// schemaErrors flattens a validation error tree. Wrappers for the schema
+// itself, groups and $refs only carry their causes and are left out.
+func schemaErrors(err *jsonschema.ValidationError, errs []domain.JSONSchemaError) []domain.JSONSchemaError {
+	switch err.ErrorKind.(type) {
+	case *kind.Schema, *kind.Group, *kind.Reference:
+	default:
+		errs = append(errs, domain.JSONSchemaError{
+			InstancePath: jsonPointer(err.InstanceLocation),
+			SchemaPath:   schemaPath(err.SchemaURL, err.ErrorKind.KeywordPath()),
+			Message:      err.ErrorKind.LocalizedString(jsonSchemaPrinter),
+		})
+	}
+	for _, cause := range err.Causes {
+		errs = schemaErrors(cause, errs)
+	}
+	return errs
+}
+
+// schemaPath locates a keyword as a JSON Pointer into the submitted schema,
+// or as an absolute URL for keywords in a subschema with its own $id
+func schemaPath(schemaURL string, keywordPath []string) string {
+	base, fragment, _ := strings.Cut(schemaURL, "#")
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		fragment = unescaped
+	}
+	path := fragment + jsonPointer(keywordPath)
+	if base == jsonSchemaURL {
+		return path
+	}
+	return base + "#" + path
+}
+
+// jsonPointer joins tokens into an RFC 6901 JSON Pointer
+func jsonPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(jsonPointerEscaper.Replace(token))
+	}
+	return sb.String()
+}
+
+// InferJSONSchema infers a JSON Schema that all samples satisfy. Types seen
+// across samples are merged, object keys present in every sample are
+// required and array items share one merged schema. Inference stops after
+// jsonQueryTimeout.
+func (s *ConverterService) InferJSONSchema(ctx context.Context, req *domain.JSONSchemaInferRequest) (*domain.JSONSchemaInferResponse, error) {
+	draft := JSONSchemaDraft2020
+	if req.Draft != nil {
+		draft = *req.Draft
+	}
+	formats := req.Formats == nil || *req.Formats
+
+	ctx, cancel := context.WithTimeout(ctx, jsonQueryTimeout)
+	defer cancel()
+
+	shape := &schemaShape{}
+	for i, sample := range req.Samples {
+		// Samples are bounded in size, so checking between them is enough
+		if err := ctx.Err(); err != nil {
+			return nil, jsonSchemaError(err)
+		}
+		value, err := decodeJSON(sample)
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %w", i+1, err)
+		}
+		shape.add(value, formats)
+	}
+
+	schema := shape.schema()
+	schema.keys = slices.Insert(schema.keys, 0, "$schema")
+	schema.values["$schema"] = jsonSchemaMetaschemas[draft]
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, schema, "  ", 0); err != nil {
+		return nil, err
+	}
+	return &domain.JSONSchemaInferResponse{Schema: buf.Bytes()}, nil
+}
+
+// Inferred types in the order they are listed in "type"
+var schemaTypes = []string{"null", "boolean", "integer", "number", "string", "array", "object"}
+
+// schemaShape accumulates the values seen at one place in the samples
+type schemaShape struct {
+	count      int             // values merged
+	types      map[string]bool // JSON Schema types seen
+	objects    int             // objects merged, to tell required keys
+	keys       []string        // object keys in the order first seen
+	properties map[string]*schemaShape
+	items      *schemaShape
+	strings    int    // strings merged
+	format     string // format every string matched so far
+}
+
+func (sh *schemaShape) add(value any, formats bool) {
+	if sh.types == nil {
+		sh.types = make(map[string]bool)
+	}
+	sh.count++
+
+	switch v := value.(type) {
+	case nil:
+		sh.types["null"] = true
+	case bool:
+		sh.types["boolean"] = true
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			sh.types["number"] = true
+		} else {
+			sh.types["integer"] = true
+		}
+	case string:
+		sh.types["string"] = true
+		format := ""
+		if formats {
+			format = stringFormat(v)
+		}
+		// A format sticks only while every string matches it
+		if sh.strings == 0 {
+			sh.format = format
+		} else if sh.format != format {
+			sh.format = ""
+		}
+		sh.strings++
+	case []any:
+		sh.types["array"] = true
+		for _, item := range v {
+			if sh.items == nil {
+				sh.items = &schemaShape{}
+			}
+			sh.items.add(item, formats)
+		}
+	case *orderedMap:
+		sh.types["object"] = true
+		sh.objects++
+		if sh.properties == nil {
+			sh.properties = make(map[string]*schemaShape)
+		}
+		for _, key := range v.keys {
+			property, ok := sh.properties[key]
+			if !ok {
+				property = &schemaShape{}
+				sh.properties[key] = property
+				sh.keys = append(sh.keys, key)
+			}
+			property.add(v.values[key], formats)
+		}
+	}
+}
+
+// schema writes the accumulated shape as a JSON Schema
+func (sh *schemaShape) schema() *orderedMap {
+	schema := newOrderedMap()
+
+	var types []any
+	for _, t := range schemaTypes {
+		// Integers are numbers too, so "number" alone covers both
+		if sh.types[t] && (t != "integer" || !sh.types["number"]) {
+			types = append(types, t)
+		}
+	}
+	switch len(types) {
+	case 0:
+		// Only reached for items of arrays that were always empty
+	case 1:
+		schema.set("type", types[0])
+	default:
+		schema.set("type", types)
+	}
+
+	if sh.format != "" {
+		schema.set("format", sh.format)
+	}
+	if len(sh.keys) > 0 {
+		properties := newOrderedMap()
+		var required []any
+		for _, key := range sh.keys {
+			property := sh.properties[key]
+			properties.set(key, property.schema())
+			if property.count == sh.objects {
+				required = append(required, key)
+			}
+		}
+		schema.set("properties", properties)
+		if len(required) > 0 {
+			schema.set("required", required)
+		}
+	}
+	if sh.items != nil {
+		schema.set("items", sh.items.schema())
+	}
+	return schema
+}
+
+// stringFormat returns the JSON Schema format a string matches, if any
+func stringFormat(s string) string {
+	if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return "date-time"
+	}
+	if _, err := time.Parse(time.DateOnly, s); err == nil {
+		return "date"
+	}
+	if uuidPattern.MatchString(s) {
+		return "uuid"
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		if strings.Contains(s, ":") {
+			return "ipv6"
+		}
+		return "ipv4"
+	}
+	if address, err := mail.ParseAddress(s); err == nil && address.Name == "" && address.Address == s {
+		return "email"
+	}
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		return "uri"
+	}
+	return ""
+}
@@ -0,0 +1,1121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// JSONPath queries as defined by RFC 9535, evaluated over values from decodeJSON
+
+// maxJSONPathInteger is the largest index or slice bound, the I-JSON integer range
+const maxJSONPathInteger = 1<<53 - 1
+
+// maxJSONPathNodes limits the nodes a query may select at any step
+const maxJSONPathNodes = 1 << 20
+
+// jsonPathType is the declared type of a filter expression
+type jsonPathType int
+
+const (
+	jsonPathValueType jsonPathType = iota
+	jsonPathLogicalType
+	jsonPathNodesType
+)
+
+// jsonPathFunctions are the function extensions of RFC 9535 with their
+// parameter and result types
+var jsonPathFunctions = map[string]struct {
+	params []jsonPathType
+	result jsonPathType
+}{
+	"length": {[]jsonPathType{jsonPathValueType}, jsonPathValueType},
+	"count":  {[]jsonPathType{jsonPathNodesType}, jsonPathValueType},
+	"match":  {[]jsonPathType{jsonPathValueType, jsonPathValueType}, jsonPathLogicalType},
+	"search": {[]jsonPathType{jsonPathValueType, jsonPathValueType}, jsonPathLogicalType},
+	"value":  {[]jsonPathType{jsonPathNodesType}, jsonPathValueType},
+}
+
+// jsonPathQuery is a query from the root ($) or from the current node (@)
+type jsonPathQuery struct {
+	relative bool
+	segments []jsonPathSegment
+}
+
+// jsonPathSegment selects children, or descendants, of each input node
+type jsonPathSegment struct {
+	descendant bool
+	selectors  []jsonPathSelector
+}
+
+type jsonPathSelectorKind int
+
+const (
+	jsonPathName jsonPathSelectorKind = iota
+	jsonPathWildcard
+	jsonPathIndex
+	jsonPathSlice
+	jsonPathFilter
+)
+
+// jsonPathSelector is one selector of a segment. Slice bounds are nil when omitted.
+type jsonPathSelector struct {
+	kind             jsonPathSelectorKind
+	name             string
+	index            int64
+	start, end, step *int64
+	filter           jsonPathExpr
+}
+
+// jsonPathExpr is a filter expression: a literal, a query, a function call,
+// a comparison or a logical operation
+type jsonPathExpr interface {
+	exprType() jsonPathType
+}
+
+type jsonPathLiteral struct {
+	value any
+}
+
+type jsonPathComparison struct {
+	op          string
+	left, right jsonPathExpr
+}
+
+type jsonPathLogicalOp struct {
+	and      bool
+	operands []jsonPathExpr
+}
+
+type jsonPathNot struct {
+	operand jsonPathExpr
+}
+
+type jsonPathCall struct {
+	name string
+	args []jsonPathExpr
+}
+
+func (*jsonPathQuery) exprType() jsonPathType      { return jsonPathNodesType }
+func (*jsonPathLiteral) exprType() jsonPathType    { return jsonPathValueType }
+func (*jsonPathComparison) exprType() jsonPathType { return jsonPathLogicalType }
+func (*jsonPathLogicalOp) exprType() jsonPathType  { return jsonPathLogicalType }
+func (*jsonPathNot) exprType() jsonPathType        { return jsonPathLogicalType }
+func (c *jsonPathCall) exprType() jsonPathType     { return jsonPathFunctions[c.name].result }
+
+// singular reports whether a query selects at most one node, having only
+// name and index selectors
+func (q *jsonPathQuery) singular() bool {
+	for _, segment := range q.segments {
+		if segment.descendant || len(segment.selectors) != 1 {
+			return false
+		}
+		if kind := segment.selectors[0].kind; kind != jsonPathName && kind != jsonPathIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonPathParser parses a query string
+type jsonPathParser struct {
+	src string
+	pos int
+}
+
+// parseJSONPath parses a JSONPath query, reporting errors with their column
+func parseJSONPath(query string) (*jsonPathQuery, error) {
+	p := &jsonPathParser{src: query}
+	if !p.peek('$') {
+		return nil, p.expected("'$'")
+	}
+	p.pos++
+	q, err := p.parseSegments(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorAt(p.pos, "unexpected %s", p.found())
+	}
+	return q, nil
+}
+
+func (p *jsonPathParser) errorAt(offset int, format string, args ...any) error {
+	line, column := offsetPosition(p.src, int64(offset))
+	return &DataParseError{Format: JSONQueryJSONPath, Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *jsonPathParser) found() string {
+	if p.pos >= len(p.src) {
+		return "end of query"
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return fmt.Sprintf("%q", r)
+}
+
+func (p *jsonPathParser) expected(what string) error {
+	return p.errorAt(p.pos, "expected %s, found %s", what, p.found())
+}
+
+func (p *jsonPathParser) peek(c byte) bool {
+	return p.pos < len(p.src) && p.src[p.pos] == c
+}
+
+// skipBlank skips the spaces, tabs and line breaks allowed between tokens
+func (p *jsonPathParser) skipBlank() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\n\r", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// parseSegments parses the segments after $ or @
+func (p *jsonPathParser) parseSegments(relative bool) (*jsonPathQuery, error) {
+	q := &jsonPathQuery{relative: relative}
+	for {
+		// Blanks are allowed between segments, but may belong to an enclosing expression
+		save := p.pos
+		p.skipBlank()
+
+		var segment jsonPathSegment
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], ".."):
+			p.pos += 2
+			segment.descendant = true
+			if p.peek('[') {
+				selectors, err := p.parseBracketed()
+				if err != nil {
+					return nil, err
+				}
+				segment.selectors = selectors
+				break
+			}
+			selector, err := p.parseDotted()
+			if err != nil {
+				return nil, err
+			}
+			segment.selectors = []jsonPathSelector{selector}
+		case p.peek('.'):
+			p.pos++
+			selector, err := p.parseDotted()
+			if err != nil {
+				return nil, err
+			}
+			segment.selectors = []jsonPathSelector{selector}
+		case p.peek('['):
+			selectors, err := p.parseBracketed()
+			if err != nil {
+				return nil, err
+			}
+			segment.selectors = selectors
+		default:
+			p.pos = save
+			return q, nil
+		}
+		q.segments = append(q.segments, segment)
+	}
+}
+
+// parseDotted parses the wildcard or member name after a dot
+func (p *jsonPathParser) parseDotted() (jsonPathSelector, error) {
+	if p.peek('*') {
+		p.pos++
+		return jsonPathSelector{kind: jsonPathWildcard}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		nameChar := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= 0x80 && r != utf8.RuneError) ||
+			(p.pos > start && r >= '0' && r <= '9')
+		if !nameChar {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return jsonPathSelector{}, p.expected("a member name or '*'")
+	}
+	return jsonPathSelector{kind: jsonPathName, name: p.src[start:p.pos]}, nil
+}
+
+// parseBracketed parses a comma separated list of selectors in brackets
+func (p *jsonPathParser) parseBracketed() ([]jsonPathSelector, error) {
+	p.pos++
+	var selectors []jsonPathSelector
+	for {
+		p.skipBlank()
+		selector, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+
+		p.skipBlank()
+		switch {
+		case p.peek(','):
+			p.pos++
+		case p.peek(']'):
+			p.pos++
+			return selectors, nil
+		default:
+			return nil, p.expected("',' or ']'")
+		}
+	}
+}
+
+// parseSelector parses a name, wildcard, index, slice or filter selector
+func (p *jsonPathParser) parseSelector() (jsonPathSelector, error) {
+	switch {
+	case p.peek('\'') || p.peek('"'):
+		name, err := p.parseString()
+		return jsonPathSelector{kind: jsonPathName, name: name}, err
+	case p.peek('*'):
+		p.pos++
+		return jsonPathSelector{kind: jsonPathWildcard}, nil
+	case p.peek('?'):
+		p.pos++
+		p.skipBlank()
+		filter, err := p.parseLogical()
+		if err != nil {
+			return jsonPathSelector{}, err
+		}
+		return jsonPathSelector{kind: jsonPathFilter, filter: filter}, nil
+	}
+
+	// Index or slice: [start S] ":" S [end S] [":" [S step]]
+	var bounds [3]*int64
+	if p.peek('-') || (p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9') {
+		n, err := p.parseInteger()
+		if err != nil {
+			return jsonPathSelector{}, err
+		}
+		bounds[0] = &n
+	}
+	save := p.pos
+	p.skipBlank()
+	if !p.peek(':') {
+		p.pos = save
+		if bounds[0] == nil {
+			return jsonPathSelector{}, p.expected("a selector")
+		}
+		return jsonPathSelector{kind: jsonPathIndex, index: *bounds[0]}, nil
+	}
+
+	for i := 1; i < 3 && p.peek(':'); i++ {
+		p.pos++
+		save = p.pos
+		p.skipBlank()
+		if p.peek('-') || (p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9') {
+			n, err := p.parseInteger()
+			if err != nil {
+				return jsonPathSelector{}, err
+			}
+			bounds[i] = &n
+			save = p.pos
+			p.skipBlank()
+		}
+		if i == 2 || !p.peek(':') {
+			p.pos = save
+		}
+	}
+	return jsonPathSelector{kind: jsonPathSlice, start: bounds[0], end: bounds[1], step: bounds[2]}, nil
+}
+
+// parseInteger parses an index or slice bound, without leading zeros or -0
+func (p *jsonPathParser) parseInteger() (int64, error) {
+	start := p.pos
+	if p.peek('-') {
+		p.pos++
+	}
+	digits := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	text := p.src[start:p.pos]
+	if p.pos == digits || (p.src[digits] == '0' && (p.pos-digits > 1 || digits > start)) {
+		return 0, p.errorAt(start, "invalid integer %q", text)
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n > maxJSONPathInteger || n < -maxJSONPathInteger {
+		return 0, p.errorAt(start, "integer %s is out of range", text)
+	}
+	return n, nil
+}
+
+// parseString parses a single or double quoted string literal
+func (p *jsonPathParser) parseString() (string, error) {
+	start := p.pos
+	quote := p.src[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			return "", p.errorAt(start, "unterminated string")
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c < 0x20:
+			return "", p.errorAt(p.pos, "control character %U in string", rune(c))
+		case c == '\\':
+			if err := p.parseEscape(&sb, quote); err != nil {
+				return "", err
+			}
+		default:
+			_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			sb.WriteString(p.src[p.pos : p.pos+size])
+			p.pos += size
+		}
+	}
+}
+
+// parseEscape decodes an escape sequence. Surrogates must come in pairs.
+func (p *jsonPathParser) parseEscape(sb *strings.Builder, quote byte) error {
+	start := p.pos
+	p.pos++
+	if p.pos >= len(p.src) {
+		return p.errorAt(start, "unterminated string")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	if i := strings.IndexByte(`\/bfnrt`, c); i >= 0 {
+		sb.WriteByte("\\/\b\f\n\r\t"[i])
+		return nil
+	}
+	if c == quote {
+		sb.WriteByte(c)
+		return nil
+	}
+	if c != 'u' {
+		return p.errorAt(start, "invalid escape sequence \\%c", c)
+	}
+
+	hex := func() (rune, bool) {
+		if p.pos+4 > len(p.src) {
+			return 0, false
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 16)
+		if err != nil {
+			return 0, false
+		}
+		p.pos += 4
+		return rune(code), true
+	}
+	r, ok := hex()
+	switch {
+	case !ok:
+		return p.errorAt(start, "invalid unicode escape")
+	case r >= 0xDC00 && r <= 0xDFFF:
+		return p.errorAt(start, "unpaired surrogate in unicode escape")
+	case r >= 0xD800 && r <= 0xDBFF:
+		if !strings.HasPrefix(p.src[p.pos:], `\u`) {
+			return p.errorAt(start, "unpaired surrogate in unicode escape")
+		}
+		p.pos += 2
+		low, ok := hex()
+		if !ok || low < 0xDC00 || low > 0xDFFF {
+			return p.errorAt(start, "unpaired surrogate in unicode escape")
+		}
+		r = utf16.DecodeRune(r, low)
+	}
+	sb.WriteRune(r)
+	return nil
+}
+
+// parseLogical parses a logical-or expression, the loosest binding
+func (p *jsonPathParser) parseLogical() (jsonPathExpr, error) {
+	return p.parseLogicalOp(false)
+}
+
+// parseLogicalOp parses operands joined by || or, when and is set, by &&
+func (p *jsonPathParser) parseLogicalOp(and bool) (jsonPathExpr, error) {
+	operator, parseOperand := "||", func() (jsonPathExpr, error) { return p.parseLogicalOp(true) }
+	if and {
+		operator, parseOperand = "&&", p.parseBasic
+	}
+
+	first, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	operands := []jsonPathExpr{first}
+	for {
+		save := p.pos
+		p.skipBlank()
+		if !strings.HasPrefix(p.src[p.pos:], operator) {
+			p.pos = save
+			break
+		}
+		p.pos += len(operator)
+		p.skipBlank()
+		operand, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &jsonPathLogicalOp{and: and, operands: operands}, nil
+}
+
+// parseBasic parses a parenthesized expression, a comparison or a test of
+// a query or function, each optionally negated
+func (p *jsonPathParser) parseBasic() (jsonPathExpr, error) {
+	if p.peek('!') {
+		p.pos++
+		p.skipBlank()
+		start := p.pos
+		var operand jsonPathExpr
+		var err error
+		if p.peek('(') {
+			operand, err = p.parseParenthesized()
+		} else if operand, err = p.parsePrimary(); err == nil {
+			err = p.checkTest(operand, start)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathNot{operand: operand}, nil
+	}
+	if p.peek('(') {
+		return p.parseParenthesized()
+	}
+
+	start := p.pos
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	save := p.pos
+	p.skipBlank()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !strings.HasPrefix(p.src[p.pos:], op) {
+			continue
+		}
+		if err := p.checkComparable(left, start); err != nil {
+			return nil, err
+		}
+		p.pos += len(op)
+		p.skipBlank()
+		rightStart := p.pos
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkComparable(right, rightStart); err != nil {
+			return nil, err
+		}
+		return &jsonPathComparison{op: op, left: left, right: right}, nil
+	}
+	p.pos = save
+	return left, p.checkTest(left, start)
+}
+
+func (p *jsonPathParser) parseParenthesized() (jsonPathExpr, error) {
+	p.pos++
+	p.skipBlank()
+	expr, err := p.parseLogical()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if !p.peek(')') {
+		return nil, p.expected("')'")
+	}
+	p.pos++
+	return expr, nil
+}
+
+// checkComparable checks that a side of a comparison is a literal, a
+// singular query or a function returning a value
+func (p *jsonPathParser) checkComparable(expr jsonPathExpr, offset int) error {
+	switch e := expr.(type) {
+	case *jsonPathQuery:
+		if !e.singular() {
+			return p.errorAt(offset, "only singular queries can be compared")
+		}
+	case *jsonPathCall:
+		if e.exprType() != jsonPathValueType {
+			return p.errorAt(offset, "the result of %s() cannot be compared", e.name)
+		}
+	}
+	return nil
+}
+
+// checkTest checks that an expression tested on its own is a query or a
+// function returning a logical value or nodes
+func (p *jsonPathParser) checkTest(expr jsonPathExpr, offset int) error {
+	switch e := expr.(type) {
+	case *jsonPathLiteral:
+		return p.errorAt(offset, "a literal must be compared")
+	case *jsonPathCall:
+		if e.exprType() == jsonPathValueType {
+			return p.errorAt(offset, "the result of %s() must be compared", e.name)
+		}
+	}
+	return nil
+}
+
+// parsePrimary parses a literal, a query or a function call
+func (p *jsonPathParser) parsePrimary() (jsonPathExpr, error) {
+	if p.pos >= len(p.src) {
+		return nil, p.expected("a literal, query or function")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '@' || c == '$':
+		p.pos++
+		return p.parseSegments(c == '@')
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathLiteral{value: s}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		literal := jsonNumberPattern.FindString(p.src[p.pos:])
+		end := p.pos + len(literal)
+		if literal == "" || (end < len(p.src) && strings.IndexByte(".0123456789eE", p.src[end]) >= 0) {
+			return nil, p.errorAt(p.pos, "invalid number")
+		}
+		p.pos = end
+		return &jsonPathLiteral{value: json.Number(literal)}, nil
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' || p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '_') {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.peek('(') {
+			return p.parseCall(name, start)
+		}
+		switch name {
+		case "true", "false":
+			return &jsonPathLiteral{value: name == "true"}, nil
+		case "null":
+			return &jsonPathLiteral{value: nil}, nil
+		}
+		return nil, p.errorAt(start, "unexpected %q", name)
+	}
+	return nil, p.expected("a literal, query or function")
+}
+
+// parseCall parses the arguments of a function and checks their types
+func (p *jsonPathParser) parseCall(name string, start int) (jsonPathExpr, error) {
+	function, ok := jsonPathFunctions[name]
+	if !ok {
+		return nil, p.errorAt(start, "unknown function %s()", name)
+	}
+	p.pos++
+
+	call := &jsonPathCall{name: name}
+	p.skipBlank()
+	for !p.peek(')') {
+		if len(call.args) > 0 {
+			if !p.peek(',') {
+				return nil, p.expected("',' or ')'")
+			}
+			p.pos++
+			p.skipBlank()
+		}
+
+		argStart := p.pos
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		if i := len(call.args); i < len(function.params) {
+			if err := p.checkArgument(name, i, function.params[i], arg, argStart); err != nil {
+				return nil, err
+			}
+		}
+		call.args = append(call.args, arg)
+		p.skipBlank()
+	}
+	p.pos++
+
+	if len(call.args) != len(function.params) {
+		return nil, p.errorAt(start, "%s() takes %d argument(s), got %d", name, len(function.params), len(call.args))
+	}
+	return call, nil
+}
+
+// parseArgument parses a function argument: a literal, query or function
+// call, or else a logical expression
+func (p *jsonPathParser) parseArgument() (jsonPathExpr, error) {
+	start := p.pos
+	if !p.peek('!') && !p.peek('(') {
+		arg, err := p.parsePrimary()
+		if err == nil {
+			save := p.pos
+			p.skipBlank()
+			if p.peek(',') || p.peek(')') {
+				p.pos = save
+				return arg, nil
+			}
+		}
+		p.pos = start
+	}
+	return p.parseLogical()
+}
+
+// checkArgument checks an argument against the declared parameter type
+func (p *jsonPathParser) checkArgument(name string, i int, param jsonPathType, arg jsonPathExpr, offset int) error {
+	ok := false
+	switch param {
+	case jsonPathValueType:
+		query, isQuery := arg.(*jsonPathQuery)
+		ok = arg.exprType() == jsonPathValueType || (isQuery && query.singular())
+	case jsonPathNodesType:
+		ok = arg.exprType() == jsonPathNodesType
+	case jsonPathLogicalType:
+		ok = arg.exprType() != jsonPathValueType
+	}
+	if !ok {
+		kinds := map[jsonPathType]string{jsonPathValueType: "a value", jsonPathNodesType: "a query", jsonPathLogicalType: "a logical expression"}
+		return p.errorAt(offset, "argument %d of %s() must be %s", i+1, name, kinds[param])
+	}
+	return nil
+}
+
+// jsonPathNode is a selected value and its location in the document
+type jsonPathNode struct {
+	value  any
+	parent *jsonPathNode
+	key    any // member name or array index
+}
+
+func (n *jsonPathNode) child(key, value any) *jsonPathNode {
+	return &jsonPathNode{value: value, parent: n, key: key}
+}
+
+// path returns the normalized path of a node, such as $['a'][0]
+func (n *jsonPathNode) path() string {
+	var keys []any
+	for node := n; node.parent != nil; node = node.parent {
+		keys = append(keys, node.key)
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('$')
+	for i := len(keys) - 1; i >= 0; i-- {
+		switch key := keys[i].(type) {
+		case int:
+			fmt.Fprintf(&sb, "[%d]", key)
+		case string:
+			sb.WriteString("['")
+			for _, r := range key {
+				switch r {
+				case '\b':
+					sb.WriteString(`\b`)
+				case '\f':
+					sb.WriteString(`\f`)
+				case '\n':
+					sb.WriteString(`\n`)
+				case '\r':
+					sb.WriteString(`\r`)
+				case '\t':
+					sb.WriteString(`\t`)
+				case '\'', '\\':
+					sb.WriteByte('\\')
+					sb.WriteRune(r)
+				default:
+					if r < 0x20 {
+						fmt.Fprintf(&sb, `\u%04x`, r)
+					} else {
+						sb.WriteRune(r)
+					}
+				}
+			}
+			sb.WriteString("']")
+		}
+	}
+	return sb.String()
+}
+
+// jsonPathEvaluator runs queries against a document until its context is done
+type jsonPathEvaluator struct {
+	ctx     context.Context
+	root    any
+	steps   int
+	err     error
+	regexps map[string]*regexp.Regexp
+}
+
+// alive counts a step and reports whether evaluation should go on
+func (ev *jsonPathEvaluator) alive() bool {
+	ev.steps++
+	if ev.err == nil && ev.steps%1024 == 0 {
+		ev.err = ev.ctx.Err()
+	}
+	return ev.err == nil
+}
+
+// query selects the nodes of a query, in document order for each segment
+func (ev *jsonPathEvaluator) query(q *jsonPathQuery, current any) []*jsonPathNode {
+	start := ev.root
+	if q.relative {
+		start = current
+	}
+	nodes := []*jsonPathNode{{value: start}}
+
+	for _, segment := range q.segments {
+		var next []*jsonPathNode
+		for _, node := range nodes {
+			if segment.descendant {
+				next = ev.selectDescendants(segment.selectors, node, next)
+			} else {
+				next = ev.selectChildren(segment.selectors, node, next)
+			}
+			if len(next) > maxJSONPathNodes && ev.err == nil {
+				ev.err = fmt.Errorf("query selects more than %d nodes", maxJSONPathNodes)
+			}
+			if ev.err != nil {
+				return nil
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// selectDescendants applies selectors to a node and all its descendants
+func (ev *jsonPathEvaluator) selectDescendants(selectors []jsonPathSelector, node *jsonPathNode, out []*jsonPathNode) []*jsonPathNode {
+	if !ev.alive() {
+		return out
+	}
+	out = ev.selectChildren(selectors, node, out)
+	switch v := node.value.(type) {
+	case *orderedMap:
+		for _, key := range v.keys {
+			out = ev.selectDescendants(selectors, node.child(key, v.values[key]), out)
+		}
+	case []any:
+		for i, item := range v {
+			out = ev.selectDescendants(selectors, node.child(i, item), out)
+		}
+	}
+	return out
+}
+
+// selectChildren applies each selector to a node in turn
+func (ev *jsonPathEvaluator) selectChildren(selectors []jsonPathSelector, node *jsonPathNode, out []*jsonPathNode) []*jsonPathNode {
+	for _, selector := range selectors {
+		switch v := node.value.(type) {
+		case *orderedMap:
+			switch selector.kind {
+			case jsonPathName:
+				if value, ok := v.get(selector.name); ok {
+					out = append(out, node.child(selector.name, value))
+				}
+			case jsonPathWildcard, jsonPathFilter:
+				for _, key := range v.keys {
+					if selector.kind == jsonPathWildcard || ev.logical(selector.filter, v.values[key]) {
+						out = append(out, node.child(key, v.values[key]))
+					}
+				}
+			}
+		case []any:
+			switch selector.kind {
+			case jsonPathIndex:
+				i := int(selector.index)
+				if i < 0 {
+					i += len(v)
+				}
+				if i >= 0 && i < len(v) {
+					out = append(out, node.child(i, v[i]))
+				}
+			case jsonPathSlice:
+				for _, i := range sliceIndexes(selector, len(v)) {
+					out = append(out, node.child(i, v[i]))
+				}
+			case jsonPathWildcard, jsonPathFilter:
+				for i, item := range v {
+					if selector.kind == jsonPathWildcard || ev.logical(selector.filter, item) {
+						out = append(out, node.child(i, item))
+					}
+				}
+			}
+		}
+		if ev.err != nil {
+			return out
+		}
+	}
+	return out
+}
+
+// sliceIndexes returns the array indexes a slice selects, following RFC 9535 section 2.3.4.2.2
+func sliceIndexes(selector jsonPathSelector, length int) []int {
+	n := int64(length)
+	step := int64(1)
+	if selector.step != nil {
+		step = *selector.step
+	}
+	if step == 0 {
+		return nil
+	}
+
+	start, end := int64(0), n
+	if step < 0 {
+		start, end = n-1, -n-1
+	}
+	if selector.start != nil {
+		start = *selector.start
+	}
+	if selector.end != nil {
+		end = *selector.end
+	}
+	normalize := func(i int64) int64 {
+		if i < 0 {
+			return n + i
+		}
+		return i
+	}
+	clamp := func(i, low, high int64) int64 { return min(max(i, low), high) }
+
+	var indexes []int
+	if step > 0 {
+		lower, upper := clamp(normalize(start), 0, n), clamp(normalize(end), 0, n)
+		for i := lower; i < upper; i += step {
+			indexes = append(indexes, int(i))
+		}
+	} else {
+		upper, lower := clamp(normalize(start), -1, n-1), clamp(normalize(end), -1, n-1)
+		for i := upper; lower < i; i += step {
+			indexes = append(indexes, int(i))
+		}
+	}
+	return indexes
+}
+
+// logical evaluates a filter expression for the current node
+func (ev *jsonPathEvaluator) logical(expr jsonPathExpr, current any) bool {
+	if !ev.alive() {
+		return false
+	}
+	switch e := expr.(type) {
+	case *jsonPathLogicalOp:
+		for _, operand := range e.operands {
+			if ev.logical(operand, current) != e.and {
+				return !e.and
+			}
+		}
+		return e.and
+	case *jsonPathNot:
+		return !ev.logical(e.operand, current)
+	case *jsonPathComparison:
+		left, leftOK := ev.value(e.left, current)
+		right, rightOK := ev.value(e.right, current)
+		return jsonPathCompare(e.op, left, leftOK, right, rightOK)
+	case *jsonPathQuery:
+		return len(ev.query(e, current)) > 0
+	case *jsonPathCall:
+		if e.exprType() == jsonPathLogicalType {
+			return ev.callLogical(e, current)
+		}
+	}
+	return false
+}
+
+// value evaluates a literal, singular query or function to a value, or
+// reports that there is none
+func (ev *jsonPathEvaluator) value(expr jsonPathExpr, current any) (any, bool) {
+	switch e := expr.(type) {
+	case *jsonPathLiteral:
+		return e.value, true
+	case *jsonPathQuery:
+		if nodes := ev.query(e, current); len(nodes) == 1 {
+			return nodes[0].value, true
+		}
+	case *jsonPathCall:
+		return ev.callValue(e, current)
+	}
+	return nil, false
+}
+
+// callValue runs length(), count() and value()
+func (ev *jsonPathEvaluator) callValue(call *jsonPathCall, current any) (any, bool) {
+	switch call.name {
+	case "length":
+		value, ok := ev.value(call.args[0], current)
+		if !ok {
+			return nil, false
+		}
+		switch v := value.(type) {
+		case string:
+			return json.Number(strconv.Itoa(utf8.RuneCountInString(v))), true
+		case []any:
+			return json.Number(strconv.Itoa(len(v))), true
+		case *orderedMap:
+			return json.Number(strconv.Itoa(len(v.keys))), true
+		}
+	case "count":
+		nodes := ev.query(call.args[0].(*jsonPathQuery), current)
+		return json.Number(strconv.Itoa(len(nodes))), true
+	case "value":
+		if nodes := ev.query(call.args[0].(*jsonPathQuery), current); len(nodes) == 1 {
+			return nodes[0].value, true
+		}
+	}
+	return nil, false
+}
+
+// callLogical runs match(), which matches a whole string, and search(),
+// which matches a substring, with I-Regexp (RFC 9485) patterns
+func (ev *jsonPathEvaluator) callLogical(call *jsonPathCall, current any) bool {
+	value, ok := ev.value(call.args[0], current)
+	s, isString := value.(string)
+	if !ok || !isString {
+		return false
+	}
+	value, ok = ev.value(call.args[1], current)
+	pattern, isString := value.(string)
+	if !ok || !isString {
+		return false
+	}
+
+	expr := iRegexpToRE2(pattern)
+	if call.name == "match" {
+		expr = `\A(?:` + expr + `)\z`
+	}
+	re, cached := ev.regexps[expr]
+	if !cached {
+		// Invalid patterns are cached as nil and never match
+		re, _ = regexp.Compile(expr)
+		if ev.regexps == nil {
+			ev.regexps = make(map[string]*regexp.Regexp)
+		}
+		ev.regexps[expr] = re
+	}
+	return re != nil && re.MatchString(s)
+}
+
+// iRegexpToRE2 translates an I-Regexp to Go syntax, where . must not match
+// line breaks
+func iRegexpToRE2(pattern string) string {
+	var sb strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			sb.WriteByte(c)
+			i++
+			c = pattern[i]
+		case c == '[' && !inClass:
+			inClass = true
+		case c == ']' && inClass:
+			inClass = false
+		case c == '.' && !inClass:
+			sb.WriteString(`[^\n\r]`)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// jsonPathCompare compares two values that may be missing. Missing values
+// are only equal to each other, and only numbers and strings are ordered.
+func jsonPathCompare(op string, left any, leftOK bool, right any, rightOK bool) bool {
+	equal := func() bool {
+		if !leftOK || !rightOK {
+			return !leftOK && !rightOK
+		}
+		return jsonValuesEqual(left, right)
+	}
+	less := func(a, b any) bool {
+		if !leftOK || !rightOK {
+			return false
+		}
+		switch a := a.(type) {
+		case json.Number:
+			b, ok := b.(json.Number)
+			return ok && compareJSONNumbers(a, b) < 0
+		case string:
+			b, ok := b.(string)
+			return ok && a < b
+		}
+		return false
+	}
+
+	switch op {
+	case "==":
+		return equal()
+	case "!=":
+		return !equal()
+	case "<":
+		return less(left, right)
+	case "<=":
+		return less(left, right) || equal()
+	case ">":
+		return less(right, left)
+	case ">=":
+		return less(right, left) || equal()
+	}
+	return false
+}
+
+// jsonValuesEqual compares decoded JSON values deeply, numbers by value
+func jsonValuesEqual(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		return ok && compareJSONNumbers(a, b) == 0
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonValuesEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case *orderedMap:
+		b, ok := b.(*orderedMap)
+		if !ok || len(a.keys) != len(b.keys) {
+			return false
+		}
+		for _, key := range a.keys {
+			value, ok := b.get(key)
+			if !ok || !jsonValuesEqual(a.values[key], value) {
+				return false
+			}
+		}
+		return true
+	case string, bool, nil:
+		return a == b
+	}
+	return false
+}
+
+// compareJSONNumbers compares number literals by value, exactly where they
+// fit a big.Float and as float64 otherwise
+func compareJSONNumbers(a, b json.Number) int {
+	x, okX := new(big.Float).SetPrec(256).SetString(string(a))
+	y, okY := new(big.Float).SetPrec(256).SetString(string(b))
+	if okX && okY {
+		return x.Cmp(y)
+	}
+	fx, _ := strconv.ParseFloat(string(a), 64)
+	fy, _ := strconv.ParseFloat(string(b), 64)
+	switch {
+	case fx < fy:
+		return -1
+	case fx > fy:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Password generation modes
+const (
+	PasswordModeRandom        = "random"
+	PasswordModePassphrase    = "passphrase"
+	PasswordModePronounceable = "pronounceable"
+)
+
+// Character classes used for random passwords
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+
+	// ambiguousChars are easily confused with each other in many fonts
+	ambiguousChars = "Il1|O0o"
+
+	consonantChars = "bcdfghjklmnprstvwxz"
+	vowelChars     = "aeiou"
+)
+
+// maxRequireEachClassAttempts bounds rejection sampling for passwords that
+// must contain every selected character class
+const maxRequireEachClassAttempts = 1000
+
+// effLargeWordlist is the EFF large wordlist for five-dice passphrases,
+// published by the Electronic Frontier Foundation under CC BY 3.0 US:
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed wordlists/eff_large_wordlist.txt
+var effLargeWordlist []byte
+
+// passphraseWords parses the embedded wordlist once
+var passphraseWords = sync.OnceValue(func() []string {
+	words := make([]string, 0, 7776)
+	scanner := bufio.NewScanner(bytes.NewReader(effLargeWordlist))
+	for scanner.Scan() {
+		// Lines are "<dice rolls>\t<word>"
+		if _, word, ok := strings.Cut(scanner.Text(), "\t"); ok {
+			words = append(words, word)
+		}
+	}
+	return words
+})
+
+// randomIndex returns a uniformly random integer in [0, n)
+func randomIndex(n int) (int, error) {
+	num, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return int(num.Int64()), nil
+}
+
+// randomString draws length characters uniformly from charset
+func randomString(charset string, length int) (string, error) {
+	result := make([]byte, length)
+	for i := range result {
+		idx, err := randomIndex(len(charset))
+		if err != nil {
+			return "", err
+		}
+		result[i] = charset[idx]
+	}
+	return string(result), nil
+}
+
+// withoutChars removes every character in exclude from s
+func withoutChars(s, exclude string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(exclude, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// generateRandomPassword draws characters uniformly from the union of the
+// classes. With requireEach, candidates missing a class are rejected, which
+// keeps the result uniform over all passwords containing every class.
+// It returns the password and its entropy in bits.
+func generateRandomPassword(classes []string, length int, requireEach bool) (string, float64, error) {
+	charset := strings.Join(classes, "")
+	if charset == "" {
+		return "", 0, fmt.Errorf("at least one character type must be included")
+	}
+
+	if !requireEach {
+		password, err := randomString(charset, length)
+		if err != nil {
+			return "", 0, err
+		}
+		return password, float64(length) * math.Log2(float64(len(charset))), nil
+	}
+
+	if len(classes) > length {
+		return "", 0, fmt.Errorf("length %d is too short to include all %d character types", length, len(classes))
+	}
+
+	for range maxRequireEachClassAttempts {
+		password, err := randomString(charset, length)
+		if err != nil {
+			return "", 0, err
+		}
+		if containsEachClass(password, classes) {
+			return password, requireEachClassEntropy(classes, length), nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("failed to generate a password containing every character type")
+}
+
+// containsEachClass reports whether password has a character from every class
+func containsEachClass(password string, classes []string) bool {
+	for _, class := range classes {
+		if !strings.ContainsAny(password, class) {
+			return false
+		}
+	}
+	return true
+}
+
+// requireEachClassEntropy counts the passwords of the given length that
+// contain every class, by inclusion-exclusion over the missing classes
+func requireEachClassEntropy(classes []string, length int) float64 {
+	total := new(big.Int)
+	for mask := 0; mask < 1<<len(classes); mask++ {
+		size, missing := 0, 0
+		for i, class := range classes {
+			if mask&(1<<i) != 0 {
+				missing++
+			} else {
+				size += len(class)
+			}
+		}
+
+		term := new(big.Int).Exp(big.NewInt(int64(size)), big.NewInt(int64(length)), nil)
+		if missing%2 == 1 {
+			total.Sub(total, term)
+		} else {
+			total.Add(total, term)
+		}
+	}
+
+	return bigLog2(total)
+}
+
+// bigLog2 returns log2 of a positive big integer
+func bigLog2(n *big.Int) float64 {
+	if n.Sign() <= 0 {
+		return 0
+	}
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(n).MantExp(mant)
+	f, _ := mant.Float64()
+	return math.Log2(f) + float64(exp)
+}
+
+// generatePassphrase picks words uniformly from the EFF wordlist, optionally
+// capitalizing them and appending a random digit to a random word. It returns
+// the passphrase and its entropy in bits.
+func generatePassphrase(count int, separator string, capitalize, digit bool) (string, float64, error) {
+	wordlist := passphraseWords()
+	words := make([]string, count)
+	for i := range words {
+		idx, err := randomIndex(len(wordlist))
+		if err != nil {
+			return "", 0, err
+		}
+		words[i] = wordlist[idx]
+		if capitalize {
+			words[i] = capitalizeFirst(words[i])
+		}
+	}
+	entropy := float64(count) * math.Log2(float64(len(wordlist)))
+
+	if digit {
+		pos, err := randomIndex(count)
+		if err != nil {
+			return "", 0, err
+		}
+		d, err := randomString(digitChars, 1)
+		if err != nil {
+			return "", 0, err
+		}
+		words[pos] += d
+		entropy += math.Log2(10) + math.Log2(float64(count))
+	}
+
+	return strings.Join(words, separator), entropy, nil
+}
+
+// generatePronounceable alternates consonants and vowels so the password can
+// be read aloud, optionally ending with a digit and a symbol. Every selected
+// class is present by construction. It returns the password and its entropy
+// in bits.
+func generatePronounceable(length int, capitalize, digit, symbol, excludeAmbiguous bool) (string, float64, error) {
+	consonants, vowels, digits, symbols := consonantChars, vowelChars, digitChars, symbolChars
+	if excludeAmbiguous {
+		consonants = withoutChars(consonants, ambiguousChars)
+		vowels = withoutChars(vowels, ambiguousChars)
+		digits = withoutChars(digits, ambiguousChars)
+		symbols = withoutChars(symbols, ambiguousChars)
+	}
+
+	letters := length
+	if digit {
+		letters--
+	}
+	if symbol {
+		letters--
+	}
+
+	start, err := randomIndex(2)
+	if err != nil {
+		return "", 0, err
+	}
+	entropy := 1.0
+
+	var b strings.Builder
+	for i := range letters {
+		charset := consonants
+		if (i+start)%2 == 1 {
+			charset = vowels
+		}
+		// A capitalized i would become an ambiguous I
+		if i == 0 && capitalize && excludeAmbiguous {
+			charset = withoutChars(charset, strings.ToLower(ambiguousChars))
+		}
+		c, err := randomString(charset, 1)
+		if err != nil {
+			return "", 0, err
+		}
+		b.WriteString(c)
+		entropy += math.Log2(float64(len(charset)))
+	}
+
+	password := b.String()
+	if capitalize {
+		password = capitalizeFirst(password)
+	}
+
+	for _, extra := range []struct {
+		enabled bool
+		charset string
+	}{{digit, digits}, {symbol, symbols}} {
+		if !extra.enabled {
+			continue
+		}
+		c, err := randomString(extra.charset, 1)
+		if err != nil {
+			return "", 0, err
+		}
+		password += c
+		entropy += math.Log2(float64(len(extra.charset)))
+	}
+
+	return password, entropy, nil
+}
+
+// capitalizeFirst uppercases the first letter of s
+func capitalizeFirst(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1" // #nosec G505 - SHA1 is only used to verify existing PBKDF2-SHA1 hashes
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Password hashing algorithms
+const (
+	PasswordHashBcrypt       = "bcrypt"
+	PasswordHashArgon2id     = "argon2id"
+	PasswordHashArgon2i      = "argon2i"
+	PasswordHashScrypt       = "scrypt"
+	PasswordHashPBKDF2SHA1   = "pbkdf2-sha1"
+	PasswordHashPBKDF2SHA256 = "pbkdf2-sha256"
+	PasswordHashPBKDF2SHA512 = "pbkdf2-sha512"
+)
+
+// Security: Cost limits applied to generated and verified hashes so a single
+// request cannot tie up the CPU or allocate unbounded memory
+const (
+	maxBcryptCost         = 14
+	maxArgon2Memory       = 256 * 1024 // in KiB
+	maxArgon2Iterations   = 10
+	maxArgon2Parallelism  = 8
+	maxScryptLogN         = 20
+	maxScryptBlockSize    = 16
+	maxScryptParallelism  = 8
+	maxScryptMemory       = 256 << 20 // in bytes
+	maxPBKDF2Iterations   = 2_000_000
+	passwordHashSaltBytes = 16
+	passwordHashKeyBytes  = 32
+)
+
+var (
+	// ErrHashCostTooHigh is returned when hash parameters exceed the cost limits
+	ErrHashCostTooHigh = errors.New("hash cost parameters exceed the allowed maximum")
+	// ErrUnrecognizedHash is returned when a hash is not in a supported format
+	ErrUnrecognizedHash = errors.New("unrecognized hash format")
+)
+
+// passwordHash is a parsed password hash. Fields that do not apply to the
+// algorithm are zero.
+type passwordHash struct {
+	algorithm   string
+	version     int
+	memory      uint32
+	iterations  int
+	parallelism int
+	logN        int
+	blockSize   int
+	salt        []byte
+	key         []byte
+}
+
+// isPasswordHashAlgorithm reports whether an algorithm is a salted password hash
+func isPasswordHashAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case PasswordHashBcrypt, PasswordHashArgon2id, PasswordHashArgon2i, PasswordHashScrypt,
+		PasswordHashPBKDF2SHA256, PasswordHashPBKDF2SHA512:
+		return true
+	default:
+		return false
+	}
+}
+
+// generatePasswordHash hashes a password with the given algorithm and cost
+// parameters, falling back to current recommended defaults
+func generatePasswordHash(algorithm, password string, params *domain.HashParams) (string, error) {
+	if params == nil {
+		params = &domain.HashParams{}
+	}
+
+	if algorithm == PasswordHashBcrypt {
+		cost := valueOr(params.Cost, bcrypt.DefaultCost)
+		if cost > maxBcryptCost {
+			return "", fmt.Errorf("%w: bcrypt cost must be at most %d", ErrHashCostTooHigh, maxBcryptCost)
+		}
+		h, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate bcrypt hash: %w", err)
+		}
+		return string(h), nil
+	}
+
+	salt := make([]byte, passwordHashSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	ph := &passwordHash{algorithm: algorithm, salt: salt}
+	switch algorithm {
+	case PasswordHashArgon2id, PasswordHashArgon2i:
+		// OWASP recommended minimum configuration
+		ph.version = argon2.Version
+		ph.memory = uint32(valueOr(params.Memory, 19*1024)) // #nosec G115 - memory is validated by binding and checkCost
+		ph.iterations = valueOr(params.Iterations, 2)
+		ph.parallelism = valueOr(params.Parallelism, 1)
+	case PasswordHashScrypt:
+		ph.logN = valueOr(params.LogN, 15)
+		ph.blockSize = valueOr(params.BlockSize, 8)
+		ph.parallelism = valueOr(params.Parallelism, 1)
+	case PasswordHashPBKDF2SHA256:
+		ph.iterations = valueOr(params.Iterations, 600_000)
+	case PasswordHashPBKDF2SHA512:
+		ph.iterations = valueOr(params.Iterations, 210_000)
+	default:
+		return "", fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+
+	if err := ph.checkCost(); err != nil {
+		return "", err
+	}
+
+	key, err := ph.derive(password, passwordHashKeyBytes)
+	if err != nil {
+		return "", err
+	}
+	ph.key = key
+
+	return ph.encode(), nil
+}
+
+// verifyPasswordHash detects the format of encoded and reports whether password matches it
+func verifyPasswordHash(encoded, password string) (bool, string, error) {
+	encoded = strings.TrimSpace(encoded)
+
+	if strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$") {
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, "", fmt.Errorf("%w: %v", ErrUnrecognizedHash, err)
+		}
+		if cost > maxBcryptCost {
+			return false, "", fmt.Errorf("%w: bcrypt cost must be at most %d", ErrHashCostTooHigh, maxBcryptCost)
+		}
+		err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, PasswordHashBcrypt, nil
+		}
+		if err != nil {
+			return false, "", fmt.Errorf("%w: %v", ErrUnrecognizedHash, err)
+		}
+		return true, PasswordHashBcrypt, nil
+	}
+
+	ph, err := parsePasswordHash(encoded)
+	if err != nil {
+		return false, "", err
+	}
+	if err := ph.checkCost(); err != nil {
+		return false, "", err
+	}
+
+	key, err := ph.derive(password, len(ph.key))
+	if err != nil {
+		return false, "", err
+	}
+
+	return subtle.ConstantTimeCompare(key, ph.key) == 1, ph.algorithm, nil
+}
+
+// parsePasswordHash parses argon2 and scrypt PHC strings, PBKDF2 PHC and
+// passlib strings ($pbkdf2-sha256$...) and Django PBKDF2 strings (pbkdf2_sha256$...)
+func parsePasswordHash(encoded string) (*passwordHash, error) {
+	if strings.HasPrefix(encoded, "pbkdf2_") {
+		return parseDjangoPBKDF2(encoded)
+	}
+
+	fields := strings.Split(encoded, "$")
+	if len(fields) < 2 || fields[0] != "" {
+		return nil, ErrUnrecognizedHash
+	}
+
+	ph := &passwordHash{algorithm: fields[1]}
+	var params map[string]int
+	var err error
+
+	switch ph.algorithm {
+	case PasswordHashArgon2id, PasswordHashArgon2i:
+		// $argon2id$v=19$m=65536,t=3,p=4$salt$hash
+		if len(fields) != 6 {
+			return nil, ErrUnrecognizedHash
+		}
+		if params, err = parsePHCParams(fields[2]); err != nil {
+			return nil, err
+		}
+		ph.version = params["v"]
+		if ph.version != argon2.Version {
+			return nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrUnrecognizedHash, ph.version)
+		}
+		if params, err = parsePHCParams(fields[3]); err != nil {
+			return nil, err
+		}
+		if params["m"] < 0 || params["m"] > maxArgon2Memory {
+			return nil, fmt.Errorf("%w: argon2 memory must be at most %d KiB", ErrHashCostTooHigh, maxArgon2Memory)
+		}
+		ph.memory = uint32(params["m"]) // #nosec G115 - memory is range checked above
+		ph.iterations = params["t"]
+		ph.parallelism = params["p"]
+	case PasswordHashScrypt:
+		// $scrypt$ln=15,r=8,p=1$salt$hash
+		if len(fields) != 5 {
+			return nil, ErrUnrecognizedHash
+		}
+		if params, err = parsePHCParams(fields[2]); err != nil {
+			return nil, err
+		}
+		ph.logN = params["ln"]
+		ph.blockSize = params["r"]
+		ph.parallelism = params["p"]
+	case PasswordHashPBKDF2SHA1, PasswordHashPBKDF2SHA256, PasswordHashPBKDF2SHA512:
+		// $pbkdf2-sha256$i=600000,l=32$salt$hash or passlib's $pbkdf2-sha256$29000$salt$hash
+		if len(fields) != 5 {
+			return nil, ErrUnrecognizedHash
+		}
+		if n, convErr := strconv.Atoi(fields[2]); convErr == nil {
+			ph.iterations = n
+		} else {
+			if params, err = parsePHCParams(fields[2]); err != nil {
+				return nil, err
+			}
+			ph.iterations = params["i"]
+		}
+	default:
+		return nil, ErrUnrecognizedHash
+	}
+
+	n := len(fields)
+	if ph.salt, err = decodeHashBase64(fields[n-2]); err != nil {
+		return nil, err
+	}
+	if ph.key, err = decodeHashBase64(fields[n-1]); err != nil {
+		return nil, err
+	}
+	if len(ph.key) == 0 {
+		return nil, fmt.Errorf("%w: empty hash", ErrUnrecognizedHash)
+	}
+
+	return ph, nil
+}
+
+// parseDjangoPBKDF2 parses Django's pbkdf2_sha256$iterations$salt$hash format,
+// where the salt is used verbatim
+func parseDjangoPBKDF2(encoded string) (*passwordHash, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) != 4 {
+		return nil, ErrUnrecognizedHash
+	}
+
+	algorithm := strings.Replace(fields[0], "_", "-", 1)
+	switch algorithm {
+	case PasswordHashPBKDF2SHA1, PasswordHashPBKDF2SHA256, PasswordHashPBKDF2SHA512:
+	default:
+		return nil, ErrUnrecognizedHash
+	}
+
+	iterations, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid iteration count", ErrUnrecognizedHash)
+	}
+	key, err := base64.StdEncoding.DecodeString(fields[3])
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("%w: invalid hash encoding", ErrUnrecognizedHash)
+	}
+
+	return &passwordHash{
+		algorithm:  algorithm,
+		iterations: iterations,
+		salt:       []byte(fields[2]),
+		key:        key,
+	}, nil
+}
+
+// parsePHCParams parses comma-separated key=value PHC parameters
+func parsePHCParams(s string) (map[string]int, error) {
+	params := make(map[string]int)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid parameter %q", ErrUnrecognizedHash, pair)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid parameter %q", ErrUnrecognizedHash, pair)
+		}
+		params[key] = n
+	}
+	return params, nil
+}
+
+// decodeHashBase64 decodes unpadded standard base64 as used by PHC strings,
+// also accepting padding and passlib's "." in place of "+"
+func decodeHashBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.ReplaceAll(s, ".", "+"), "=")
+	data, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid base64: %v", ErrUnrecognizedHash, err)
+	}
+	return data, nil
+}
+
+// checkCost rejects parameters outside the supported range before any work is done
+func (ph *passwordHash) checkCost() error {
+	switch ph.algorithm {
+	case PasswordHashArgon2id, PasswordHashArgon2i:
+		if ph.iterations < 1 || ph.parallelism < 1 || ph.parallelism > 255 || ph.memory < uint32(8*ph.parallelism) { // #nosec G115 - parallelism is at most 255
+			return fmt.Errorf("%w: invalid argon2 parameters", ErrUnrecognizedHash)
+		}
+		if ph.memory > maxArgon2Memory || ph.iterations > maxArgon2Iterations || ph.parallelism > maxArgon2Parallelism {
+			return fmt.Errorf("%w: argon2 allows at most m=%d, t=%d, p=%d", ErrHashCostTooHigh, maxArgon2Memory, maxArgon2Iterations, maxArgon2Parallelism)
+		}
+	case PasswordHashScrypt:
+		if ph.logN < 1 || ph.blockSize < 1 || ph.parallelism < 1 {
+			return fmt.Errorf("%w: invalid scrypt parameters", ErrUnrecognizedHash)
+		}
+		if ph.logN > maxScryptLogN || ph.blockSize > maxScryptBlockSize || ph.parallelism > maxScryptParallelism ||
+			128*ph.blockSize<<ph.logN > maxScryptMemory {
+			return fmt.Errorf("%w: scrypt allows at most ln=%d, r=%d, p=%d and %d MiB", ErrHashCostTooHigh, maxScryptLogN, maxScryptBlockSize, maxScryptParallelism, maxScryptMemory>>20)
+		}
+	case PasswordHashPBKDF2SHA1, PasswordHashPBKDF2SHA256, PasswordHashPBKDF2SHA512:
+		if ph.iterations < 1 {
+			return fmt.Errorf("%w: invalid PBKDF2 iteration count", ErrUnrecognizedHash)
+		}
+		if ph.iterations > maxPBKDF2Iterations {
+			return fmt.Errorf("%w: PBKDF2 allows at most %d iterations", ErrHashCostTooHigh, maxPBKDF2Iterations)
+		}
+	}
+	return nil
+}
+
+// derive computes a key of keyLen bytes from password with the hash parameters
+func (ph *passwordHash) derive(password string, keyLen int) ([]byte, error) {
+	// Security: Bound the key length taken from untrusted hashes
+	if keyLen < 1 || keyLen > 128 {
+		return nil, fmt.Errorf("%w: invalid hash length", ErrUnrecognizedHash)
+	}
+
+	switch ph.algorithm {
+	case PasswordHashArgon2id:
+		return argon2.IDKey([]byte(password), ph.salt, uint32(ph.iterations), ph.memory, uint8(ph.parallelism), uint32(keyLen)), nil // #nosec G115 - parameters are range checked by checkCost
+	case PasswordHashArgon2i:
+		return argon2.Key([]byte(password), ph.salt, uint32(ph.iterations), ph.memory, uint8(ph.parallelism), uint32(keyLen)), nil // #nosec G115 - parameters are range checked by checkCost
+	case PasswordHashScrypt:
+		key, err := scrypt.Key([]byte(password), ph.salt, 1<<ph.logN, ph.blockSize, ph.parallelism, keyLen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive scrypt key: %w", err)
+		}
+		return key, nil
+	case PasswordHashPBKDF2SHA1, PasswordHashPBKDF2SHA256, PasswordHashPBKDF2SHA512:
+		key, err := pbkdf2.Key(pbkdf2Hashes[ph.algorithm], password, ph.salt, ph.iterations, keyLen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive PBKDF2 key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, ErrUnrecognizedHash
+	}
+}
+
+// pbkdf2Hashes maps PBKDF2 variants to their HMAC hash
+var pbkdf2Hashes = map[string]func() hash.Hash{
+	PasswordHashPBKDF2SHA1:   sha1.New,
+	PasswordHashPBKDF2SHA256: sha256.New,
+	PasswordHashPBKDF2SHA512: sha512.New,
+}
+
+// encode formats the hash as a PHC string
+func (ph *passwordHash) encode() string {
+	salt := base64.RawStdEncoding.EncodeToString(ph.salt)
+	key := base64.RawStdEncoding.EncodeToString(ph.key)
+
+	switch ph.algorithm {
+	case PasswordHashArgon2id, PasswordHashArgon2i:
+		return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", ph.algorithm, ph.version, ph.memory, ph.iterations, ph.parallelism, salt, key)
+	case PasswordHashScrypt:
+		return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", ph.logN, ph.blockSize, ph.parallelism, salt, key)
+	default:
+		return fmt.Sprintf("$%s$i=%d,l=%d$%s$%s", ph.algorithm, ph.iterations, len(ph.key), salt, key)
+	}
+}
+
+// valueOr returns *p, or def when p is nil
+func valueOr(p *int, def int) int {
+	if p == nil {
+		return def
+	}
+	return *p
+}
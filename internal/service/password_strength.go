@@ -0,0 +1,361 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	entropyCalc "github.com/ccojocar/zxcvbn-go/entropy"
+	"github.com/ccojocar/zxcvbn-go/match"
+	"github.com/ccojocar/zxcvbn-go/matching"
+	"github.com/ccojocar/zxcvbn-go/scoring"
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+// ErrWeakPassword is returned when a password scores below the required minimum
+var ErrWeakPassword = errors.New("password is too weak")
+
+// maxMatchedPasswordLength bounds how much of a password is pattern matched,
+// since matching cost grows quickly with length. Later characters are
+// counted as brute force.
+const maxMatchedPasswordLength = 64
+
+// Guess rates in guesses per second for the crack time scenarios
+const (
+	onlineThrottledRate   = 100.0 / 3600
+	onlineUnthrottledRate = 10.0
+	offlineSlowHashRate   = 1e4
+	offlineFastHashRate   = 1e10
+)
+
+// analyzePassword estimates password strength with zxcvbn pattern matching
+// against its embedded dictionaries, treating userInputs as an extra dictionary
+func analyzePassword(password string, userInputs []string) *domain.PasswordAnalysis {
+	matched, rest := password, ""
+	if len(password) > maxMatchedPasswordLength {
+		cut := maxMatchedPasswordLength
+		for cut > 0 && !utf8.RuneStart(password[cut]) {
+			cut--
+		}
+		matched, rest = password[:cut], password[cut:]
+	}
+
+	result := scoring.MinimumEntropyMatchSequence(matched, passwordMatches(matched, userInputs))
+	entropy, score := result.Entropy, result.Score
+	if rest != "" {
+		entropy += float64(utf8.RuneCountInString(rest)) * math.Log2(entropyCalc.CalcBruteForceCardinality(password))
+		score = entropyScore(entropy)
+	}
+
+	analysis := &domain.PasswordAnalysis{
+		Score:          score,
+		Entropy:        round2(entropy),
+		CharsetEntropy: round2(charsetEntropy(password)),
+		Length:         utf8.RuneCountInString(password),
+		CrackTimes: domain.CrackTimes{
+			OnlineThrottled:   crackTime(entropy, onlineThrottledRate),
+			OnlineUnthrottled: crackTime(entropy, onlineUnthrottledRate),
+			OfflineSlowHash:   crackTime(entropy, offlineSlowHashRate),
+			OfflineFastHash:   crackTime(entropy, offlineFastHashRate),
+		},
+		Matches: make([]domain.PasswordMatch, 0, len(result.MatchSequence)+1),
+	}
+
+	for _, m := range result.MatchSequence {
+		analysis.Matches = append(analysis.Matches, domain.PasswordMatch{
+			Pattern:    m.Pattern,
+			Token:      m.Token,
+			Dictionary: m.DictionaryName,
+		})
+	}
+	if rest != "" {
+		analysis.Matches = append(analysis.Matches, domain.PasswordMatch{Pattern: "bruteforce", Token: rest})
+	}
+
+	analysis.Feedback = passwordFeedback(score, analysis.Matches)
+
+	return analysis
+}
+
+// entropyScore maps entropy to a 0-4 score with the thresholds zxcvbn uses,
+// which assume 10ms per guess spread over 100 attackers
+func entropyScore(entropy float64) int {
+	seconds := math.Pow(2, entropy) / 2 * 0.0001
+	switch {
+	case seconds < 1e2:
+		return 0
+	case seconds < 1e4:
+		return 1
+	case seconds < 1e6:
+		return 2
+	case seconds < 1e8:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// passwordMatches finds guessable patterns in a password. zxcvbn-go reports
+// l33t matches with the substituted token and without their extra entropy,
+// so they are detected here by comparing the token with the password. Like
+// upstream zxcvbn, single character l33t matches such as "1" for "i" are
+// dropped as noise. Date matches are given their pattern name and the
+// inclusive end index used by every other matcher.
+func passwordMatches(password string, userInputs []string) []match.Match {
+	all := matching.Omnimatch(password, userInputs)
+
+	matches := all[:0]
+	for _, m := range all {
+		if m.Pattern == "dictionary" && m.I >= 0 && m.J < len(password) && m.Token != password[m.I:m.J+1] {
+			if m.J == m.I {
+				continue
+			}
+			m.Entropy += l33tEntropy(password[m.I:m.J+1], m.Token)
+			m.Token = password[m.I : m.J+1]
+			m.DictionaryName += "_3117"
+		}
+		if m.DictionaryName == "date_match" {
+			m.Pattern = "date"
+			m.DictionaryName = ""
+			m.J = m.I + len(m.Token) - 1
+		}
+		matches = append(matches, m)
+	}
+
+	return matches
+}
+
+// l33tEntropy is the extra entropy of l33t substitutions, counting the ways
+// to choose which characters were substituted
+func l33tEntropy(token, word string) float64 {
+	subs, unsubs := 0, 0
+	for i := 0; i < len(token) && i < len(word); i++ {
+		if token[i] != word[i] {
+			subs++
+		} else {
+			unsubs++
+		}
+	}
+
+	possibilities := 0.0
+	for i := 1; i <= min(subs, unsubs); i++ {
+		possibilities += binomial(subs+unsubs, i)
+	}
+	if possibilities <= 2 {
+		return 1
+	}
+	return math.Log2(possibilities)
+}
+
+// binomial computes n choose k
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+// charsetEntropy is the entropy of a password drawn uniformly from the
+// character classes it uses
+func charsetEntropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	return float64(utf8.RuneCountInString(password)) * math.Log2(float64(pool))
+}
+
+// crackTime estimates the average time to guess a password of the given
+// entropy, which takes half the search space on average
+func crackTime(entropy, guessesPerSecond float64) domain.CrackTimeEstimate {
+	seconds := math.Pow(2, entropy) / 2 / guessesPerSecond
+	if math.IsInf(seconds, 0) || seconds > math.MaxFloat64 {
+		seconds = math.MaxFloat64
+	}
+
+	return domain.CrackTimeEstimate{
+		Seconds: seconds,
+		Display: displayDuration(seconds),
+	}
+}
+
+// displayDuration formats seconds as a rough human readable duration
+func displayDuration(seconds float64) string {
+	units := []struct {
+		name    string
+		seconds float64
+	}{
+		{"century", 100 * 365.25 * 86400},
+		{"year", 365.25 * 86400},
+		{"month", 30.44 * 86400},
+		{"day", 86400},
+		{"hour", 3600},
+		{"minute", 60},
+		{"second", 1},
+	}
+
+	if seconds < 1 {
+		return "less than a second"
+	}
+	if seconds >= 100*units[0].seconds {
+		return "centuries"
+	}
+	for _, unit := range units {
+		if seconds >= unit.seconds {
+			n := int(math.Round(seconds / unit.seconds))
+			if n == 1 {
+				return "1 " + unit.name
+			}
+			if unit.name == "century" {
+				return fmt.Sprintf("%d centuries", n)
+			}
+			return fmt.Sprintf("%d %ss", n, unit.name)
+		}
+	}
+	return "less than a second"
+}
+
+// passwordFeedback explains the weakest pattern found and how to improve
+func passwordFeedback(score int, matches []domain.PasswordMatch) domain.PasswordFeedback {
+	if len(matches) == 0 {
+		return domain.PasswordFeedback{
+			Suggestions: []string{
+				"Use a few words, avoid common phrases",
+				"No need for symbols, digits, or uppercase letters",
+			},
+		}
+	}
+	if score >= 3 {
+		return domain.PasswordFeedback{Suggestions: []string{}}
+	}
+
+	// The longest guessable match dominates the estimate
+	var longest *domain.PasswordMatch
+	for i := range matches {
+		if matches[i].Pattern == "bruteforce" {
+			continue
+		}
+		if longest == nil || len(matches[i].Token) > len(longest.Token) {
+			longest = &matches[i]
+		}
+	}
+
+	feedback := domain.PasswordFeedback{Suggestions: []string{"Add another word or two. Uncommon words are better."}}
+	if longest == nil {
+		return feedback
+	}
+
+	switch longest.Pattern {
+	case "dictionary":
+		feedback.Warning = dictionaryWarning(longest, len(matches) == 1)
+		word := longest.Token
+		switch {
+		case len(word) > 1 && strings.ToUpper(word) == word && strings.ToLower(word) != word:
+			feedback.Suggestions = append(feedback.Suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+		case startsUpper(word):
+			feedback.Suggestions = append(feedback.Suggestions, "Capitalization doesn't help very much")
+		}
+		if strings.HasSuffix(longest.Dictionary, "_3117") {
+			feedback.Suggestions = append(feedback.Suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+		}
+	case "spatial":
+		feedback.Warning = "Short keyboard patterns are easy to guess"
+		if len(longest.Token) >= 6 {
+			feedback.Warning = "Straight rows of keys are easy to guess"
+		}
+		feedback.Suggestions = append(feedback.Suggestions, "Use a longer keyboard pattern with more turns")
+	case "repeat":
+		feedback.Warning = `Repeats like "aaa" or "abcabcabc" are easy to guess`
+		feedback.Suggestions = append(feedback.Suggestions, "Avoid repeated words and characters")
+	case "sequence":
+		feedback.Warning = "Sequences like abc or 6543 are easy to guess"
+		feedback.Suggestions = append(feedback.Suggestions, "Avoid sequences")
+	case "date":
+		feedback.Warning = "Dates are often easy to guess"
+		feedback.Suggestions = append(feedback.Suggestions, "Avoid dates and years that are associated with you")
+	}
+
+	return feedback
+}
+
+// dictionaryWarning describes why a dictionary match is weak
+func dictionaryWarning(m *domain.PasswordMatch, soleMatch bool) string {
+	dictionary := strings.TrimSuffix(m.Dictionary, "_3117")
+	switch dictionary {
+	case "Passwords":
+		if soleMatch {
+			return "This is a very common password"
+		}
+		return "This is similar to a commonly used password"
+	case "user_inputs":
+		return "Avoid using personal information such as your username"
+	case "English":
+		if soleMatch {
+			return "A word by itself is easy to guess"
+		}
+	case "MaleNames", "FemaleNames", "Surname":
+		if soleMatch {
+			return "Names and surnames by themselves are easy to guess"
+		}
+		return "Common names and surnames are easy to guess"
+	}
+	return ""
+}
+
+// startsUpper reports whether s starts with an uppercase letter followed by lowercase letters
+func startsUpper(s string) bool {
+	runes := []rune(s)
+	return len(runes) > 1 && unicode.IsUpper(runes[0]) && strings.ToLower(string(runes[1:])) == string(runes[1:])
+}
+
+// round2 rounds to two decimal places
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// checkPasswordStrength rejects passwords scoring below minScore
+func checkPasswordStrength(password string, minScore int, userInputs ...string) error {
+	if minScore <= 0 {
+		return nil
+	}
+
+	analysis := analyzePassword(password, userInputs)
+	if analysis.Score >= minScore {
+		return nil
+	}
+
+	reason := analysis.Feedback.Warning
+	if reason == "" && len(analysis.Feedback.Suggestions) > 0 {
+		reason = analysis.Feedback.Suggestions[0]
+	}
+	return fmt.Errorf("%w (score %d of 4, at least %d required): %s", ErrWeakPassword, analysis.Score, minScore, reason)
+}
@@ -95,6 +95,14 @@ func (s *PastebinService) DeletePaste(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteExpiredPastes deletes all pastes past their expiry time
+func (s *PastebinService) DeleteExpiredPastes(ctx context.Context) error {
+	if err := s.repo.DeleteExpiredPastes(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired pastes: %w", err)
+	}
+	return nil
+}
+
 // ListRecentPastes lists recent public pastes
 func (s *PastebinService) ListRecentPastes(ctx context.Context, limit int) ([]*domain.Paste, error) {
 	if limit <= 0 || limit > 100 {
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/go-playground/validator/v10"
+)
+
+// PipelineRecipeRepository defines the interface for saved pipeline recipes
+type PipelineRecipeRepository interface {
+	SaveRecipe(ctx context.Context, recipe *domain.PipelineRecipe) error
+	GetRecipe(ctx context.Context, userID int64, name string) (*domain.PipelineRecipe, error) // nil when not found
+	ListRecipes(ctx context.Context, userID int64) ([]*domain.PipelineRecipe, error)
+	DeleteRecipe(ctx context.Context, userID int64, name string) (bool, error)
+}
+
+// maxPipelineBufferSize limits the buffer passed between pipeline steps
+const maxPipelineBufferSize = 1 << 20
+
+var (
+	// ErrInvalidPipeline is returned for unknown operations or invalid step arguments
+	ErrInvalidPipeline = errors.New("invalid pipeline")
+	// ErrPipelineRecipeNotFound is returned when a saved recipe does not exist
+	ErrPipelineRecipeNotFound = errors.New("pipeline recipe not found")
+	// ErrPipelineAuthRequired is returned when saved recipes are used anonymously
+	ErrPipelineAuthRequired = errors.New("authentication is required to use saved recipes")
+)
+
+// recipeNamePattern restricts recipe names to URL-safe characters
+var recipeNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,100}$`)
+
+// stepValidator validates step arguments with the same binding rules the
+// handlers apply to the standalone endpoints
+var stepValidator = sync.OnceValue(func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	return v
+})
+
+// pipelineFunc transforms the buffer of one pipeline step
+type pipelineFunc func(input []byte) ([]byte, error)
+
+// pipelineOp compiles the JSON arguments of a step into its transformation
+type pipelineOp func(args json.RawMessage) (pipelineFunc, error)
+
+// PipelineService chains hash, converter, crypto and generator operations
+type PipelineService struct {
+	repo PipelineRecipeRepository
+	ops  map[string]pipelineOp
+}
+
+// NewPipelineService creates a new pipeline service
+func NewPipelineService(repo PipelineRecipeRepository, hash *HashService, converter *ConverterService, crypto *CryptoService, generator *GeneratorService) *PipelineService {
+	return &PipelineService{
+		repo: repo,
+		ops: map[string]pipelineOp{
+			"encode": inputOp("Text", func(req *domain.EncodeRequest, input string) { req.Text = input }, hash.EncodeRaw),
+			"hash": inputOp("Text", func(req *domain.HashRequest, input string) { req.Text = input }, func(req *domain.HashRequest) ([]byte, error) {
+				if len(req.Algorithms) > 0 {
+					return nil, errors.New("algorithms is not supported, use one hash step per algorithm")
+				}
+				resp, err := hash.Hash(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Hash), nil
+			}),
+			"hmac": inputOp("Text", func(req *domain.HMACRequest, input string) { req.Text = input }, func(req *domain.HMACRequest) ([]byte, error) {
+				resp, err := crypto.HMACOperation(req)
+				if err != nil {
+					return nil, err
+				}
+				if resp.Valid != nil {
+					return []byte(strconv.FormatBool(*resp.Valid)), nil
+				}
+				return []byte(*resp.Signature), nil
+			}),
+			"aes": inputOp("Text", func(req *domain.AESRequest, input string) { req.Text = input }, func(req *domain.AESRequest) ([]byte, error) {
+				resp, err := crypto.AESOperation(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Result), nil
+			}),
+			"rsa": inputOp("Text", func(req *domain.RSARequest, input string) { req.Text = input }, func(req *domain.RSARequest) ([]byte, error) {
+				resp, err := crypto.RSAOperation(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Result), nil
+			}),
+			"format-json": inputOp("JSON", func(req *domain.FormatJSONRequest, input string) { req.JSON = input }, func(req *domain.FormatJSONRequest) ([]byte, error) {
+				resp, err := converter.FormatJSON(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Result), nil
+			}),
+			"convert-yaml": inputOp("Content", func(req *domain.ConvertYAMLRequest, input string) { req.Content = input }, func(req *domain.ConvertYAMLRequest) ([]byte, error) {
+				resp, err := converter.ConvertYAML(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Result), nil
+			}),
+			"convert-base": inputOp("Value", func(req *domain.ConvertBaseRequest, input string) { req.Value = strings.TrimSpace(input) }, func(req *domain.ConvertBaseRequest) ([]byte, error) {
+				resp, err := converter.ConvertBase(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Result), nil
+			}),
+			"convert-time": inputOp("Value", func(req *domain.ConvertTimeRequest, input string) { req.Value = strings.TrimSpace(input) }, func(req *domain.ConvertTimeRequest) ([]byte, error) {
+				resp, err := converter.ConvertTime(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Result), nil
+			}),
+			"convert-color": inputOp("Value", func(req *domain.ConvertColorRequest, input string) { req.Value = strings.TrimSpace(input) }, func(req *domain.ConvertColorRequest) ([]byte, error) {
+				resp, err := converter.ConvertColor(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Result), nil
+			}),
+			"generate-uuid": generatorOp(func(req *domain.GenerateUUIDRequest) ([]byte, error) {
+				resp, err := generator.GenerateUUID(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(strings.Join(resp.UUIDs, "\n")), nil
+			}),
+			"generate-token": generatorOp(func(req *domain.GenerateTokenRequest) ([]byte, error) {
+				resp, err := generator.GenerateToken(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Token), nil
+			}),
+			"generate-password": generatorOp(func(req *domain.GeneratePasswordRequest) ([]byte, error) {
+				resp, err := hash.GeneratePassword(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Password), nil
+			}),
+			"generate-lorem": generatorOp(func(req *domain.GenerateLoremRequest) ([]byte, error) {
+				resp, err := generator.GenerateLorem(req)
+				if err != nil {
+					return nil, err
+				}
+				return []byte(resp.Text), nil
+			}),
+			"gzip":   bufferOp(gzipBuffer),
+			"gunzip": bufferOp(gunzipBuffer),
+		},
+	}
+}
+
+// inputOp builds an operation from a service request whose input field,
+// named field and assigned by set, is filled with the step's input
+func inputOp[T any](field string, set func(*T, string), run func(*T) ([]byte, error)) pipelineOp {
+	return func(args json.RawMessage) (pipelineFunc, error) {
+		var base T
+		if err := decodeStepArgs(args, &base, field); err != nil {
+			return nil, err
+		}
+		return func(input []byte) ([]byte, error) {
+			req := base
+			set(&req, string(input))
+			return run(&req)
+		}, nil
+	}
+}
+
+// generatorOp builds an operation that ignores its input
+func generatorOp[T any](run func(*T) ([]byte, error)) pipelineOp {
+	return func(args json.RawMessage) (pipelineFunc, error) {
+		var base T
+		if err := decodeStepArgs(args, &base, ""); err != nil {
+			return nil, err
+		}
+		return func([]byte) ([]byte, error) {
+			req := base
+			return run(&req)
+		}, nil
+	}
+}
+
+// bufferOp builds an operation without arguments that transforms raw bytes
+func bufferOp(run pipelineFunc) pipelineOp {
+	return func(args json.RawMessage) (pipelineFunc, error) {
+		switch string(bytes.TrimSpace(args)) {
+		case "", "{}", "null":
+		default:
+			return nil, errors.New("operation takes no arguments")
+		}
+		return run, nil
+	}
+}
+
+// decodeStepArgs decodes and validates step arguments into req. The input
+// field is skipped during validation since it is filled at run time.
+func decodeStepArgs(args json.RawMessage, req any, inputField string) error {
+	if len(bytes.TrimSpace(args)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(args))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(req); err != nil {
+			return fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	var err error
+	if inputField == "" {
+		err = stepValidator().Struct(req)
+	} else {
+		err = stepValidator().StructExcept(req, inputField)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// gzipBuffer compresses the buffer with gzip
+func gzipBuffer(input []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(input); err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzipBuffer decompresses a gzip buffer
+func gunzipBuffer(input []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(input))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	defer r.Close()
+
+	// Security: Limit decompressed size to prevent decompression bombs
+	data, err := io.ReadAll(io.LimitReader(r, maxPipelineBufferSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	return data, nil
+}
+
+// compileSteps compiles pipeline steps, reporting the first invalid step
+func (s *PipelineService) compileSteps(steps []domain.PipelineStep) ([]pipelineFunc, error) {
+	funcs := make([]pipelineFunc, len(steps))
+	for i, step := range steps {
+		op, ok := s.ops[step.Op]
+		if !ok {
+			return nil, fmt.Errorf("%w: step %d: unsupported operation: %s", ErrInvalidPipeline, i+1, step.Op)
+		}
+		fn, err := op(step.Args)
+		if err != nil {
+			return nil, fmt.Errorf("%w: step %d (%s): %w", ErrInvalidPipeline, i+1, step.Op, err)
+		}
+		funcs[i] = fn
+	}
+	return funcs, nil
+}
+
+// RunPipeline runs the request's steps, or the caller's saved recipe, over the
+// input and returns the output of every step. Binary outputs are hex or
+// base64 encoded.
+func (s *PipelineService) RunPipeline(ctx context.Context, req *domain.PipelineRequest, userID *int64) (*domain.PipelineResponse, error) {
+	steps := req.Steps
+	if req.Recipe != nil {
+		if len(req.Steps) > 0 {
+			return nil, fmt.Errorf("%w: set either steps or recipe, not both", ErrInvalidPipeline)
+		}
+		if userID == nil {
+			return nil, ErrPipelineAuthRequired
+		}
+		recipe, err := s.GetRecipe(ctx, *userID, *req.Recipe)
+		if err != nil {
+			return nil, err
+		}
+		steps = recipe.Steps
+	}
+
+	funcs, err := s.compileSteps(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer, err := decodeInput(req.Input, req.InputEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPipeline, err)
+	}
+
+	response := &domain.PipelineResponse{Steps: make([]domain.PipelineStepResult, 0, len(steps))}
+	start := time.Now()
+	for i, fn := range funcs {
+		stepStart := time.Now()
+		output, err := fn(buffer)
+		if err == nil && len(output) > maxPipelineBufferSize {
+			err = fmt.Errorf("output exceeds maximum size of %d bytes", maxPipelineBufferSize)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: step %d (%s): %w", ErrInvalidPipeline, i+1, steps[i].Op, err)
+		}
+
+		result := domain.PipelineStepResult{
+			Op:         steps[i].Op,
+			DurationMs: durationMs(time.Since(stepStart)),
+		}
+		result.Output, result.Encoding = encodeOutput(output, req.BinaryEncoding)
+		result.Binary = result.Encoding != ""
+		response.Steps = append(response.Steps, result)
+		buffer = output
+	}
+
+	response.Output, response.Encoding = encodeOutput(buffer, req.BinaryEncoding)
+	response.Binary = response.Encoding != ""
+	response.DurationMs = durationMs(time.Since(start))
+
+	return response, nil
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// SaveRecipe creates or replaces a named recipe of the user. Steps are
+// compiled first so invalid recipes are rejected when saved.
+func (s *PipelineService) SaveRecipe(ctx context.Context, userID int64, name string, req *domain.SavePipelineRecipeRequest) (*domain.PipelineRecipe, error) {
+	if !recipeNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: recipe names must be 1-100 letters, digits, '-' or '_'", ErrInvalidPipeline)
+	}
+	if _, err := s.compileSteps(req.Steps); err != nil {
+		return nil, err
+	}
+
+	recipe := &domain.PipelineRecipe{
+		UserID: userID,
+		Name:   name,
+		Steps:  req.Steps,
+	}
+	if err := s.repo.SaveRecipe(ctx, recipe); err != nil {
+		return nil, fmt.Errorf("failed to save recipe: %w", err)
+	}
+
+	return recipe, nil
+}
+
+// GetRecipe retrieves a named recipe of the user
+func (s *PipelineService) GetRecipe(ctx context.Context, userID int64, name string) (*domain.PipelineRecipe, error) {
+	recipe, err := s.repo.GetRecipe(ctx, userID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipe: %w", err)
+	}
+	if recipe == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPipelineRecipeNotFound, name)
+	}
+	return recipe, nil
+}
+
+// ListRecipes lists the user's recipes ordered by name
+func (s *PipelineService) ListRecipes(ctx context.Context, userID int64) ([]*domain.PipelineRecipe, error) {
+	recipes, err := s.repo.ListRecipes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipes: %w", err)
+	}
+	return recipes, nil
+}
+
+// DeleteRecipe deletes a named recipe of the user
+func (s *PipelineService) DeleteRecipe(ctx context.Context, userID int64, name string) error {
+	deleted, err := s.repo.DeleteRecipe(ctx, userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete recipe: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %s", ErrPipelineRecipeNotFound, name)
+	}
+	return nil
+}
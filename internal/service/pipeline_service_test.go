@@ -0,0 +1,253 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+// memoryPipelineRecipeRepository keeps pipeline recipes in memory for tests
+type memoryPipelineRecipeRepository struct {
+	recipes map[string]*domain.PipelineRecipe
+	err     error // returned by GetRecipe when set, to simulate storage failures
+}
+
+func newMemoryPipelineRecipeRepository() *memoryPipelineRecipeRepository {
+	return &memoryPipelineRecipeRepository{recipes: make(map[string]*domain.PipelineRecipe)}
+}
+
+func recipeKey(userID int64, name string) string {
+	return fmt.Sprintf("%d/%s", userID, name)
+}
+
+func (r *memoryPipelineRecipeRepository) SaveRecipe(_ context.Context, recipe *domain.PipelineRecipe) error {
+	r.recipes[recipeKey(recipe.UserID, recipe.Name)] = recipe
+	return nil
+}
+
+func (r *memoryPipelineRecipeRepository) GetRecipe(_ context.Context, userID int64, name string) (*domain.PipelineRecipe, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.recipes[recipeKey(userID, name)], nil
+}
+
+func (r *memoryPipelineRecipeRepository) ListRecipes(_ context.Context, userID int64) ([]*domain.PipelineRecipe, error) {
+	var recipes []*domain.PipelineRecipe
+	for _, recipe := range r.recipes {
+		if recipe.UserID == userID {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes, nil
+}
+
+func (r *memoryPipelineRecipeRepository) DeleteRecipe(_ context.Context, userID int64, name string) (bool, error) {
+	key := recipeKey(userID, name)
+	_, ok := r.recipes[key]
+	delete(r.recipes, key)
+	return ok, nil
+}
+
+func newTestPipelineService() *PipelineService {
+	return NewPipelineService(newMemoryPipelineRecipeRepository(), NewHashService(), NewConverterService(), NewCryptoService(), NewGeneratorService())
+}
+
+func pipelineStep(op, args string) domain.PipelineStep {
+	return domain.PipelineStep{Op: op, Args: json.RawMessage(args)}
+}
+
+func gzipBase64(t *testing.T, data string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestRunPipeline(t *testing.T) {
+	svc := newTestPipelineService()
+
+	steps := []domain.PipelineStep{
+		pipelineStep("encode", `{"operation":"base64-decode"}`),
+		pipelineStep("gunzip", ""),
+		pipelineStep("format-json", `{"indent":2}`),
+		pipelineStep("hash", `{"algorithm":"sha256"}`),
+	}
+	result, err := svc.RunPipeline(context.Background(), &domain.PipelineRequest{
+		Input: gzipBase64(t, `{"b":1,"a":[true]}`),
+		Steps: steps,
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunPipeline failed: %v", err)
+	}
+
+	if len(result.Steps) != len(steps) {
+		t.Fatalf("Expected %d step results, got %d", len(steps), len(result.Steps))
+	}
+	// The decoded gzip data is binary
+	if !result.Steps[0].Binary || result.Steps[0].Encoding != "base64" {
+		t.Errorf("Expected binary base64 output for step 1, got %+v", result.Steps[0])
+	}
+
+	formatted := result.Steps[2].Output
+	if !strings.Contains(formatted, "\n  ") {
+		t.Errorf("Expected indented JSON, got %q", formatted)
+	}
+	sum := sha256.Sum256([]byte(formatted))
+	if result.Output != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected sha256 of formatted JSON, got %s", result.Output)
+	}
+}
+
+func TestRunPipelineBinaryOutput(t *testing.T) {
+	svc := newTestPipelineService()
+	hexEncoding := "hex"
+
+	result, err := svc.RunPipeline(context.Background(), &domain.PipelineRequest{
+		Input:          "hello",
+		BinaryEncoding: &hexEncoding,
+		Steps:          []domain.PipelineStep{pipelineStep("gzip", "{}"), pipelineStep("gunzip", "")},
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunPipeline failed: %v", err)
+	}
+	if !result.Steps[0].Binary || result.Steps[0].Encoding != "hex" || !strings.HasPrefix(result.Steps[0].Output, "1f8b") {
+		t.Errorf("Expected hex gzip output, got %+v", result.Steps[0])
+	}
+	if result.Output != "hello" || result.Binary {
+		t.Errorf("Expected round trip to hello, got %+v", result)
+	}
+}
+
+func TestRunPipelineInvalidSteps(t *testing.T) {
+	svc := newTestPipelineService()
+
+	tests := []struct {
+		name string
+		step domain.PipelineStep
+		want string
+	}{
+		{"unknown op", pipelineStep("reverse", ""), "step 1: unsupported operation: reverse"},
+		{"unknown arg", pipelineStep("hash", `{"algorithm":"sha256","algo":"md5"}`), "step 1 (hash): invalid arguments"},
+		{"invalid arg", pipelineStep("hash", `{"algorithm":"sha999"}`), "step 1 (hash): invalid arguments"},
+		{"missing arg", pipelineStep("encode", ""), "step 1 (encode): invalid arguments"},
+		{"args on gzip", pipelineStep("gzip", `{"level":9}`), "step 1 (gzip): operation takes no arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.RunPipeline(context.Background(), &domain.PipelineRequest{
+				Input: "x",
+				Steps: []domain.PipelineStep{tt.step},
+			}, nil)
+			if !errors.Is(err, ErrInvalidPipeline) {
+				t.Fatalf("Expected ErrInvalidPipeline, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Failures while running report the failing step
+	_, err := svc.RunPipeline(context.Background(), &domain.PipelineRequest{
+		Input: "not base64!",
+		Steps: []domain.PipelineStep{pipelineStep("encode", `{"operation":"url-encode"}`), pipelineStep("encode", `{"operation":"base64-decode"}`)},
+	}, nil)
+	if !errors.Is(err, ErrInvalidPipeline) || !strings.Contains(err.Error(), "step 2 (encode)") {
+		t.Errorf("Expected step 2 failure, got %v", err)
+	}
+}
+
+func TestGunzipLimit(t *testing.T) {
+	svc := newTestPipelineService()
+
+	bomb := gzipBase64(t, strings.Repeat("a", maxPipelineBufferSize+1))
+	base64Encoding := "base64"
+	_, err := svc.RunPipeline(context.Background(), &domain.PipelineRequest{
+		Input:         bomb,
+		InputEncoding: &base64Encoding,
+		Steps:         []domain.PipelineStep{pipelineStep("gunzip", "")},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeds maximum size") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+}
+
+func TestPipelineRecipes(t *testing.T) {
+	svc := newTestPipelineService()
+	ctx := context.Background()
+	userID, otherID := int64(1), int64(2)
+
+	_, err := svc.SaveRecipe(ctx, userID, "b64-sha1", &domain.SavePipelineRecipeRequest{
+		Steps: []domain.PipelineStep{pipelineStep("encode", `{"operation":"base64-decode"}`), pipelineStep("hash", `{"algorithm":"sha1"}`)},
+	})
+	if err != nil {
+		t.Fatalf("SaveRecipe failed: %v", err)
+	}
+
+	recipe := "b64-sha1"
+	result, err := svc.RunPipeline(ctx, &domain.PipelineRequest{Input: "aGVsbG8=", Recipe: &recipe}, &userID)
+	if err != nil {
+		t.Fatalf("RunPipeline with recipe failed: %v", err)
+	}
+	if result.Output != "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d" {
+		t.Errorf("Unexpected recipe output: %s", result.Output)
+	}
+
+	if _, err := svc.RunPipeline(ctx, &domain.PipelineRequest{Recipe: &recipe}, nil); !errors.Is(err, ErrPipelineAuthRequired) {
+		t.Errorf("Expected ErrPipelineAuthRequired, got %v", err)
+	}
+	if _, err := svc.RunPipeline(ctx, &domain.PipelineRequest{Recipe: &recipe}, &otherID); !errors.Is(err, ErrPipelineRecipeNotFound) {
+		t.Errorf("Expected ErrPipelineRecipeNotFound for other user, got %v", err)
+	}
+	if _, err := svc.RunPipeline(ctx, &domain.PipelineRequest{Recipe: &recipe, Steps: []domain.PipelineStep{pipelineStep("gzip", "")}}, &userID); !errors.Is(err, ErrInvalidPipeline) {
+		t.Errorf("Expected ErrInvalidPipeline for both steps and recipe, got %v", err)
+	}
+
+	// Invalid recipes are rejected when saved
+	if _, err := svc.SaveRecipe(ctx, userID, "bad", &domain.SavePipelineRecipeRequest{
+		Steps: []domain.PipelineStep{pipelineStep("nope", "")},
+	}); !errors.Is(err, ErrInvalidPipeline) {
+		t.Errorf("Expected ErrInvalidPipeline for unknown op, got %v", err)
+	}
+	if _, err := svc.SaveRecipe(ctx, userID, "bad name", &domain.SavePipelineRecipeRequest{
+		Steps: []domain.PipelineStep{pipelineStep("gzip", "")},
+	}); !errors.Is(err, ErrInvalidPipeline) {
+		t.Errorf("Expected ErrInvalidPipeline for invalid name, got %v", err)
+	}
+
+	if err := svc.DeleteRecipe(ctx, userID, recipe); err != nil {
+		t.Fatalf("DeleteRecipe failed: %v", err)
+	}
+	if err := svc.DeleteRecipe(ctx, userID, recipe); !errors.Is(err, ErrPipelineRecipeNotFound) {
+		t.Errorf("Expected ErrPipelineRecipeNotFound after delete, got %v", err)
+	}
+}
+
+func TestGetRecipeStorageError(t *testing.T) {
+	repo := newMemoryPipelineRecipeRepository()
+	repo.err = errors.New("connection refused")
+	svc := NewPipelineService(repo, NewHashService(), NewConverterService(), NewCryptoService(), NewGeneratorService())
+
+	_, err := svc.GetRecipe(context.Background(), 1, "b64-sha1")
+	if err == nil || errors.Is(err, ErrPipelineRecipeNotFound) {
+		t.Errorf("Expected a storage error rather than not found, got %v", err)
+	}
+}
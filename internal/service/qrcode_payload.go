@@ -0,0 +1,355 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+// QR payload types
+const (
+	QRTypeText   = "text"
+	QRTypeWiFi   = "wifi"
+	QRTypeVCard  = "vcard"
+	QRTypeMeCard = "mecard"
+	QRTypeGeo    = "geo"
+	QRTypeVEvent = "vevent"
+	QRTypeSMS    = "sms"
+	QRTypeMailto = "mailto"
+	QRTypeEPC    = "epc"
+)
+
+// buildQRPayload builds the text to encode for the given payload type
+func buildQRPayload(payloadType string, req *domain.GenerateQRRequest) (string, error) {
+	switch payloadType {
+	case QRTypeText:
+		if req.Text == "" {
+			return "", fmt.Errorf("text is required for type %s", QRTypeText)
+		}
+		return req.Text, nil
+	case QRTypeWiFi:
+		if req.WiFi == nil {
+			return "", fmt.Errorf("wifi is required for type %s", QRTypeWiFi)
+		}
+		return buildWiFiPayload(req.WiFi), nil
+	case QRTypeVCard, QRTypeMeCard:
+		if req.Contact == nil {
+			return "", fmt.Errorf("contact is required for type %s", payloadType)
+		}
+		if payloadType == QRTypeMeCard {
+			return buildMeCardPayload(req.Contact), nil
+		}
+		return buildVCardPayload(req.Contact), nil
+	case QRTypeGeo:
+		if req.Geo == nil {
+			return "", fmt.Errorf("geo is required for type %s", QRTypeGeo)
+		}
+		return buildGeoPayload(req.Geo), nil
+	case QRTypeVEvent:
+		if req.Event == nil {
+			return "", fmt.Errorf("event is required for type %s", QRTypeVEvent)
+		}
+		return buildVEventPayload(req.Event)
+	case QRTypeSMS:
+		if req.SMS == nil {
+			return "", fmt.Errorf("sms is required for type %s", QRTypeSMS)
+		}
+		return buildSMSPayload(req.SMS)
+	case QRTypeMailto:
+		if req.Mailto == nil {
+			return "", fmt.Errorf("mailto is required for type %s", QRTypeMailto)
+		}
+		return buildMailtoPayload(req.Mailto), nil
+	case QRTypeEPC:
+		if req.Payment == nil {
+			return "", fmt.Errorf("payment is required for type %s", QRTypeEPC)
+		}
+		return buildEPCPayload(req.Payment)
+	default:
+		return "", fmt.Errorf("unsupported QR payload type: %s", payloadType)
+	}
+}
+
+// meCardEscaper escapes the reserved characters of the WIFI: and MECARD: formats
+var meCardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+// vCardEscaper escapes text values in vCard and iCalendar content lines (RFC 6350, RFC 5545)
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// buildWiFiPayload builds a WIFI: network configuration payload
+func buildWiFiPayload(w *domain.QRWiFiPayload) string {
+	auth := w.Auth
+	if auth == "" {
+		auth = "WPA"
+	}
+
+	var b strings.Builder
+	b.WriteString("WIFI:T:" + auth + ";S:" + meCardEscaper.Replace(w.SSID) + ";")
+	if auth != "nopass" && w.Password != "" {
+		b.WriteString("P:" + meCardEscaper.Replace(w.Password) + ";")
+	}
+	if w.Hidden {
+		b.WriteString("H:true;")
+	}
+	b.WriteString(";")
+
+	return b.String()
+}
+
+// buildMeCardPayload builds a compact MECARD: contact payload
+func buildMeCardPayload(c *domain.QRContactPayload) string {
+	var b strings.Builder
+	b.WriteString("MECARD:")
+
+	field := func(name, value string) {
+		if value != "" {
+			b.WriteString(name + ":" + meCardEscaper.Replace(value) + ";")
+		}
+	}
+
+	// The comma separating last and first name is part of the format
+	name := meCardEscaper.Replace(c.LastName)
+	if c.FirstName != "" {
+		if name != "" {
+			name += ","
+		}
+		name += meCardEscaper.Replace(c.FirstName)
+	}
+	if name != "" {
+		b.WriteString("N:" + name + ";")
+	}
+	field("ORG", c.Organization)
+	field("TEL", c.Phone)
+	field("EMAIL", c.Email)
+	field("ADR", c.Address)
+	field("URL", c.URL)
+	field("NOTE", c.Note)
+	b.WriteString(";")
+
+	return b.String()
+}
+
+// buildVCardPayload builds a vCard 3.0 contact payload
+func buildVCardPayload(c *domain.QRContactPayload) string {
+	var b strings.Builder
+
+	line := func(name, value string) {
+		if value != "" {
+			b.WriteString(name + ":" + value + "\r\n")
+		}
+	}
+
+	fullName := strings.TrimSpace(c.FirstName + " " + c.LastName)
+	if fullName == "" {
+		fullName = c.Organization
+	}
+
+	b.WriteString("BEGIN:VCARD\r\n")
+	b.WriteString("VERSION:3.0\r\n")
+	b.WriteString("N:" + vCardEscaper.Replace(c.LastName) + ";" + vCardEscaper.Replace(c.FirstName) + ";;;\r\n")
+	b.WriteString("FN:" + vCardEscaper.Replace(fullName) + "\r\n")
+	line("ORG", vCardEscaper.Replace(c.Organization))
+	line("TITLE", vCardEscaper.Replace(c.Title))
+	line("TEL;TYPE=CELL", vCardEscaper.Replace(c.Phone))
+	line("EMAIL", vCardEscaper.Replace(c.Email))
+	if c.Address != "" {
+		// Free-form address goes into the street component
+		b.WriteString("ADR:;;" + vCardEscaper.Replace(c.Address) + ";;;;\r\n")
+	}
+	line("URL", c.URL)
+	line("NOTE", vCardEscaper.Replace(c.Note))
+	b.WriteString("END:VCARD")
+
+	return b.String()
+}
+
+// buildGeoPayload builds an RFC 5870 geo: URI
+func buildGeoPayload(g *domain.QRGeoPayload) string {
+	payload := "geo:" + formatCoordinate(g.Latitude) + "," + formatCoordinate(g.Longitude)
+	if g.Altitude != nil {
+		payload += "," + formatCoordinate(*g.Altitude)
+	}
+	if g.Label != "" {
+		payload += "?q=" + percentEncode(g.Label)
+	}
+	return payload
+}
+
+// formatCoordinate formats a coordinate with the minimum digits needed
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// icalTimeLayout is the UTC date-time form used in iCalendar properties
+const icalTimeLayout = "20060102T150405Z"
+
+// buildVEventPayload builds an iCalendar VEVENT payload
+func buildVEventPayload(e *domain.QREventPayload) (string, error) {
+	if e.End != nil && e.End.Before(e.Start) {
+		return "", fmt.Errorf("event end must not be before start")
+	}
+
+	var b strings.Builder
+	b.WriteString("BEGIN:VEVENT\r\n")
+	b.WriteString("SUMMARY:" + vCardEscaper.Replace(e.Summary) + "\r\n")
+	b.WriteString("DTSTART:" + e.Start.UTC().Format(icalTimeLayout) + "\r\n")
+	if e.End != nil {
+		b.WriteString("DTEND:" + e.End.UTC().Format(icalTimeLayout) + "\r\n")
+	}
+	if e.Location != "" {
+		b.WriteString("LOCATION:" + vCardEscaper.Replace(e.Location) + "\r\n")
+	}
+	if e.Description != "" {
+		b.WriteString("DESCRIPTION:" + vCardEscaper.Replace(e.Description) + "\r\n")
+	}
+	b.WriteString("DTSTAMP:" + time.Now().UTC().Format(icalTimeLayout) + "\r\n")
+	b.WriteString("END:VEVENT")
+
+	return b.String(), nil
+}
+
+// buildSMSPayload builds an SMSTO: payload
+func buildSMSPayload(m *domain.QRSMSPayload) (string, error) {
+	phone, err := normalizePhone(m.Phone)
+	if err != nil {
+		return "", err
+	}
+	// Readers split on the first two colons only, so the message needs no escaping
+	return "SMSTO:" + phone + ":" + m.Message, nil
+}
+
+// normalizePhone strips formatting characters from a phone number
+func normalizePhone(phone string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			// formatting, dropped
+		default:
+			return "", fmt.Errorf("invalid phone number: %q", phone)
+		}
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("invalid phone number: %q", phone)
+	}
+	return b.String(), nil
+}
+
+// buildMailtoPayload builds an RFC 6068 mailto: URI
+func buildMailtoPayload(m *domain.QRMailtoPayload) string {
+	var params []string
+	if m.Cc != "" {
+		params = append(params, "cc="+percentEncode(m.Cc))
+	}
+	if m.Subject != "" {
+		params = append(params, "subject="+percentEncode(m.Subject))
+	}
+	if m.Body != "" {
+		params = append(params, "body="+percentEncode(strings.ReplaceAll(m.Body, "\n", "\r\n")))
+	}
+
+	payload := "mailto:" + url.PathEscape(m.To)
+	if len(params) > 0 {
+		payload += "?" + strings.Join(params, "&")
+	}
+	return payload
+}
+
+// percentEncode escapes a URI query value, encoding spaces as %20 as
+// required by mailto: and geo: URIs
+func percentEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// buildEPCPayload builds a European Payments Council SEPA credit transfer
+// payload (EPC069-12, version 002)
+func buildEPCPayload(p *domain.QRPaymentPayload) (string, error) {
+	iban := strings.ToUpper(strings.ReplaceAll(p.IBAN, " ", ""))
+	if !validIBAN(iban) {
+		return "", fmt.Errorf("invalid IBAN: %s", p.IBAN)
+	}
+	if p.BIC != "" && !validBIC(p.BIC) {
+		return "", fmt.Errorf("invalid BIC: %s", p.BIC)
+	}
+
+	// Fields are separated by line breaks, and EPC limits their length in bytes
+	for _, field := range []struct {
+		name, value string
+		max         int
+	}{
+		{"name", p.Name, 70},
+		{"remittance", p.Remittance, 140},
+	} {
+		if strings.ContainsAny(field.value, "\r\n") {
+			return "", fmt.Errorf("payment %s must not contain line breaks", field.name)
+		}
+		if len(field.value) > field.max {
+			return "", fmt.Errorf("payment %s exceeds %d bytes", field.name, field.max)
+		}
+	}
+
+	amount := ""
+	if p.Amount > 0 {
+		amount = "EUR" + strconv.FormatFloat(p.Amount, 'f', 2, 64)
+	}
+
+	lines := []string{
+		"BCD",
+		"002",
+		"1", // UTF-8
+		"SCT",
+		strings.ToUpper(p.BIC),
+		p.Name,
+		iban,
+		amount,
+		"", // purpose code
+		"", // structured creditor reference
+		p.Remittance,
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n"), nil
+}
+
+// validBIC checks that a BIC has 8 or 11 letters and digits
+func validBIC(bic string) bool {
+	if len(bic) != 8 && len(bic) != 11 {
+		return false
+	}
+	for _, r := range bic {
+		if !(r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// validIBAN checks the length, character set and ISO 7064 mod 97 checksum of an IBAN
+func validIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	// Move the country code and check digits to the end, then map letters to 10-35
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, r := range rearranged {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+
+	return remainder == 1
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// QR code output formats
+const (
+	QRFormatPNG  = "png"
+	QRFormatSVG  = "svg"
+	QRFormatEPS  = "eps"
+	QRFormatText = "text"
+	QRFormatUTF8 = "utf8"
+)
+
+// QRCodeContentType returns the MIME type used to serve a QR code stored in the given format
+func QRCodeContentType(format string) string {
+	switch format {
+	case QRFormatSVG:
+		return "image/svg+xml"
+	case QRFormatEPS:
+		return "application/postscript"
+	case QRFormatText, QRFormatUTF8:
+		return "text/plain; charset=utf-8"
+	default:
+		return "image/png"
+	}
+}
+
+// qrStyle holds the visual options applied when rendering a QR code
+type qrStyle struct {
+	Foreground color.Color
+	Background color.Color
+	// QuietZone is the width of the light border in modules
+	QuietZone int
+	// Logo is composited onto the center of PNG output when set
+	Logo image.Image
+}
+
+// defaultQRStyle returns black-on-white styling with the standard 4-module quiet zone
+func defaultQRStyle() qrStyle {
+	return qrStyle{
+		Foreground: color.Black,
+		Background: color.White,
+		QuietZone:  4,
+	}
+}
+
+// renderQRCode renders an encoded QR code in the requested output format.
+// size is the image width and height in pixels (points for EPS) and is
+// ignored by the terminal formats.
+func renderQRCode(q *qrcode.QRCode, format string, size int, style qrStyle) ([]byte, error) {
+	if style.Logo != nil && format != QRFormatPNG {
+		return nil, fmt.Errorf("logo overlay is only supported for %s format", QRFormatPNG)
+	}
+
+	q.DisableBorder = true
+	bitmap := withQuietZone(q.Bitmap(), style.QuietZone)
+
+	switch format {
+	case QRFormatPNG:
+		return renderPNG(bitmap, size, style)
+	case QRFormatSVG:
+		return renderSVG(bitmap, size, style), nil
+	case QRFormatEPS:
+		return renderEPS(bitmap, size, style), nil
+	case QRFormatText:
+		return renderText(bitmap), nil
+	case QRFormatUTF8:
+		return renderUTF8(bitmap), nil
+	default:
+		return nil, fmt.Errorf("unsupported QR code format: %s", format)
+	}
+}
+
+// withQuietZone surrounds a borderless bitmap with margin light modules
+func withQuietZone(bitmap [][]bool, margin int) [][]bool {
+	return withMargins(bitmap, margin, margin)
+}
+
+// withMargins surrounds a bitmap with mx light modules on the left and right
+// and my light modules on the top and bottom
+func withMargins(bitmap [][]bool, mx, my int) [][]bool {
+	width := 2 * mx
+	if len(bitmap) > 0 {
+		width += len(bitmap[0])
+	}
+
+	out := make([][]bool, len(bitmap)+2*my)
+	for y := range out {
+		out[y] = make([]bool, width)
+	}
+	for y, row := range bitmap {
+		copy(out[y+my][mx:], row)
+	}
+	return out
+}
+
+// renderPNG rasterizes the bitmap to a size x size PNG, growing the image if
+// it is too small to give every module at least one pixel
+func renderPNG(bitmap [][]bool, size int, style qrStyle) ([]byte, error) {
+	n := len(bitmap)
+	if size < n {
+		size = n
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: style.Background}, image.Point{}, draw.Src)
+
+	modulesPerPixel := float64(n) / float64(size)
+	for y := 0; y < size; y++ {
+		my := int(float64(y) * modulesPerPixel)
+		for x := 0; x < size; x++ {
+			if bitmap[my][int(float64(x)*modulesPerPixel)] {
+				img.Set(x, y, style.Foreground)
+			}
+		}
+	}
+
+	if style.Logo != nil {
+		overlayLogo(img, style.Logo, style.Background)
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// qrLogoScale is the fraction of the QR code width covered by a logo. At
+// error correction level H up to 30% of the symbol can be lost, so a logo
+// spanning a fifth of the width (4% of the area) plus its pad stays readable.
+const qrLogoScale = 0.2
+
+// overlayLogo scales the logo to fit the center of the image and draws it on
+// a padded background-colored plate
+func overlayLogo(img *image.RGBA, logo image.Image, background color.Color) {
+	size := img.Bounds().Dx()
+	box := int(float64(size) * qrLogoScale)
+	if box < 1 {
+		return
+	}
+
+	scaled := scaleImage(logo, box)
+	w, h := scaled.Bounds().Dx(), scaled.Bounds().Dy()
+	pad := box / 10
+
+	plate := image.Rect((size-w)/2-pad, (size-h)/2-pad, (size+w)/2+pad, (size+h)/2+pad)
+	draw.Draw(img, plate, &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	target := image.Rect((size-w)/2, (size-h)/2, (size-w)/2+w, (size-h)/2+h)
+	draw.Draw(img, target, scaled, image.Point{}, draw.Over)
+}
+
+// scaleImage resizes src with box filtering so its longest side equals box,
+// preserving the aspect ratio
+func scaleImage(src image.Image, box int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	w, h := box, box
+	if sw > sh {
+		h = max(1, box*sh/sw)
+	} else {
+		w = max(1, box*sw/sh)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*sh/h
+		y1 := max(y0+1, b.Min.Y+(y+1)*sh/h)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*sw/w
+			x1 := max(x0+1, b.Min.X+(x+1)*sw/w)
+
+			var r, g, bl, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					count++
+				}
+			}
+
+			// #nosec G115 - averages of 16-bit channels shifted to 8 bits
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r / count) >> 8),
+				G: uint8((g / count) >> 8),
+				B: uint8((bl / count) >> 8),
+				A: uint8((a / count) >> 8),
+			})
+		}
+	}
+
+	return dst
+}
+
+// hexColor formats a color as #rrggbb
+func hexColor(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+// psColor formats a color as PostScript setrgbcolor operands
+func psColor(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("%.4f %.4f %.4f", float64(rgba.R)/255, float64(rgba.G)/255, float64(rgba.B)/255)
+}
+
+// moduleRun is a horizontal run of dark modules in a bitmap row
+type moduleRun struct {
+	x, y, width int
+}
+
+// darkRuns collapses each bitmap row into horizontal runs of dark modules so
+// vector output needs one shape per run instead of one per module
+func darkRuns(bitmap [][]bool) []moduleRun {
+	var runs []moduleRun
+	for y, row := range bitmap {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			runs = append(runs, moduleRun{x: start, y: y, width: x - start})
+		}
+	}
+	return runs
+}
+
+// renderSVG renders the bitmap as an SVG document scaled to size pixels
+func renderSVG(bitmap [][]bool, size int, style qrStyle) []byte {
+	n := len(bitmap)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n", size, size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`+"\n", n, n, hexColor(style.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hexColor(style.Foreground))
+	for _, r := range darkRuns(bitmap) {
+		fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", r.x, r.y, r.width, r.width)
+	}
+	buf.WriteString(`"/>` + "\n</svg>\n")
+
+	return buf.Bytes()
+}
+
+// renderEPS renders the bitmap as an Encapsulated PostScript document with a
+// bounding box of size points
+func renderEPS(bitmap [][]bool, size int, style qrStyle) []byte {
+	n := len(bitmap)
+	scale := strconv.FormatFloat(float64(size)/float64(n), 'f', 4, 64)
+
+	var buf bytes.Buffer
+	buf.WriteString("%!PS-Adobe-3.0 EPSF-3.0\n")
+	buf.WriteString("%%Creator: GoPilot\n")
+	fmt.Fprintf(&buf, "%%%%BoundingBox: 0 0 %d %d\n", size, size)
+	buf.WriteString("%%EndComments\n")
+	buf.WriteString("gsave\n")
+	fmt.Fprintf(&buf, "%s %s scale\n", scale, scale)
+	fmt.Fprintf(&buf, "%s setrgbcolor 0 0 %d %d rectfill\n", psColor(style.Background), n, n)
+	fmt.Fprintf(&buf, "%s setrgbcolor\n", psColor(style.Foreground))
+	for _, r := range darkRuns(bitmap) {
+		// PostScript's origin is the bottom-left corner
+		fmt.Fprintf(&buf, "%d %d %d 1 rectfill\n", r.x, n-r.y-1, r.width)
+	}
+	buf.WriteString("grestore\n")
+	buf.WriteString("showpage\n")
+	buf.WriteString("%%EOF\n")
+
+	return buf.Bytes()
+}
+
+// renderText renders the bitmap as plain ASCII, two characters per module.
+// Light modules are drawn so the code scans on dark terminal backgrounds.
+func renderText(bitmap [][]bool) []byte {
+	var buf bytes.Buffer
+	for _, row := range bitmap {
+		for _, dark := range row {
+			if dark {
+				buf.WriteString("  ")
+			} else {
+				buf.WriteString("##")
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// renderUTF8 renders the bitmap with Unicode half blocks, packing two module
+// rows into each line of text. Like renderText, light modules are drawn.
+func renderUTF8(bitmap [][]bool) []byte {
+	var buf bytes.Buffer
+	for y := 0; y < len(bitmap); y += 2 {
+		for x := range bitmap[y] {
+			top := !bitmap[y][x]
+			bottom := false
+			if y+1 < len(bitmap) {
+				bottom = !bitmap[y+1][x]
+			}
+
+			switch {
+			case top && bottom:
+				buf.WriteString("█")
+			case top:
+				buf.WriteString("▀")
+			case bottom:
+				buf.WriteString("▄")
+			default:
+				buf.WriteString(" ")
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
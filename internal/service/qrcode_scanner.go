@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+	"github.com/makiuchi-d/gozxing"
+	multiqr "github.com/makiuchi-d/gozxing/multi/qrcode"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// qrScanHints makes the reader spend extra effort on skewed or noisy images
+var qrScanHints = map[gozxing.DecodeHintType]interface{}{
+	gozxing.DecodeHintType_TRY_HARDER: true,
+}
+
+// scanQRCodes finds every QR code in the image. Light-on-dark codes are
+// found by retrying with inverted luminance when the normal pass finds none.
+func scanQRCodes(img image.Image) ([]*gozxing.Result, error) {
+	source := gozxing.NewLuminanceSourceFromImage(img)
+
+	for _, src := range []gozxing.LuminanceSource{source, gozxing.NewInvertedLuminanceSource(source)} {
+		results, err := scanLuminance(src)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) > 0 {
+			return results, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// scanLuminance runs the multi-code reader and falls back to the single-code
+// reader, which copes better with a code filling the whole image
+func scanLuminance(source gozxing.LuminanceSource) ([]*gozxing.Result, error) {
+	bitmap, err := gozxing.NewBinaryBitmap(gozxing.NewHybridBinarizer(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to binarize image: %w", err)
+	}
+
+	results, err := multiqr.NewQRCodeMultiReader().DecodeMultiple(bitmap, qrScanHints)
+	if err == nil && len(results) > 0 {
+		return results, nil
+	}
+	if err != nil && !isQRNotFound(err) {
+		return nil, fmt.Errorf("failed to decode QR code: %w", err)
+	}
+
+	result, err := qrcode.NewQRCodeReader().Decode(bitmap, qrScanHints)
+	if err != nil {
+		if isQRNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to decode QR code: %w", err)
+	}
+
+	return []*gozxing.Result{result}, nil
+}
+
+// isQRNotFound reports whether a reader error means no readable code was
+// present rather than a failure of the reader itself
+func isQRNotFound(err error) bool {
+	var notFound gozxing.NotFoundException
+	var checksum gozxing.ChecksumException
+	var format gozxing.FormatException
+	return errors.As(err, &notFound) || errors.As(err, &checksum) || errors.As(err, &format)
+}
+
+// moduleSizer is implemented by finder and alignment pattern result points
+type moduleSizer interface {
+	GetEstimatedModuleSize() float64
+}
+
+// toDecodedQRCode converts a reader result into its API representation. The
+// result points are finder pattern centers, so the bounding box is grown by
+// 3.5 modules to reach the outer edge of the finder patterns.
+func toDecodedQRCode(result *gozxing.Result) domain.DecodedQRCode {
+	points := result.GetResultPoints()
+	decoded := domain.DecodedQRCode{
+		Text:   result.GetText(),
+		Points: make([]domain.QRPoint, 0, len(points)),
+	}
+	if len(points) == 0 {
+		return decoded
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	pad := 0.0
+	for _, p := range points {
+		x, y := p.GetX(), p.GetY()
+		decoded.Points = append(decoded.Points, domain.QRPoint{X: x, Y: y})
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+		if sizer, ok := p.(moduleSizer); ok {
+			pad = math.Max(pad, 3.5*sizer.GetEstimatedModuleSize())
+		}
+	}
+
+	x := int(math.Max(0, math.Floor(minX-pad)))
+	y := int(math.Max(0, math.Floor(minY-pad)))
+	decoded.Bounds = domain.QRBounds{
+		X:      x,
+		Y:      y,
+		Width:  int(math.Ceil(maxX+pad)) - x,
+		Height: int(math.Ceil(maxY+pad)) - y,
+	}
+
+	return decoded
+}
@@ -1,10 +1,17 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder for uploaded images
+	_ "image/jpeg" // register JPEG decoder for uploaded images
+	_ "image/png"  // register PNG decoder for uploaded images
+	"time"
 
 	"github.com/codewithwan/gopilot/internal/domain"
 	"github.com/skip2/go-qrcode"
@@ -14,53 +21,108 @@ import (
 type QRCodeRepository interface {
 	CreateQRCode(ctx context.Context, qr *domain.QRCode) error
 	GetQRCodeByID(ctx context.Context, id string) (*domain.QRCode, error)
+	ListQRCodesByUser(ctx context.Context, userID int64, limit, offset int32) ([]*domain.QRCode, error)
+	CountQRCodesByUser(ctx context.Context, userID int64) (int64, error)
+	DeleteQRCode(ctx context.Context, id string) error
+	DeleteExpiredQRCodes(ctx context.Context) error
 }
 
-// QRCodeService handles QR code generation
+// QRCodeService handles QR code and barcode generation
 type QRCodeService struct {
-	repo QRCodeRepository
+	repo      QRCodeRepository
+	converter *ConverterService
+	shortener *URLShortenerService
+	publicURL string
 }
 
-// NewQRCodeService creates a new QR code service
-func NewQRCodeService(repo QRCodeRepository) *QRCodeService {
-	return &QRCodeService{repo: repo}
+// NewQRCodeService creates a new QR code service. Dynamic QR codes encode
+// short URLs created by shortener under publicURL.
+func NewQRCodeService(repo QRCodeRepository, shortener *URLShortenerService, publicURL string) *QRCodeService {
+	return &QRCodeService{
+		repo:      repo,
+		converter: NewConverterService(),
+		shortener: shortener,
+		publicURL: publicURL,
+	}
 }
 
-// GenerateQR generates a QR code
-func (s *QRCodeService) GenerateQR(ctx context.Context, req *domain.GenerateQRRequest) (*domain.QRCode, error) {
-	size := 256
-	if req.Size != nil {
-		size = *req.Size
+// qrRecoveryLevels maps error correction level names to encoder levels
+var qrRecoveryLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// maxQRImageDimension caps the width and height of decoded logo and scan images
+const maxQRImageDimension = 4096
+
+// qrOptions holds the resolved rendering options of a request
+type qrOptions struct {
+	Format          string
+	Size            int
+	ErrorCorrection string
+	Style           qrStyle
+}
+
+// GenerateQR generates a QR code owned by userID, which is nil for anonymous requests.
+// Only the text and style are stored; the image is rendered when retrieved.
+func (s *QRCodeService) GenerateQR(ctx context.Context, req *domain.GenerateQRRequest, userID *int64) (*domain.QRCode, error) {
+	opts, err := s.resolveOptions(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
 	}
 
-	format := "png"
-	if req.Format != nil {
-		format = *req.Format
+	payloadType := QRTypeText
+	if req.Type != nil {
+		payloadType = *req.Type
 	}
 
-	var imageData []byte
-	var err error
+	text, err := buildQRPayload(payloadType, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
+	}
 
-	if format == "png" {
-		imageData, err = qrcode.Encode(req.Text, qrcode.Medium, size)
+	var expiresAt *time.Time
+	if req.ExpireIn != nil {
+		expiry := time.Now().Add(time.Duration(*req.ExpireIn) * time.Hour)
+		expiresAt = &expiry
+	}
+
+	qr := &domain.QRCode{
+		Symbology:       SymbologyQR,
+		Type:            payloadType,
+		Format:          opts.Format,
+		Size:            opts.Size,
+		ErrorCorrection: opts.ErrorCorrection,
+		Foreground:      req.Foreground,
+		Background:      req.Background,
+		QuietZone:       opts.Style.QuietZone,
+		Logo:            req.Logo,
+		UserID:          userID,
+		ExpiresAt:       expiresAt,
+	}
+
+	if req.Dynamic {
+		shortURL, err := s.createDynamicLink(ctx, payloadType, text, req.ExpireIn)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate QR code: %w", err)
+			return nil, err
 		}
-	} else {
-		// For SVG, we'll store a placeholder (full SVG support would require additional library)
-		return nil, fmt.Errorf("SVG format not yet supported")
+		qr.ShortCode = &shortURL.Code
+		qr.Destination = shortURL.OriginalURL
+		qr.EditToken = shortURL.EditToken
+		text = s.publicURL + "/s/" + shortURL.Code
 	}
 
-	id := s.generateID(10)
-
-	qr := &domain.QRCode{
-		ID:        id,
-		Text:      req.Text,
-		Format:    format,
-		Size:      size,
-		ImageData: imageData,
+	imageData, err := encodeQR(text, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
 	}
 
+	qr.ID = s.generateID(10)
+	qr.Text = text
+	qr.ImageData = imageData
+
 	if err := s.repo.CreateQRCode(ctx, qr); err != nil {
 		return nil, fmt.Errorf("failed to save QR code: %w", err)
 	}
@@ -68,16 +130,300 @@ func (s *QRCodeService) GenerateQR(ctx context.Context, req *domain.GenerateQRRe
 	return qr, nil
 }
 
-// GetQRCode retrieves a QR code by ID
-func (s *QRCodeService) GetQRCode(ctx context.Context, id string) (*domain.QRCode, error) {
+// RenderQR renders a QR code for plain text without storing it and returns
+// the image along with its format
+func (s *QRCodeService) RenderQR(req *domain.RenderQRRequest) ([]byte, string, error) {
+	opts, err := s.resolveOptions(&domain.GenerateQRRequest{
+		Size:            req.Size,
+		Format:          req.Format,
+		ErrorCorrection: req.ErrorCorrection,
+		Foreground:      req.Foreground,
+		Background:      req.Background,
+		QuietZone:       req.QuietZone,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	imageData, err := encodeQR(req.Text, opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return imageData, opts.Format, nil
+}
+
+// resolveOptions applies defaults to the format, size and error correction
+// level of a request and resolves its style
+func (s *QRCodeService) resolveOptions(req *domain.GenerateQRRequest) (qrOptions, error) {
+	opts := qrOptions{
+		Format:          QRFormatPNG,
+		Size:            256,
+		ErrorCorrection: "M",
+	}
+
+	if req.Size != nil {
+		opts.Size = *req.Size
+	}
+	if req.Format != nil {
+		opts.Format = *req.Format
+	}
+	if req.ErrorCorrection != nil {
+		opts.ErrorCorrection = *req.ErrorCorrection
+	}
+
+	style, err := s.buildStyle(req)
+	if err != nil {
+		return opts, err
+	}
+	opts.Style = style
+
+	// A logo hides part of the symbol, so use the highest level of redundancy
+	if style.Logo != nil {
+		opts.ErrorCorrection = "H"
+	}
+
+	return opts, nil
+}
+
+// encodeQR encodes text as a QR code and renders it with the given options
+func encodeQR(text string, opts qrOptions) ([]byte, error) {
+	q, err := qrcode.New(text, qrRecoveryLevels[opts.ErrorCorrection])
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	imageData, err := renderQRCode(q, opts.Format, opts.Size, opts.Style)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render QR code: %w", err)
+	}
+
+	return imageData, nil
+}
+
+// createDynamicLink creates the editable short URL a dynamic QR code points to
+func (s *QRCodeService) createDynamicLink(ctx context.Context, payloadType, destination string, expireIn *int) (*domain.ShortURL, error) {
+	if s.shortener == nil {
+		return nil, fmt.Errorf("%w: dynamic QR codes are not available", ErrInvalidQRCode)
+	}
+	if payloadType != QRTypeText {
+		return nil, fmt.Errorf("%w: dynamic QR codes require type %s", ErrInvalidQRCode, QRTypeText)
+	}
+
+	if !isHTTPURL(destination) {
+		return nil, fmt.Errorf("%w: dynamic QR codes require an http or https URL as text", ErrInvalidQRCode)
+	}
+
+	editable := true
+	shortURL, err := s.shortener.CreateShortURL(ctx, &domain.CreateShortURLRequest{
+		OriginalURL: destination,
+		ExpireIn:    expireIn,
+		Editable:    &editable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic link: %w", err)
+	}
+
+	return shortURL, nil
+}
+
+// buildStyle resolves the colors, quiet zone and logo requested for a QR code
+func (s *QRCodeService) buildStyle(req *domain.GenerateQRRequest) (qrStyle, error) {
+	style := defaultQRStyle()
+
+	if req.Foreground != nil {
+		fg, err := s.converter.ParseColor(*req.Foreground)
+		if err != nil {
+			return style, fmt.Errorf("invalid foreground color: %w", err)
+		}
+		style.Foreground = fg
+	}
+
+	if req.Background != nil {
+		bg, err := s.converter.ParseColor(*req.Background)
+		if err != nil {
+			return style, fmt.Errorf("invalid background color: %w", err)
+		}
+		style.Background = bg
+	}
+
+	if req.QuietZone != nil {
+		style.QuietZone = *req.QuietZone
+	}
+
+	if len(req.Logo) > 0 {
+		logo, err := decodeImage(req.Logo)
+		if err != nil {
+			return style, fmt.Errorf("invalid logo image: %w", err)
+		}
+		style.Logo = logo
+	}
+
+	return style, nil
+}
+
+// decodeImage decodes a PNG, JPEG or GIF image, rejecting images larger than
+// maxQRImageDimension in either direction
+func decodeImage(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	// Security: Reject oversized images before allocating pixel buffers
+	if cfg.Width > maxQRImageDimension || cfg.Height > maxQRImageDimension {
+		return nil, fmt.Errorf("image exceeds %dx%d pixels", maxQRImageDimension, maxQRImageDimension)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// DecodeQR locates and decodes all QR codes in an image
+func (s *QRCodeService) DecodeQR(req *domain.DecodeQRRequest) (*domain.DecodeQRResponse, error) {
+	if len(req.Image) == 0 {
+		return nil, fmt.Errorf("image is required")
+	}
+
+	img, err := decodeImage(req.Image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	results, err := scanQRCodes(img)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]domain.DecodedQRCode, 0, len(results))
+	for _, result := range results {
+		codes = append(codes, toDecodedQRCode(result))
+	}
+
+	return &domain.DecodeQRResponse{
+		Codes: codes,
+		Count: len(codes),
+	}, nil
+}
+
+var (
+	// ErrInvalidQRCode is returned when a QR code request has an invalid
+	// payload, style or option
+	ErrInvalidQRCode = errors.New("invalid QR code")
+	// ErrQRCodeNotFound is returned when a QR code does not exist or has expired
+	ErrQRCodeNotFound = errors.New("QR code not found")
+	// ErrQRCodeForbidden is returned when deleting a QR code that was not
+	// created by the requesting user
+	ErrQRCodeForbidden = errors.New("QR code can only be deleted by its owner")
+)
+
+// GetQRCode retrieves a QR code or barcode by ID and renders it, at its
+// stored size unless size is set. For barcodes size is the width; the height
+// of two-dimensional barcodes is scaled along with it.
+func (s *QRCodeService) GetQRCode(ctx context.Context, id string, size *int) (*domain.QRCode, error) {
+	qr, err := s.getQRCode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if qr.Symbology != SymbologyQR {
+		if size != nil {
+			if qr.Height != nil && !isLinearSymbology(qr.Symbology) && qr.Size > 0 {
+				height := *qr.Height * *size / qr.Size
+				qr.Height = &height
+			}
+			qr.Size = *size
+		}
+
+		qr.ImageData, err = s.renderStoredBarcode(qr)
+		if err != nil {
+			return nil, err
+		}
+		return qr, nil
+	}
+
+	if size != nil {
+		qr.Size = *size
+	}
+
+	opts, err := s.resolveOptions(&domain.GenerateQRRequest{
+		Size:            &qr.Size,
+		Format:          &qr.Format,
+		ErrorCorrection: &qr.ErrorCorrection,
+		Foreground:      qr.Foreground,
+		Background:      qr.Background,
+		QuietZone:       &qr.QuietZone,
+		Logo:            qr.Logo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	qr.ImageData, err = encodeQR(qr.Text, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return qr, nil
+}
+
+// getQRCode loads a QR code, treating expired codes as missing
+func (s *QRCodeService) getQRCode(ctx context.Context, id string) (*domain.QRCode, error) {
 	qr, err := s.repo.GetQRCodeByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get QR code: %w", err)
 	}
+	if qr == nil {
+		return nil, ErrQRCodeNotFound
+	}
+
+	if qr.ExpiresAt != nil && time.Now().After(*qr.ExpiresAt) {
+		return nil, fmt.Errorf("%w: QR code has expired", ErrQRCodeNotFound)
+	}
 
 	return qr, nil
 }
 
+// ListQRCodes lists the unexpired QR codes owned by a user
+func (s *QRCodeService) ListQRCodes(ctx context.Context, userID int64, limit, offset int32) ([]*domain.QRCode, int64, error) {
+	codes, err := s.repo.ListQRCodesByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list QR codes: %w", err)
+	}
+
+	count, err := s.repo.CountQRCodesByUser(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count QR codes: %w", err)
+	}
+
+	return codes, count, nil
+}
+
+// DeleteQRCode deletes a QR code owned by userID. Anonymous codes have no
+// owner and are only removed once they expire.
+func (s *QRCodeService) DeleteQRCode(ctx context.Context, id string, userID *int64) error {
+	qr, err := s.getQRCode(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if qr.UserID == nil || userID == nil || *userID != *qr.UserID {
+		return ErrQRCodeForbidden
+	}
+
+	if err := s.repo.DeleteQRCode(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete QR code: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredQRCodes deletes all QR codes past their expiry time
+func (s *QRCodeService) DeleteExpiredQRCodes(ctx context.Context) error {
+	if err := s.repo.DeleteExpiredQRCodes(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired QR codes: %w", err)
+	}
+	return nil
+}
+
 // generateID generates a random ID
 func (s *QRCodeService) generateID(length int) string {
 	b := make([]byte, length)
@@ -0,0 +1,515 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+// memoryQRCodeRepository keeps QR codes in memory for tests
+type memoryQRCodeRepository struct {
+	codes map[string]*domain.QRCode
+}
+
+func newMemoryQRCodeRepository() *memoryQRCodeRepository {
+	return &memoryQRCodeRepository{codes: make(map[string]*domain.QRCode)}
+}
+
+func (r *memoryQRCodeRepository) CreateQRCode(_ context.Context, qr *domain.QRCode) error {
+	r.codes[qr.ID] = qr
+	return nil
+}
+
+func (r *memoryQRCodeRepository) GetQRCodeByID(_ context.Context, id string) (*domain.QRCode, error) {
+	qr, ok := r.codes[id]
+	if !ok {
+		return nil, nil
+	}
+	stored := *qr
+	stored.ImageData = nil
+	return &stored, nil
+}
+
+func (r *memoryQRCodeRepository) ListQRCodesByUser(_ context.Context, userID int64, _, _ int32) ([]*domain.QRCode, error) {
+	var codes []*domain.QRCode
+	for _, qr := range r.codes {
+		if qr.UserID != nil && *qr.UserID == userID {
+			codes = append(codes, qr)
+		}
+	}
+	return codes, nil
+}
+
+func (r *memoryQRCodeRepository) CountQRCodesByUser(ctx context.Context, userID int64) (int64, error) {
+	codes, err := r.ListQRCodesByUser(ctx, userID, 0, 0)
+	return int64(len(codes)), err
+}
+
+func (r *memoryQRCodeRepository) DeleteQRCode(_ context.Context, id string) error {
+	delete(r.codes, id)
+	return nil
+}
+
+func (r *memoryQRCodeRepository) DeleteExpiredQRCodes(_ context.Context) error {
+	for id, qr := range r.codes {
+		if qr.ExpiresAt != nil && qr.ExpiresAt.Before(time.Now()) {
+			delete(r.codes, id)
+		}
+	}
+	return nil
+}
+
+func stringPtr(s string) *string { return &s }
+
+func intPtr(i int) *int { return &i }
+
+func testLogo(t *testing.T) []byte {
+	t.Helper()
+	logo := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(logo, logo.Bounds(), &image.Uniform{C: color.RGBA{R: 220, G: 40, B: 40, A: 255}}, image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logo); err != nil {
+		t.Fatalf("Failed to encode logo: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestGenerateQR_DecodesBack self-tests generated PNG output by scanning it
+func TestGenerateQR_DecodesBack(t *testing.T) {
+	tests := []struct {
+		name string
+		req  domain.GenerateQRRequest
+		want string
+	}{
+		{
+			name: "default style",
+			req:  domain.GenerateQRRequest{Text: "https://example.com/hello"},
+			want: "https://example.com/hello",
+		},
+		{
+			name: "colors and small quiet zone",
+			req: domain.GenerateQRRequest{
+				Text:            "colored",
+				Size:            intPtr(320),
+				ErrorCorrection: stringPtr("Q"),
+				Foreground:      stringPtr("#1e3a8a"),
+				Background:      stringPtr("rgb(254, 249, 195)"),
+				QuietZone:       intPtr(1),
+			},
+			want: "colored",
+		},
+		{
+			name: "light on dark",
+			req: domain.GenerateQRRequest{
+				Text:       "inverted",
+				Foreground: stringPtr("#ffffff"),
+				Background: stringPtr("#000000"),
+			},
+			want: "inverted",
+		},
+		{
+			name: "wifi payload",
+			req: domain.GenerateQRRequest{
+				Type: stringPtr(QRTypeWiFi),
+				WiFi: &domain.QRWiFiPayload{SSID: "Office; 2F", Password: "s3cret"},
+			},
+			want: `WIFI:T:WPA;S:Office\; 2F;P:s3cret;;`,
+		},
+	}
+
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := svc.GenerateQR(context.Background(), &tt.req, nil)
+			if err != nil {
+				t.Fatalf("Failed to generate QR code: %v", err)
+			}
+
+			// Stored codes are re-rendered from their text and style
+			stored, err := svc.GetQRCode(context.Background(), qr.ID, nil)
+			if err != nil {
+				t.Fatalf("Failed to get QR code: %v", err)
+			}
+			if !bytes.Equal(stored.ImageData, qr.ImageData) {
+				t.Error("Re-rendered image differs from generated image")
+			}
+
+			result, err := svc.DecodeQR(&domain.DecodeQRRequest{Image: stored.ImageData})
+			if err != nil {
+				t.Fatalf("Failed to decode QR code: %v", err)
+			}
+			if result.Count != 1 {
+				t.Fatalf("Expected 1 code, got %d", result.Count)
+			}
+			if result.Codes[0].Text != tt.want {
+				t.Errorf("Expected text %q, got %q", tt.want, result.Codes[0].Text)
+			}
+		})
+	}
+}
+
+func TestGenerateQR_LogoDecodesBack(t *testing.T) {
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+
+	pngLogo := testLogo(t)
+	img, err := png.Decode(bytes.NewReader(pngLogo))
+	if err != nil {
+		t.Fatalf("Failed to read logo: %v", err)
+	}
+	var jpegLogo bytes.Buffer
+	if err := jpeg.Encode(&jpegLogo, img, nil); err != nil {
+		t.Fatalf("Failed to encode logo: %v", err)
+	}
+
+	for name, logo := range map[string][]byte{"png": pngLogo, "jpeg": jpegLogo.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			qr, err := svc.GenerateQR(context.Background(), &domain.GenerateQRRequest{
+				Text: "https://example.com/with-logo",
+				Size: intPtr(512),
+				Logo: logo,
+			}, nil)
+			if err != nil {
+				t.Fatalf("Failed to generate QR code: %v", err)
+			}
+
+			result, err := svc.DecodeQR(&domain.DecodeQRRequest{Image: qr.ImageData})
+			if err != nil {
+				t.Fatalf("Failed to decode QR code: %v", err)
+			}
+			if result.Count != 1 || result.Codes[0].Text != "https://example.com/with-logo" {
+				t.Errorf("Unexpected decode result: %+v", result)
+			}
+		})
+	}
+}
+
+// TestGenerateQR_Formats checks the content type of each output format and
+// scans the PNG and SVG output
+func TestGenerateQR_Formats(t *testing.T) {
+	const text = "https://example.com/formats"
+
+	tests := []struct {
+		format      string
+		contentType string
+		check       func(t *testing.T, data []byte) []byte // returns a PNG to scan, if any
+	}{
+		{QRFormatPNG, "image/png", func(t *testing.T, data []byte) []byte {
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Failed to read PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != 256 || b.Dy() != 256 {
+				t.Errorf("Expected 256x256 image, got %dx%d", b.Dx(), b.Dy())
+			}
+			return data
+		}},
+		{QRFormatSVG, "image/svg+xml", svgToPNG},
+		{QRFormatEPS, "application/postscript", func(t *testing.T, data []byte) []byte {
+			if !bytes.HasPrefix(data, []byte("%!PS-Adobe-3.0 EPSF-3.0\n")) || !bytes.Contains(data, []byte("%%BoundingBox: 0 0 256 256\n")) {
+				t.Errorf("Unexpected EPS header: %.80q", data)
+			}
+			return nil
+		}},
+		{QRFormatText, "text/plain; charset=utf-8", func(t *testing.T, data []byte) []byte {
+			if !bytes.Contains(data, []byte("##")) {
+				t.Errorf("Expected text modules, got %.80q", data)
+			}
+			return nil
+		}},
+	}
+
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			qr, err := svc.GenerateQR(context.Background(), &domain.GenerateQRRequest{
+				Text:   text,
+				Size:   intPtr(256),
+				Format: stringPtr(tt.format),
+			}, nil)
+			if err != nil {
+				t.Fatalf("Failed to generate QR code: %v", err)
+			}
+
+			if got := QRCodeContentType(qr.Format); got != tt.contentType {
+				t.Errorf("Expected content type %q, got %q", tt.contentType, got)
+			}
+
+			scan := tt.check(t, qr.ImageData)
+			if scan == nil {
+				return
+			}
+			result, err := svc.DecodeQR(&domain.DecodeQRRequest{Image: scan})
+			if err != nil {
+				t.Fatalf("Failed to decode QR code: %v", err)
+			}
+			if result.Count != 1 || result.Codes[0].Text != text {
+				t.Errorf("Unexpected decode result: %+v", result)
+			}
+		})
+	}
+}
+
+// svgToPNG parses an SVG QR code and rasterizes its module runs at 4 pixels
+// per module
+func svgToPNG(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var doc struct {
+		Width   string `xml:"width,attr"`
+		ViewBox string `xml:"viewBox,attr"`
+		Path    struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to parse SVG: %v", err)
+	}
+	if doc.Width != "256" {
+		t.Errorf("Expected width 256, got %q", doc.Width)
+	}
+
+	var n int
+	if _, err := fmt.Sscanf(doc.ViewBox, "0 0 %d %d", &n, &n); err != nil {
+		t.Fatalf("Failed to parse viewBox %q: %v", doc.ViewBox, err)
+	}
+
+	const scale = 4
+	img := image.NewGray(image.Rect(0, 0, n*scale, n*scale))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, run := range strings.Split(strings.TrimSuffix(doc.Path.D, "z"), "z") {
+		var x, y, width int
+		if _, err := fmt.Sscanf(run, "M%d %dh%d", &x, &y, &width); err != nil {
+			t.Fatalf("Failed to parse path segment %q: %v", run, err)
+		}
+		draw.Draw(img, image.Rect(x*scale, y*scale, (x+width)*scale, (y+1)*scale), image.Black, image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerateQR_EPCPayload(t *testing.T) {
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+	payment := func(name, bic, remittance string) *domain.GenerateQRRequest {
+		return &domain.GenerateQRRequest{
+			Type:    stringPtr(QRTypeEPC),
+			Payment: &domain.QRPaymentPayload{Name: name, IBAN: "DE89 3704 0044 0532 0130 00", BIC: bic, Amount: 12.5, Remittance: remittance},
+		}
+	}
+
+	qr, err := svc.GenerateQR(context.Background(), payment("ACME GmbH", "COBADEFFXXX", "Invoice 42"), nil)
+	if err != nil {
+		t.Fatalf("Failed to generate QR code: %v", err)
+	}
+	want := "BCD\n002\n1\nSCT\nCOBADEFFXXX\nACME GmbH\nDE89370400440532013000\nEUR12.50\n\n\nInvoice 42"
+	if qr.Text != want {
+		t.Errorf("Expected payload %q, got %q", want, qr.Text)
+	}
+
+	tests := []struct {
+		name string
+		req  *domain.GenerateQRRequest
+	}{
+		{"newline in name", payment("ACME\nDE02120300000000202051", "", "")},
+		{"carriage return in remittance", payment("ACME", "", "Invoice\r42")},
+		{"name over 70 bytes", payment(strings.Repeat("ü", 36), "", "")},
+		{"malformed BIC", payment("ACME", "COBA\nDEFF", "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.GenerateQR(context.Background(), tt.req, nil); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestGenerateQR_InvalidRequest(t *testing.T) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		req  domain.GenerateQRRequest
+	}{
+		{"invalid IBAN", domain.GenerateQRRequest{Type: stringPtr(QRTypeEPC), Payment: &domain.QRPaymentPayload{Name: "ACME", IBAN: "DE00 0000"}}},
+		{"invalid phone", domain.GenerateQRRequest{Type: stringPtr(QRTypeSMS), SMS: &domain.QRSMSPayload{Phone: "call me"}}},
+		{"event ends before start", domain.GenerateQRRequest{Type: stringPtr(QRTypeVEvent), Event: &domain.QREventPayload{Summary: "Demo", Start: start, End: &end}}},
+		{"invalid color", domain.GenerateQRRequest{Text: "x", Foreground: stringPtr("not-a-color")}},
+		{"invalid logo", domain.GenerateQRRequest{Text: "x", Logo: []byte("not an image")}},
+		{"logo in SVG", domain.GenerateQRRequest{Text: "x", Format: stringPtr(QRFormatSVG), Logo: testLogo(t)}},
+		{"dynamic without URL", domain.GenerateQRRequest{Text: "x", Dynamic: true}},
+	}
+
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.GenerateQR(context.Background(), &tt.req, nil); !errors.Is(err, ErrInvalidQRCode) {
+				t.Errorf("Expected ErrInvalidQRCode, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeQR_NoCode(t *testing.T) {
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+
+	blank := image.NewGray(image.Rect(0, 0, 100, 100))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, blank); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+
+	result, err := svc.DecodeQR(&domain.DecodeQRRequest{Image: buf.Bytes()})
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+	if result.Count != 0 {
+		t.Errorf("Expected no codes, got %d", result.Count)
+	}
+}
+
+func TestDecodeQR_InvalidImage(t *testing.T) {
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+
+	if _, err := svc.DecodeQR(&domain.DecodeQRRequest{Image: []byte("not an image")}); err == nil {
+		t.Error("Expected error for invalid image")
+	}
+}
+
+func TestDecodeQR_MultipleCodes(t *testing.T) {
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+
+	canvas := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	for i, text := range []string{"left", "right"} {
+		qr, err := svc.GenerateQR(context.Background(), &domain.GenerateQRRequest{Text: text, Size: intPtr(256)}, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate QR code: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(qr.ImageData))
+		if err != nil {
+			t.Fatalf("Failed to read QR code: %v", err)
+		}
+		offset := image.Pt(20+i*300, 20)
+		draw.Draw(canvas, img.Bounds().Add(offset), img, image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+
+	result, err := svc.DecodeQR(&domain.DecodeQRRequest{Image: buf.Bytes()})
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+	if result.Count != 2 {
+		t.Fatalf("Expected 2 codes, got %d", result.Count)
+	}
+	for _, code := range result.Codes {
+		wantX := 20
+		if code.Text == "right" {
+			wantX = 320
+		}
+		// The symbol starts after the 4-module quiet zone
+		if code.Bounds.X < wantX || code.Bounds.X > wantX+60 || code.Bounds.Width < 150 {
+			t.Errorf("Unexpected bounds for %q: %+v", code.Text, code.Bounds)
+		}
+	}
+}
+
+func TestGetQRCode_Size(t *testing.T) {
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+
+	qr, err := svc.GenerateQR(context.Background(), &domain.GenerateQRRequest{Text: "resize me"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate QR code: %v", err)
+	}
+
+	stored, err := svc.GetQRCode(context.Background(), qr.ID, intPtr(640))
+	if err != nil {
+		t.Fatalf("Failed to get QR code: %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(stored.ImageData))
+	if err != nil {
+		t.Fatalf("Failed to read QR code: %v", err)
+	}
+	if img.Bounds().Dx() != 640 {
+		t.Errorf("Expected width 640, got %d", img.Bounds().Dx())
+	}
+}
+
+func TestGetQRCode_Expired(t *testing.T) {
+	repo := newMemoryQRCodeRepository()
+	svc := NewQRCodeService(repo, nil, "")
+
+	qr, err := svc.GenerateQR(context.Background(), &domain.GenerateQRRequest{Text: "short lived", ExpireIn: intPtr(1)}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate QR code: %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	repo.codes[qr.ID].ExpiresAt = &past
+
+	if _, err := svc.GetQRCode(context.Background(), qr.ID, nil); err == nil {
+		t.Error("Expected error for expired QR code")
+	}
+
+	if err := svc.DeleteExpiredQRCodes(context.Background()); err != nil {
+		t.Fatalf("Failed to delete expired QR codes: %v", err)
+	}
+	if _, ok := repo.codes[qr.ID]; ok {
+		t.Error("Expected expired QR code to be deleted")
+	}
+}
+
+func TestDeleteQRCode_Owner(t *testing.T) {
+	svc := NewQRCodeService(newMemoryQRCodeRepository(), nil, "")
+	owner, other := int64(1), int64(2)
+
+	qr, err := svc.GenerateQR(context.Background(), &domain.GenerateQRRequest{Text: "owned"}, &owner)
+	if err != nil {
+		t.Fatalf("Failed to generate QR code: %v", err)
+	}
+
+	if err := svc.DeleteQRCode(context.Background(), qr.ID, nil); !errors.Is(err, ErrQRCodeForbidden) {
+		t.Errorf("Expected ErrQRCodeForbidden for anonymous delete, got %v", err)
+	}
+	if err := svc.DeleteQRCode(context.Background(), qr.ID, &other); !errors.Is(err, ErrQRCodeForbidden) {
+		t.Errorf("Expected ErrQRCodeForbidden for other user, got %v", err)
+	}
+	if err := svc.DeleteQRCode(context.Background(), qr.ID, &owner); err != nil {
+		t.Errorf("Failed to delete QR code as owner: %v", err)
+	}
+	if err := svc.DeleteQRCode(context.Background(), qr.ID, &owner); !errors.Is(err, ErrQRCodeNotFound) {
+		t.Errorf("Expected ErrQRCodeNotFound after delete, got %v", err)
+	}
+
+	anonymous, err := svc.GenerateQR(context.Background(), &domain.GenerateQRRequest{Text: "anonymous"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate QR code: %v", err)
+	}
+	for _, userID := range []*int64{nil, &owner} {
+		if err := svc.DeleteQRCode(context.Background(), anonymous.ID, userID); !errors.Is(err, ErrQRCodeForbidden) {
+			t.Errorf("Expected ErrQRCodeForbidden for anonymous QR code, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+// Time calculation operations
+const (
+	TimeCalcAdd      = "add"
+	TimeCalcSubtract = "subtract"
+	TimeCalcDiff     = "diff"
+	TimeCalcConvert  = "convert"
+)
+
+// isoDurationPattern matches ISO 8601 durations such as P1Y2M, P2W and
+// -PT1.5H. Only the time components may have fractions.
+var isoDurationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+(?:[.,]\d+)?)H)?(?:(\d+(?:[.,]\d+)?)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+
+// dayDuration is the length of a day in exact durations
+const dayDuration = 24 * time.Hour
+
+// calendarDuration is a duration with calendar parts, which vary in length,
+// and an exact clock part
+type calendarDuration struct {
+	years, months, days int
+	clock               time.Duration
+}
+
+// isCalendar reports whether the duration has years or months, which have no fixed length
+func (d calendarDuration) isCalendar() bool {
+	return d.years != 0 || d.months != 0
+}
+
+// exact returns the length of a duration without years or months, counting days as 24 hours
+func (d calendarDuration) exact() (time.Duration, error) {
+	if d.isCalendar() {
+		return 0, fmt.Errorf("years and months have no fixed length, use add or subtract instead")
+	}
+	if d.days > int(math.MaxInt64/int64(dayDuration)) || d.days < int(math.MinInt64/int64(dayDuration)) {
+		return 0, fmt.Errorf("duration is too large")
+	}
+	days := time.Duration(d.days) * dayDuration
+	if (d.clock > 0 && days > math.MaxInt64-d.clock) || (d.clock < 0 && days < math.MinInt64-d.clock) {
+		return 0, fmt.Errorf("duration is too large")
+	}
+	return days + d.clock, nil
+}
+
+// addTo adds the duration to t, sign times. Years and months keep the day of
+// the month, clamped to the end of shorter months, and days keep the wall
+// clock time across daylight saving time changes.
+func (d calendarDuration) addTo(t time.Time, sign int) time.Time {
+	if d.isCalendar() {
+		year, month, dayOfMonth := t.Date()
+		target := time.Date(year+sign*d.years, month+time.Month(sign*d.months), 1, 0, 0, 0, 0, time.UTC)
+		if last := daysIn(target.Year(), target.Month()); dayOfMonth > last {
+			dayOfMonth = last
+		}
+		hour, minute, second := t.Clock()
+		t = time.Date(target.Year(), target.Month(), dayOfMonth, hour, minute, second, t.Nanosecond(), t.Location())
+	}
+	return t.AddDate(0, 0, sign*d.days).Add(time.Duration(sign) * d.clock)
+}
+
+// daysIn returns the number of days in a month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// parseDuration parses a Go duration such as 1h30m or an ISO 8601 duration
+// such as P1DT2H
+func parseDuration(value string) (calendarDuration, error) {
+	value = strings.TrimSpace(value)
+	upper := strings.ToUpper(value)
+	if !strings.HasPrefix(strings.TrimLeft(upper, "+-"), "P") {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return calendarDuration{}, fmt.Errorf("invalid duration: %s", value)
+		}
+		return calendarDuration{clock: d}, nil
+	}
+
+	m := isoDurationPattern.FindStringSubmatch(upper)
+	if m == nil || strings.HasSuffix(upper, "P") || strings.HasSuffix(upper, "T") {
+		return calendarDuration{}, fmt.Errorf("invalid ISO 8601 duration: %s", value)
+	}
+
+	sign := 1
+	if m[1] == "-" {
+		sign = -1
+	}
+	var d calendarDuration
+	var parts [4]int
+	for i, text := range m[2:6] {
+		if text == "" {
+			continue
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil || n > math.MaxInt32 {
+			return calendarDuration{}, fmt.Errorf("duration is too large")
+		}
+		parts[i] = n
+	}
+	d.years, d.months, d.days = sign*parts[0], sign*parts[1], sign*(parts[2]*7+parts[3])
+
+	nanos := new(big.Rat)
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		text := m[6+i]
+		if text == "" {
+			continue
+		}
+		x, ok := new(big.Rat).SetString(strings.Replace(text, ",", ".", 1))
+		if !ok {
+			return calendarDuration{}, fmt.Errorf("invalid ISO 8601 duration: %s", value)
+		}
+		nanos.Add(nanos, x.Mul(x, new(big.Rat).SetInt64(int64(unit))))
+	}
+	clock := new(big.Int).Quo(nanos.Num(), nanos.Denom())
+	if !clock.IsInt64() {
+		return calendarDuration{}, fmt.Errorf("duration is too large")
+	}
+	d.clock = time.Duration(sign) * time.Duration(clock.Int64())
+
+	return d, nil
+}
+
+// formatISODuration writes an exact duration in ISO 8601, counting days as 24 hours
+func formatISODuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var sb strings.Builder
+	// Work in unsigned nanoseconds so the minimum duration can be negated
+	nanos := uint64(d) // #nosec G115 - negated below for negative durations
+	if d < 0 {
+		sb.WriteByte('-')
+		nanos = -nanos
+	}
+	sb.WriteByte('P')
+
+	const nanosPerDay, nanosPerHour, nanosPerMinute, nanosPerSecond = uint64(dayDuration), uint64(time.Hour), uint64(time.Minute), uint64(time.Second)
+	days := nanos / nanosPerDay
+	hours := nanos % nanosPerDay / nanosPerHour
+	minutes := nanos % nanosPerHour / nanosPerMinute
+	seconds := nanos % nanosPerMinute / nanosPerSecond
+	fraction := nanos % nanosPerSecond
+
+	if days > 0 {
+		fmt.Fprintf(&sb, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || fraction > 0 {
+		sb.WriteByte('T')
+	}
+	if hours > 0 {
+		fmt.Fprintf(&sb, "%dH", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(&sb, "%dM", minutes)
+	}
+	if seconds > 0 || fraction > 0 {
+		fmt.Fprintf(&sb, "%d", seconds)
+		if fraction > 0 {
+			sb.WriteString(strings.TrimRight(fmt.Sprintf(".%09d", fraction), "0"))
+		}
+		sb.WriteByte('S')
+	}
+	return sb.String()
+}
+
+// durationUnits expresses an exact duration in every unit
+func durationUnits(d time.Duration) *domain.DurationUnits {
+	return &domain.DurationUnits{
+		Go:           d.String(),
+		ISO8601:      formatISODuration(d),
+		Nanoseconds:  d.Nanoseconds(),
+		Milliseconds: float64(d) / float64(time.Millisecond),
+		Seconds:      d.Seconds(),
+		Minutes:      d.Minutes(),
+		Hours:        d.Hours(),
+		Days:         float64(d) / float64(dayDuration),
+		Weeks:        float64(d) / float64(7*dayDuration),
+	}
+}
+
+// CalculateTime adds or subtracts durations, measures the time between two
+// dates in calendar and business days, and converts durations between units
+func (s *ConverterService) CalculateTime(req *domain.TimeCalcRequest) (*domain.TimeCalcResponse, error) {
+	loc, err := loadTimeZone(req.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	// Times are read like convert/time reads them, defaulting to now
+	parseTime := func(value *string, name string) (time.Time, error) {
+		if value == nil {
+			return time.Now().In(loc), nil
+		}
+		t, _, err := parseTimeValue(*value, TimeFormatAuto, "", loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if req.Timezone != nil {
+			t = t.In(loc)
+		}
+		return t, nil
+	}
+
+	response := &domain.TimeCalcResponse{Operation: req.Operation}
+
+	switch req.Operation {
+	case TimeCalcAdd, TimeCalcSubtract:
+		if req.Duration == nil {
+			return nil, fmt.Errorf("duration is required for %s", req.Operation)
+		}
+		d, err := parseDuration(*req.Duration)
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseTime(req.Time, "time")
+		if err != nil {
+			return nil, err
+		}
+
+		sign := 1
+		if req.Operation == TimeCalcSubtract {
+			sign = -1
+		}
+		t = d.addTo(t, sign)
+		if t.Before(minSupportedTime) || t.After(maxSupportedTime) {
+			return nil, fmt.Errorf("result is outside years 0000 to 9999")
+		}
+
+		format, layout := TimeFormatISO8601, ""
+		if req.Format != nil {
+			format = *req.Format
+		}
+		if req.Layout != nil {
+			layout = *req.Layout
+		}
+		if response.Result, err = formatTimeValue(t, format, layout, time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to format result: %w", err)
+		}
+		response.Timezone = timeZoneName(t)
+
+	case TimeCalcDiff:
+		start, err := parseTime(req.Time, "time")
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTime(req.End, "end")
+		if err != nil {
+			return nil, err
+		}
+
+		d := end.Sub(start)
+		if !start.Add(d).Equal(end) {
+			return nil, fmt.Errorf("difference is too large for a duration")
+		}
+		response.Duration = durationUnits(d)
+
+		// Days are counted on the calendar of the start time
+		end = end.In(start.Location())
+		calendarDays := civilDay(end) - civilDay(start)
+		businessDays, err := countBusinessDays(start, end, req.Holidays)
+		if err != nil {
+			return nil, err
+		}
+		response.CalendarDays = &calendarDays
+		response.BusinessDays = &businessDays
+		response.Timezone = timeZoneName(start)
+
+	case TimeCalcConvert:
+		if req.Duration == nil {
+			return nil, fmt.Errorf("duration is required for convert")
+		}
+		d, err := parseDuration(*req.Duration)
+		if err != nil {
+			return nil, err
+		}
+		exact, err := d.exact()
+		if err != nil {
+			return nil, err
+		}
+		response.Duration = durationUnits(exact)
+
+	default:
+		return nil, fmt.Errorf("unsupported operation: %s", req.Operation)
+	}
+
+	return response, nil
+}
+
+// civilDay numbers the calendar date of t, in its own zone, in days since the Unix epoch
+func civilDay(t time.Time) int {
+	year, month, dayOfMonth := t.Date()
+	return int(time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC).Unix() / int64(dayDuration/time.Second))
+}
+
+// countBusinessDays counts the weekdays from the date of start up to but
+// not including the date of end, excluding holidays. It is negative when
+// end is before start.
+func countBusinessDays(start, end time.Time, holidays []string) (int, error) {
+	from, to := civilDay(start), civilDay(end)
+	sign := 1
+	if to < from {
+		from, to = to, from
+		sign = -1
+	}
+
+	// Whole weeks have 5 weekdays, then count the remaining days one by one
+	weeks := (to - from) / 7
+	count := weeks * 5
+	for d := from + weeks*7; d < to; d++ {
+		if isWeekday(d) {
+			count++
+		}
+	}
+
+	seen := make(map[int]bool, len(holidays))
+	for _, holiday := range holidays {
+		date, err := time.Parse(time.DateOnly, holiday)
+		if err != nil {
+			return 0, fmt.Errorf("invalid holiday: %s", holiday)
+		}
+		d := civilDay(date)
+		if !seen[d] && d >= from && d < to && isWeekday(d) {
+			count--
+		}
+		seen[d] = true
+	}
+
+	return sign * count, nil
+}
+
+// isWeekday reports whether a day since the Unix epoch is Monday to Friday
+func isWeekday(d int) bool {
+	// January 1, 1970 was a Thursday
+	weekday := time.Weekday(((d % 7) + 7 + int(time.Thursday)) % 7)
+	return weekday != time.Saturday && weekday != time.Sunday
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed the IANA time zone database for images without tzdata
+	_ "time/tzdata"
+)
+
+// Time formats
+const (
+	TimeFormatAuto     = "auto"
+	TimeFormatUnix     = "unix"
+	TimeFormatUnixMs   = "unix_ms"
+	TimeFormatUnixUs   = "unix_us"
+	TimeFormatUnixNs   = "unix_ns"
+	TimeFormatISO8601  = "iso8601"
+	TimeFormatRFC1123  = "rfc1123"
+	TimeFormatRFC822   = "rfc822"
+	TimeFormatISOWeek  = "iso_week"
+	TimeFormatHuman    = "human"
+	TimeFormatRelative = "relative"
+	TimeFormatLayout   = "layout"
+	TimeFormatStrftime = "strftime"
+)
+
+// humanTimeLayout is the layout of human readable output
+const humanTimeLayout = "Mon, 02 Jan 2006 15:04:05 MST"
+
+// epochUnitsPerSecond is the resolution of each epoch format
+var epochUnitsPerSecond = map[string]int64{
+	TimeFormatUnix:   1,
+	TimeFormatUnixMs: 1e3,
+	TimeFormatUnixUs: 1e6,
+	TimeFormatUnixNs: 1e9,
+}
+
+// timeLayouts are the layouts tried for each textual format, most specific first
+var timeLayouts = map[string][]string{
+	TimeFormatISO8601: {
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04",
+		"2006-01-02",
+	},
+	TimeFormatRFC1123: {time.RFC1123Z, time.RFC1123},
+	TimeFormatRFC822:  {time.RFC822Z, time.RFC822},
+}
+
+// autoTimeFormats is the order textual formats are tried in when detecting
+var autoTimeFormats = []string{TimeFormatISO8601, TimeFormatRFC1123, TimeFormatRFC822}
+
+var (
+	epochPattern   = regexp.MustCompile(`^[+-]?(\d+)(\.\d+)?$`)
+	isoWeekPattern = regexp.MustCompile(`^(\d{4})-?W(\d{2})(?:-?([1-7]))?$`)
+)
+
+// Supported time range, the years RFC 3339 can represent
+var (
+	minSupportedTime = time.Date(0, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxSupportedTime = time.Date(9999, time.December, 31, 23, 59, 59, 999999999, time.UTC)
+)
+
+// loadTimeZone loads an IANA time zone, UTC when name is empty. The server's
+// local zone is not exposed.
+func loadTimeZone(name *string) (*time.Location, error) {
+	if name == nil || *name == "" {
+		return time.UTC, nil
+	}
+	if *name == "Local" {
+		return nil, fmt.Errorf("unknown time zone: %s", *name)
+	}
+	loc, err := time.LoadLocation(*name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone: %s", *name)
+	}
+	return loc, nil
+}
+
+// timeZoneName names the zone of t, falling back to its UTC offset for zones
+// read from the input
+func timeZoneName(t time.Time) string {
+	if name := t.Location().String(); name != "" {
+		return name
+	}
+	return "UTC" + t.Format("-07:00")
+}
+
+// parseTimeValue parses value in the given format, detecting it when format
+// is empty or auto. Epochs and values without a zone offset are read in loc.
+// It returns the time and the format it was read as.
+func parseTimeValue(value, format, layout string, loc *time.Location) (time.Time, string, error) {
+	value = strings.TrimSpace(value)
+
+	if format == "" || format == TimeFormatAuto {
+		if layout != "" {
+			format = TimeFormatLayout
+			if strings.Contains(layout, "%") {
+				format = TimeFormatStrftime
+			}
+		} else {
+			format = detectTimeFormat(value)
+		}
+		if format == "" {
+			return time.Time{}, "", fmt.Errorf("unrecognized time format")
+		}
+	}
+
+	var t time.Time
+	var err error
+	switch format {
+	case TimeFormatUnix, TimeFormatUnixMs, TimeFormatUnixUs, TimeFormatUnixNs:
+		t, err = parseEpoch(value, epochUnitsPerSecond[format])
+		t = t.In(loc)
+	case TimeFormatISO8601, TimeFormatRFC1123, TimeFormatRFC822:
+		t, err = parseTimeLayouts(value, timeLayouts[format], loc)
+		if err != nil {
+			err = fmt.Errorf("invalid %s time", format)
+		}
+	case TimeFormatISOWeek:
+		t, err = parseISOWeek(value, loc)
+	case TimeFormatLayout, TimeFormatStrftime:
+		if layout == "" {
+			return time.Time{}, "", fmt.Errorf("from_layout is required for %s input", format)
+		}
+		if format == TimeFormatStrftime {
+			if layout, err = strftimeToLayout(layout); err != nil {
+				return time.Time{}, "", err
+			}
+		}
+		t, err = time.ParseInLocation(layout, value, loc)
+	default:
+		return time.Time{}, "", fmt.Errorf("unsupported from format: %s", format)
+	}
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	if t.Before(minSupportedTime) || t.After(maxSupportedTime) {
+		return time.Time{}, "", fmt.Errorf("time is outside years 0000 to 9999")
+	}
+	return t, format, nil
+}
+
+// detectTimeFormat guesses the format of value. Epochs are told apart by
+// their number of integer digits: up to 11 are seconds, which covers the
+// years up to 5138, and each further 3 digits a finer unit.
+func detectTimeFormat(value string) string {
+	if m := epochPattern.FindStringSubmatch(value); m != nil {
+		switch digits := len(m[1]); {
+		case digits <= 11:
+			return TimeFormatUnix
+		case digits <= 14:
+			return TimeFormatUnixMs
+		case digits <= 17:
+			return TimeFormatUnixUs
+		default:
+			return TimeFormatUnixNs
+		}
+	}
+	if isoWeekPattern.MatchString(value) {
+		return TimeFormatISOWeek
+	}
+	for _, format := range autoTimeFormats {
+		if _, err := parseTimeLayouts(value, timeLayouts[format], time.UTC); err == nil {
+			return format
+		}
+	}
+	return ""
+}
+
+// parseTimeLayouts parses value with the first matching layout
+func parseTimeLayouts(value string, layouts []string, loc *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// parseEpoch parses a possibly fractional number of units since the Unix
+// epoch. Digits finer than a nanosecond are truncated.
+func parseEpoch(value string, unitsPerSecond int64) (time.Time, error) {
+	if !epochPattern.MatchString(value) {
+		return time.Time{}, fmt.Errorf("invalid epoch timestamp: %s", value)
+	}
+	x, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid epoch timestamp: %s", value)
+	}
+
+	nanos := new(big.Int).Mul(x.Num(), big.NewInt(1e9/unitsPerSecond))
+	nanos.Quo(nanos, x.Denom())
+
+	sec, nsec := new(big.Int).DivMod(nanos, big.NewInt(1e9), new(big.Int))
+	if !sec.IsInt64() {
+		return time.Time{}, fmt.Errorf("epoch timestamp out of range")
+	}
+	return time.Unix(sec.Int64(), nsec.Int64()).UTC(), nil
+}
+
+// formatEpoch writes t as units since the Unix epoch. Seconds keep any
+// fractional part; finer units are rounded down.
+func formatEpoch(t time.Time, unitsPerSecond int64) (string, error) {
+	nanos := new(big.Int).Mul(big.NewInt(t.Unix()), big.NewInt(1e9))
+	nanos.Add(nanos, big.NewInt(int64(t.Nanosecond())))
+
+	if unitsPerSecond == 1 && t.Nanosecond() != 0 {
+		sec := new(big.Rat).SetFrac(nanos, big.NewInt(1e9))
+		return strings.TrimRight(sec.FloatString(9), "0"), nil
+	}
+
+	units := new(big.Int).Div(nanos, big.NewInt(1e9/unitsPerSecond))
+	if !units.IsInt64() {
+		return "", fmt.Errorf("time does not fit in a 64-bit epoch of this unit")
+	}
+	return units.String(), nil
+}
+
+// parseISOWeek parses an ISO 8601 week date such as 2024-W05-3, defaulting
+// to Monday when the weekday is omitted
+func parseISOWeek(value string, loc *time.Location) (time.Time, error) {
+	m := isoWeekPattern.FindStringSubmatch(value)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid ISO week date: %s", value)
+	}
+	year, _ := strconv.Atoi(m[1])
+	week, _ := strconv.Atoi(m[2])
+	weekday := 1
+	if m[3] != "" {
+		weekday, _ = strconv.Atoi(m[3])
+	}
+
+	// December 28 is always in the last week of its year
+	if _, weeks := time.Date(year, time.December, 28, 0, 0, 0, 0, loc).ISOWeek(); week < 1 || week > weeks {
+		return time.Time{}, fmt.Errorf("week %d is out of range for %d", week, year)
+	}
+
+	// January 4 is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, (week-1)*7+weekday-1), nil
+}
+
+// formatISOWeek writes t as an ISO 8601 week date
+func formatISOWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return fmt.Sprintf("%04d-W%02d-%d", year, week, weekday)
+}
+
+// strftimeLayouts maps strftime directives to Go layout elements
+var strftimeLayouts = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'p': "PM",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'Z': "MST",
+	'z': "-0700",
+	'j': "002",
+	'f': "000000",
+	'L': "000",
+	'T': "15:04:05",
+	'D': "01/02/06",
+	'F': "2006-01-02",
+	'R': "15:04",
+}
+
+// layoutElements are literal texts Go would read as layout elements
+var layoutElements = []string{"Jan", "Mon", "MST", "PM", "pm", "Z07", "__2", "_2"}
+
+// strftimeToLayout converts a strftime format to a Go layout. Go layouts
+// cannot escape text, so literal digits and text that spells a layout
+// element are rejected.
+func strftimeToLayout(format string) (string, error) {
+	var sb strings.Builder
+	literalStart := 0
+
+	checkLiteral := func(literal string) error {
+		if strings.ContainsAny(literal, "0123456789") {
+			return fmt.Errorf("strftime literal %q contains digits", literal)
+		}
+		for _, element := range layoutElements {
+			if strings.Contains(literal, element) {
+				return fmt.Errorf("strftime literal %q contains layout element %q", literal, element)
+			}
+		}
+		return nil
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		if err := checkLiteral(format[literalStart:i]); err != nil {
+			return "", err
+		}
+		sb.WriteString(format[literalStart:i])
+		if i+1 == len(format) {
+			return "", fmt.Errorf("strftime format ends with %%")
+		}
+
+		i++
+		switch directive := format[i]; directive {
+		case '%':
+			sb.WriteByte('%')
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'f', 'L':
+			// Go only reads fractional seconds after a period or comma
+			if s := sb.String(); s == "" || (s[len(s)-1] != '.' && s[len(s)-1] != ',') {
+				return "", fmt.Errorf("strftime %%%c must follow a period or comma", directive)
+			}
+			sb.WriteString(strftimeLayouts[directive])
+		default:
+			element, ok := strftimeLayouts[directive]
+			if !ok {
+				return "", fmt.Errorf("unsupported strftime directive: %%%c", directive)
+			}
+			sb.WriteString(element)
+		}
+		literalStart = i + 1
+	}
+
+	if err := checkLiteral(format[literalStart:]); err != nil {
+		return "", err
+	}
+	sb.WriteString(format[literalStart:])
+	return sb.String(), nil
+}
+
+// formatTimeValue writes t in the given format. Relative output is measured from now.
+func formatTimeValue(t time.Time, format, layout string, now time.Time) (string, error) {
+	switch format {
+	case TimeFormatUnix, TimeFormatUnixMs, TimeFormatUnixUs, TimeFormatUnixNs:
+		return formatEpoch(t, epochUnitsPerSecond[format])
+	case TimeFormatISO8601:
+		return t.Format(time.RFC3339Nano), nil
+	case TimeFormatRFC1123:
+		return t.Format(time.RFC1123Z), nil
+	case TimeFormatRFC822:
+		return t.Format(time.RFC822Z), nil
+	case TimeFormatISOWeek:
+		return formatISOWeek(t), nil
+	case TimeFormatHuman:
+		return t.Format(humanTimeLayout), nil
+	case TimeFormatRelative:
+		return formatRelativeTime(t, now), nil
+	case TimeFormatLayout, TimeFormatStrftime:
+		if layout == "" {
+			return "", fmt.Errorf("to_layout is required for %s output", format)
+		}
+		if format == TimeFormatStrftime {
+			var err error
+			if layout, err = strftimeToLayout(layout); err != nil {
+				return "", err
+			}
+		}
+		return t.Format(layout), nil
+	default:
+		return "", fmt.Errorf("unsupported to format: %s", format)
+	}
+}
+
+// relativeTimeUnits are the units of relative output in seconds, largest first
+var relativeTimeUnits = []struct {
+	name    string
+	seconds int64
+}{
+	{"year", 365 * 24 * 60 * 60},
+	{"month", 30 * 24 * 60 * 60},
+	{"week", 7 * 24 * 60 * 60},
+	{"day", 24 * 60 * 60},
+	{"hour", 60 * 60},
+	{"minute", 60},
+	{"second", 1},
+}
+
+// formatRelativeTime describes t relative to now in its largest whole unit,
+// such as "3 hours ago" or "in 2 days"
+func formatRelativeTime(t, now time.Time) string {
+	// Whole seconds, as time.Duration only spans about 292 years
+	diff := t.Truncate(time.Second).Unix() - now.Truncate(time.Second).Unix()
+	future := diff > 0
+	if !future {
+		diff = -diff
+	}
+
+	for _, unit := range relativeTimeUnits {
+		n := diff / unit.seconds
+		if n < 1 {
+			continue
+		}
+		amount := fmt.Sprintf("%d %s", n, unit.name)
+		if n > 1 {
+			amount += "s"
+		}
+		if future {
+			return "in " + amount
+		}
+		return amount + " ago"
+	}
+	return "just now"
+}
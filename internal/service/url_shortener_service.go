@@ -3,8 +3,13 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/url"
 	"time"
 
 	"github.com/codewithwan/gopilot/internal/domain"
@@ -17,6 +22,8 @@ type URLShortenerRepository interface {
 	CreateShortURL(ctx context.Context, shortURL *domain.ShortURL) error
 	GetShortURLByCode(ctx context.Context, code string) (*domain.ShortURL, error)
 	IncrementClicks(ctx context.Context, id int64) error
+	IncrementBotClicks(ctx context.Context, id int64) error
+	UpdateDestination(ctx context.Context, id int64, originalURL string) (*domain.ShortURL, error)
 	LogClick(ctx context.Context, click *domain.URLClickLog) error
 	DeleteExpiredURLs(ctx context.Context) error
 }
@@ -62,6 +69,18 @@ func (s *URLShortenerService) CreateShortURL(ctx context.Context, req *domain.Cr
 		UpdatedAt:   time.Now(),
 	}
 
+	// Security: Only a hash of the edit token is stored; the token itself is returned once
+	if req.Editable != nil && *req.Editable {
+		token, err := generateEditToken()
+		if err != nil {
+			return nil, err
+		}
+		tokenHash := hashEditToken(token)
+		shortURL.Editable = true
+		shortURL.EditToken = token
+		shortURL.EditTokenHash = &tokenHash
+	}
+
 	if err := s.repo.CreateShortURL(ctx, shortURL); err != nil {
 		return nil, fmt.Errorf("failed to create short URL: %w", err)
 	}
@@ -69,6 +88,60 @@ func (s *URLShortenerService) CreateShortURL(ctx context.Context, req *domain.Cr
 	return shortURL, nil
 }
 
+var (
+	ErrShortURLNotEditable = errors.New("short URL is not editable")
+	ErrInvalidEditToken    = errors.New("invalid edit token")
+	ErrInvalidDestination  = errors.New("destination must be an http or https URL")
+)
+
+// isHTTPURL reports whether destination is an absolute http or https URL
+func isHTTPURL(destination string) bool {
+	u, err := url.ParseRequestURI(destination)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// UpdateShortURL changes the destination of an editable short URL
+func (s *URLShortenerService) UpdateShortURL(ctx context.Context, code string, req *domain.UpdateShortURLRequest) (*domain.ShortURL, error) {
+	if !isHTTPURL(req.OriginalURL) {
+		return nil, ErrInvalidDestination
+	}
+
+	shortURL, err := s.GetShortURL(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if shortURL.EditTokenHash == nil {
+		return nil, ErrShortURLNotEditable
+	}
+	// Security: Constant time comparison to avoid leaking the hash through timing
+	if subtle.ConstantTimeCompare([]byte(hashEditToken(req.EditToken)), []byte(*shortURL.EditTokenHash)) != 1 {
+		return nil, ErrInvalidEditToken
+	}
+
+	updated, err := s.repo.UpdateDestination(ctx, shortURL.ID, req.OriginalURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update short URL: %w", err)
+	}
+
+	return updated, nil
+}
+
+// generateEditToken generates a random 32 character edit token
+func generateEditToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate edit token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashEditToken returns the hex SHA-256 digest under which an edit token is stored
+func hashEditToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // GetShortURL retrieves a short URL by code
 func (s *URLShortenerService) GetShortURL(ctx context.Context, code string) (*domain.ShortURL, error) {
 	shortURL, err := s.repo.GetShortURLByCode(ctx, code)
@@ -84,14 +157,25 @@ func (s *URLShortenerService) GetShortURL(ctx context.Context, code string) (*do
 	return shortURL, nil
 }
 
-// RecordClick records a click on a short URL
-func (s *URLShortenerService) RecordClick(ctx context.Context, shortURL *domain.ShortURL, referrer, userAgent, ipAddress string) error {
-	if err := s.repo.IncrementClicks(ctx, shortURL.ID); err != nil {
-		return fmt.Errorf("failed to increment clicks: %w", err)
+// RecordClick records a click on a short URL. Clicks from known bots, link
+// unfurlers and browser prefetches (purpose is the Purpose / Sec-Purpose
+// header) are counted separately as bot clicks.
+func (s *URLShortenerService) RecordClick(ctx context.Context, shortURL *domain.ShortURL, referrer, userAgent, ipAddress, purpose string) error {
+	isBot := isBotClick(userAgent, purpose)
+
+	if isBot {
+		if err := s.repo.IncrementBotClicks(ctx, shortURL.ID); err != nil {
+			return fmt.Errorf("failed to increment bot clicks: %w", err)
+		}
+	} else {
+		if err := s.repo.IncrementClicks(ctx, shortURL.ID); err != nil {
+			return fmt.Errorf("failed to increment clicks: %w", err)
+		}
 	}
 
 	click := &domain.URLClickLog{
 		ShortURLID: shortURL.ID,
+		IsBot:      isBot,
 		ClickedAt:  time.Now(),
 	}
 
@@ -112,6 +196,14 @@ func (s *URLShortenerService) RecordClick(ctx context.Context, shortURL *domain.
 	return nil
 }
 
+// DeleteExpiredURLs deletes all short URLs past their expiry time
+func (s *URLShortenerService) DeleteExpiredURLs(ctx context.Context) error {
+	if err := s.repo.DeleteExpiredURLs(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired short URLs: %w", err)
+	}
+	return nil
+}
+
 // generateBase62Code generates a random base62 code
 func (s *URLShortenerService) generateBase62Code(length int) string {
 	result := make([]byte, length)
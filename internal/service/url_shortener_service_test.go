@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codewithwan/gopilot/internal/domain"
+)
+
+// memoryURLShortenerRepository keeps short URLs in memory for tests
+type memoryURLShortenerRepository struct {
+	urls   map[string]*domain.ShortURL
+	nextID int64
+}
+
+func newMemoryURLShortenerRepository() *memoryURLShortenerRepository {
+	return &memoryURLShortenerRepository{urls: make(map[string]*domain.ShortURL)}
+}
+
+func (r *memoryURLShortenerRepository) CreateShortURL(_ context.Context, shortURL *domain.ShortURL) error {
+	r.nextID++
+	shortURL.ID = r.nextID
+	stored := *shortURL
+	r.urls[shortURL.Code] = &stored
+	return nil
+}
+
+func (r *memoryURLShortenerRepository) GetShortURLByCode(_ context.Context, code string) (*domain.ShortURL, error) {
+	shortURL, ok := r.urls[code]
+	if !ok {
+		return nil, fmt.Errorf("short URL not found")
+	}
+	stored := *shortURL
+	return &stored, nil
+}
+
+func (r *memoryURLShortenerRepository) byID(id int64) *domain.ShortURL {
+	for _, shortURL := range r.urls {
+		if shortURL.ID == id {
+			return shortURL
+		}
+	}
+	return nil
+}
+
+func (r *memoryURLShortenerRepository) IncrementClicks(_ context.Context, id int64) error {
+	r.byID(id).Clicks++
+	return nil
+}
+
+func (r *memoryURLShortenerRepository) IncrementBotClicks(_ context.Context, id int64) error {
+	r.byID(id).BotClicks++
+	return nil
+}
+
+func (r *memoryURLShortenerRepository) UpdateDestination(_ context.Context, id int64, originalURL string) (*domain.ShortURL, error) {
+	shortURL := r.byID(id)
+	shortURL.OriginalURL = originalURL
+	stored := *shortURL
+	return &stored, nil
+}
+
+func (r *memoryURLShortenerRepository) LogClick(context.Context, *domain.URLClickLog) error {
+	return nil
+}
+
+func (r *memoryURLShortenerRepository) DeleteExpiredURLs(context.Context) error {
+	return nil
+}
+
+func TestUpdateShortURL(t *testing.T) {
+	repo := newMemoryURLShortenerRepository()
+	svc := NewURLShortenerService(repo)
+
+	editable, err := svc.CreateShortURL(context.Background(), &domain.CreateShortURLRequest{
+		OriginalURL: "https://example.com/old",
+		Editable:    boolPtr(true),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create short URL: %v", err)
+	}
+	fixed, err := svc.CreateShortURL(context.Background(), &domain.CreateShortURLRequest{
+		OriginalURL: "https://example.com/fixed",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create short URL: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		token       string
+		originalURL string
+		wantErr     error
+	}{
+		{"wrong token", editable.Code, "0123456789abcdef0123456789abcdef", "https://example.com/new", ErrInvalidEditToken},
+		{"empty token", editable.Code, "", "https://example.com/new", ErrInvalidEditToken},
+		{"not editable", fixed.Code, editable.EditToken, "https://example.com/new", ErrShortURLNotEditable},
+		{"javascript URL", editable.Code, editable.EditToken, "javascript:alert(1)", ErrInvalidDestination},
+		{"ftp URL", editable.Code, editable.EditToken, "ftp://example.com/file", ErrInvalidDestination},
+		{"relative URL", editable.Code, editable.EditToken, "/new", ErrInvalidDestination},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.UpdateShortURL(context.Background(), tt.code, &domain.UpdateShortURLRequest{
+				OriginalURL: tt.originalURL,
+				EditToken:   tt.token,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if got := repo.urls[editable.Code].OriginalURL; got != "https://example.com/old" {
+		t.Errorf("Expected rejected updates to keep the destination, got %q", got)
+	}
+
+	updated, err := svc.UpdateShortURL(context.Background(), editable.Code, &domain.UpdateShortURLRequest{
+		OriginalURL: "https://example.com/new",
+		EditToken:   editable.EditToken,
+	})
+	if err != nil {
+		t.Fatalf("Failed to update short URL: %v", err)
+	}
+	if updated.OriginalURL != "https://example.com/new" {
+		t.Errorf("Expected updated destination, got %q", updated.OriginalURL)
+	}
+
+	stored, err := svc.GetShortURL(context.Background(), editable.Code)
+	if err != nil {
+		t.Fatalf("Failed to get short URL: %v", err)
+	}
+	if stored.OriginalURL != "https://example.com/new" {
+		t.Errorf("Expected stored destination to change, got %q", stored.OriginalURL)
+	}
+}